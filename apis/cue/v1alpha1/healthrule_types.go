@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HealthRule is the custom health evaluation rule for objects of one kind
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:printcolumn:name="API-VERSION",type=string,JSONPath=`.spec.apiVersion`
+// +kubebuilder:printcolumn:name="KIND",type=string,JSONPath=`.spec.kind`
+// +kubebuilder:resource:shortName={hr,healthrule}
+type HealthRule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HealthRuleSpec `json:"spec"`
+}
+
+// HealthRuleSpec the spec for HealthRule
+type HealthRuleSpec struct {
+	// APIVersion of the objects to evaluate
+	APIVersion string `json:"apiVersion"`
+	// Kind of the objects to evaluate
+	Kind string `json:"kind"`
+	// Template is the CUE template to evaluate the health, the object is
+	// available as context.data and the template should output status and
+	// message
+	Template string `json:"template"`
+}
+
+// HealthRuleList list for HealthRule
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type HealthRuleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []HealthRule `json:"items"`
+}
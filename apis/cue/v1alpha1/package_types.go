@@ -52,11 +52,26 @@ const (
 	ProtocolHTTPS ProviderProtocol = "https"
 )
 
+// ProviderEncoding the encoding of the parameters and returns exchanged with
+// the external Provider
+// +kubebuilder:validation:Enum=json;cue
+type ProviderEncoding string
+
+const (
+	// EncodingJSON the parameters are sent as json, the default encoding
+	EncodingJSON ProviderEncoding = "json"
+	// EncodingCUE the parameters are sent as CUE source, which keeps the
+	// definitions, defaults and incomplete values
+	EncodingCUE ProviderEncoding = "cue"
+)
+
 // Provider the external Provider in Package for cuex to run functions
 type Provider struct {
 	Protocol ProviderProtocol `json:"protocol"`
 	Endpoint string           `json:"endpoint"`
 	// +optional
+	Encoding ProviderEncoding `json:"encoding,omitempty"`
+	// +optional
 	// +kubebuilder:default={}
 	Header map[string]string `json:"header,omitempty"`
 }
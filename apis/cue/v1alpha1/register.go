@@ -40,6 +40,12 @@ const PackageResource = "packages"
 // PackageGroupVersionResource GroupVersionResource for Package
 var PackageGroupVersionResource = GroupVersion.WithResource(PackageResource)
 
+// HealthRuleResource resource name for HealthRule
+const HealthRuleResource = "healthrules"
+
+// HealthRuleGroupVersionResource GroupVersionResource for HealthRule
+var HealthRuleGroupVersionResource = GroupVersion.WithResource(HealthRuleResource)
+
 func init() {
 	apiruntime.Must(AddToScheme(scheme.Scheme))
 }
@@ -47,6 +53,6 @@ func init() {
 // AddToScheme .
 var AddToScheme = func(scheme *runtime.Scheme) error {
 	metav1.AddToGroupVersion(scheme, GroupVersion)
-	scheme.AddKnownTypes(GroupVersion, &Package{}, &PackageList{})
+	scheme.AddKnownTypes(GroupVersion, &Package{}, &PackageList{}, &HealthRule{}, &HealthRuleList{})
 	return nil
 }
@@ -0,0 +1,361 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cuex
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/build"
+	"cuelang.org/go/cue/cuecontext"
+	cueerrors "cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/cue/token"
+)
+
+// CompletionItemKind the kind of the completion item
+type CompletionItemKind string
+
+const (
+	// CompletionItemKindPackage the importable package
+	CompletionItemKindPackage CompletionItemKind = "package"
+	// CompletionItemKindDefinition the definition, such as `#Apply`
+	CompletionItemKindDefinition CompletionItemKind = "definition"
+	// CompletionItemKindField the regular or optional field
+	CompletionItemKindField CompletionItemKind = "field"
+)
+
+const usageTag = "+usage="
+
+// Cursor the 1-based line and column in the source
+type Cursor struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// CompletionItem the candidate for completion
+type CompletionItem struct {
+	Label         string             `json:"label"`
+	Kind          CompletionItemKind `json:"kind"`
+	Detail        string             `json:"detail,omitempty"`
+	Documentation string             `json:"documentation,omitempty"`
+}
+
+// Diagnostic the problem found in the source
+type Diagnostic struct {
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+// AssistResult the result for assisting the source at the cursor
+type AssistResult struct {
+	// Imports the packages available for import
+	Imports []CompletionItem `json:"imports"`
+	// Path the path of the value under the cursor
+	Path string `json:"path,omitempty"`
+	// Type the kind of the value under the cursor
+	Type string `json:"type,omitempty"`
+	// Documentation the +usage docs of the value under the cursor
+	Documentation string `json:"documentation,omitempty"`
+	// Fields the fields available at the cursor
+	Fields []CompletionItem `json:"fields"`
+	// Diagnostics the errors found in the source
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+var importLinePattern = regexp.MustCompile(`^\s*(import\s+)?([A-Za-z_][\w]*\s+)?"([^"]*)$`)
+
+// Assist returns the completion, the docs and the diagnostics for the source
+// at the cursor. Provider functions are not executed.
+func (in *Compiler) Assist(_ context.Context, src string, cursor Cursor) (*AssistResult, error) {
+	imports := in.PackageManager.GetImports()
+	offset := cursorOffset(src, cursor)
+	lineStart := strings.LastIndex(src[:offset], "\n") + 1
+	res := &AssistResult{Imports: []CompletionItem{}, Fields: []CompletionItem{}}
+
+	f, val, err := in.build(src, imports)
+	res.Diagnostics = toDiagnostics(err)
+	if err == nil {
+		res.Diagnostics = toDiagnostics(val.Validate())
+	} else {
+		// retry with the line under the cursor blanked, which is usually
+		// the one being edited
+		lineEnd := len(src)
+		if idx := strings.Index(src[offset:], "\n"); idx >= 0 {
+			lineEnd = offset + idx
+		}
+		if f, val, err = in.build(src[:lineStart]+strings.Repeat(" ", lineEnd-lineStart)+src[lineEnd:], imports); err != nil {
+			f = nil
+		}
+	}
+
+	linePrefix := src[lineStart:offset]
+	if m := importLinePattern.FindStringSubmatch(linePrefix); m != nil {
+		res.Imports = importsOf(imports, m[3])
+		return res, nil
+	}
+	res.Imports = importsOf(imports, "")
+	if f == nil {
+		return res, nil
+	}
+
+	start, end := wordRange(src, offset)
+	word, chain := src[start:end], src[start:offset]
+	scope := enclosingPath(f.Decls, offset)
+	a := &assistant{val: val, imports: imports, aliases: importAliases(f), scope: scope}
+	if idx := strings.LastIndex(chain, "."); idx >= 0 {
+		if v, ok := a.resolve(chain[:idx]); ok {
+			res.Fields = fieldsOf(v, chain[idx+1:], true)
+		}
+	} else if v := val.LookupPath(cue.MakePath(scope...)); v.Exists() {
+		res.Fields = fieldsOf(v, chain, strings.HasPrefix(chain, "#"))
+	}
+
+	if word == "" {
+		return res, nil
+	}
+	var target cue.Value
+	var found bool
+	if !strings.Contains(word, ".") && strings.HasPrefix(strings.TrimSpace(src[end:]), ":") {
+		target = val.LookupPath(cue.MakePath(append(scope, selectorOf(word))...))
+		found = target.Exists()
+	} else {
+		target, found = a.resolve(word)
+	}
+	if found {
+		res.Path = target.Path().String()
+		res.Type = target.IncompleteKind().String()
+		res.Documentation = usageOf(target)
+	}
+	return res, nil
+}
+
+func (in *Compiler) build(src string, imports []*build.Instance) (*ast.File, cue.Value, error) {
+	f, err := parser.ParseFile("-", src, parser.ParseComments)
+	if err != nil {
+		return nil, cue.Value{}, err
+	}
+	bi := build.NewContext().NewInstance("", nil)
+	bi.Imports = imports
+	if err = bi.AddSyntax(f); err != nil {
+		return nil, cue.Value{}, err
+	}
+	val := cuecontext.New().BuildInstance(bi)
+	return f, val, val.Err()
+}
+
+type assistant struct {
+	val     cue.Value
+	imports []*build.Instance
+	aliases map[string]string
+	scope   []cue.Selector
+}
+
+// resolve find the value referred by the dot separated chain, either from the
+// imported package or from the enclosing scopes
+func (in *assistant) resolve(chain string) (cue.Value, bool) {
+	labels := strings.Split(chain, ".")
+	var sels []cue.Selector
+	for _, label := range labels {
+		if label == "" {
+			return cue.Value{}, false
+		}
+		sels = append(sels, selectorOf(label))
+	}
+	if importPath, ok := in.aliases[labels[0]]; ok {
+		for _, inst := range in.imports {
+			if inst.ImportPath == importPath {
+				v := cuecontext.New().BuildInstance(inst).LookupPath(cue.MakePath(sels[1:]...))
+				return v, v.Exists()
+			}
+		}
+		return cue.Value{}, false
+	}
+	for i := len(in.scope); i >= 0; i-- {
+		if v := in.val.LookupPath(cue.MakePath(append(append([]cue.Selector{}, in.scope[:i]...), sels...)...)); v.Exists() {
+			return v, true
+		}
+	}
+	return cue.Value{}, false
+}
+
+func cursorOffset(src string, cursor Cursor) int {
+	offset := 0
+	for line := 1; line < cursor.Line; line++ {
+		idx := strings.Index(src[offset:], "\n")
+		if idx < 0 {
+			return len(src)
+		}
+		offset += idx + 1
+	}
+	lineEnd := len(src)
+	if idx := strings.Index(src[offset:], "\n"); idx >= 0 {
+		lineEnd = offset + idx
+	}
+	return min(offset+max(cursor.Column-1, 0), lineEnd)
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '$' || c == '#' || c == '.' ||
+		'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+// wordRange returns the range of the dot separated identifiers under the
+// cursor, the end stops at the end of the identifier containing the cursor
+func wordRange(src string, offset int) (int, int) {
+	start, end := offset, offset
+	for start > 0 && isWordChar(src[start-1]) {
+		start--
+	}
+	for end < len(src) && isWordChar(src[end]) && src[end] != '.' {
+		end++
+	}
+	return start, end
+}
+
+func selectorOf(label string) cue.Selector {
+	if strings.HasPrefix(label, "#") {
+		return cue.Def(label)
+	}
+	return cue.Str(label)
+}
+
+// enclosingPath returns the path of the struct containing the offset
+func enclosingPath(decls []ast.Decl, offset int) []cue.Selector {
+	for _, decl := range decls {
+		field, ok := decl.(*ast.Field)
+		if !ok {
+			continue
+		}
+		label, _, err := ast.LabelName(field.Label)
+		if err != nil {
+			continue
+		}
+		if lit := structAt(field.Value, offset); lit != nil {
+			return append([]cue.Selector{selectorOf(label)}, enclosingPath(lit.Elts, offset)...)
+		}
+	}
+	return nil
+}
+
+func structAt(expr ast.Expr, offset int) *ast.StructLit {
+	switch x := expr.(type) {
+	case *ast.StructLit:
+		if x.Lbrace.IsValid() && x.Lbrace.Offset() < offset && offset <= x.Rbrace.Offset() {
+			return x
+		}
+		if !x.Lbrace.IsValid() && x.Pos().Offset() <= offset && offset <= x.End().Offset() {
+			return x
+		}
+	case *ast.BinaryExpr:
+		if lit := structAt(x.X, offset); lit != nil {
+			return lit
+		}
+		return structAt(x.Y, offset)
+	}
+	return nil
+}
+
+func importAliases(f *ast.File) map[string]string {
+	aliases := map[string]string{}
+	for _, spec := range f.Imports {
+		importPath := strings.Trim(spec.Path.Value, `"`)
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		aliases[name] = importPath
+	}
+	return aliases
+}
+
+func importsOf(imports []*build.Instance, prefix string) []CompletionItem {
+	items := []CompletionItem{}
+	for _, inst := range imports {
+		if strings.HasPrefix(inst.ImportPath, prefix) {
+			items = append(items, CompletionItem{Label: inst.ImportPath, Kind: CompletionItemKindPackage, Detail: inst.PkgName})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
+
+func fieldsOf(v cue.Value, prefix string, definitions bool) []CompletionItem {
+	items := []CompletionItem{}
+	it, err := v.Fields(cue.Optional(true), cue.Definitions(definitions))
+	if err != nil {
+		return items
+	}
+	for it.Next() {
+		sel := it.Selector()
+		label := strings.TrimRight(sel.String(), "?!")
+		if !strings.HasPrefix(label, prefix) {
+			continue
+		}
+		kind := CompletionItemKindField
+		if sel.IsDefinition() {
+			kind = CompletionItemKindDefinition
+		}
+		items = append(items, CompletionItem{
+			Label:         label,
+			Kind:          kind,
+			Detail:        it.Value().IncompleteKind().String(),
+			Documentation: usageOf(it.Value()),
+		})
+	}
+	return items
+}
+
+// usageOf returns the +usage docs of the value
+func usageOf(v cue.Value) string {
+	var usages []string
+	for _, cg := range v.Doc() {
+		for _, line := range strings.Split(cg.Text(), "\n") {
+			if usage, ok := strings.CutPrefix(strings.TrimSpace(line), usageTag); ok {
+				usages = append(usages, usage)
+			}
+		}
+	}
+	return strings.Join(usages, "\n")
+}
+
+func toDiagnostics(err error) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, e := range cueerrors.Errors(err) {
+		d := Diagnostic{Message: e.Error(), Path: strings.Join(e.Path(), ".")}
+		for _, pos := range append([]token.Pos{e.Position()}, e.InputPositions()...) {
+			if pos.IsValid() {
+				d.Line, d.Column = pos.Line(), pos.Column()
+				break
+			}
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
+
+// Assist use cuex default compiler to assist the source at the cursor
+func Assist(ctx context.Context, src string, cursor Cursor) (*AssistResult, error) {
+	return DefaultCompiler.Get().Assist(ctx, src, cursor)
+}
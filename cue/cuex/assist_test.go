@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cuex_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/pkg/cue/cuex"
+)
+
+func labelsOf(items []cuex.CompletionItem) []string {
+	var labels []string
+	for _, item := range items {
+		labels = append(labels, item.Label)
+	}
+	return labels
+}
+
+func TestAssist(t *testing.T) {
+	compiler := cuex.NewCompilerWithDefaultInternalPackages()
+	ctx := context.Background()
+
+	t.Run("imports", func(t *testing.T) {
+		res, err := compiler.Assist(ctx, "import (\n\t\"vela/k\n)\n", cuex.Cursor{Line: 2, Column: 9})
+		require.NoError(t, err)
+		require.Equal(t, []string{"vela/kube"}, labelsOf(res.Imports))
+		require.NotEmpty(t, res.Diagnostics)
+	})
+
+	t.Run("package definitions", func(t *testing.T) {
+		src := "import \"vela/kube\"\n\napply: kube.#A\n"
+		res, err := compiler.Assist(ctx, src, cuex.Cursor{Line: 3, Column: 15})
+		require.NoError(t, err)
+		require.Equal(t, []string{"#Apply"}, labelsOf(res.Fields))
+		require.Equal(t, cuex.CompletionItemKindDefinition, res.Fields[0].Kind)
+		require.Contains(t, labelsOf(res.Imports), "vela/http")
+	})
+
+	t.Run("fields with docs", func(t *testing.T) {
+		src := "import \"vela/kube\"\n\napply: kube.#Apply & {\n\t$params: {\n\t\tcl\n\t}\n}\n"
+		res, err := compiler.Assist(ctx, src, cuex.Cursor{Line: 5, Column: 5})
+		require.NoError(t, err)
+		require.Equal(t, []string{"cluster"}, labelsOf(res.Fields))
+		require.Equal(t, "The cluster to use", res.Fields[0].Documentation)
+	})
+
+	t.Run("hover", func(t *testing.T) {
+		src := "import \"vela/kube\"\n\napply: kube.#Apply & {\n\t$params: resource: {}\n}\n"
+		res, err := compiler.Assist(ctx, src, cuex.Cursor{Line: 4, Column: 4})
+		require.NoError(t, err)
+		require.Equal(t, "apply.$params", res.Path)
+		require.Equal(t, "The params of this action", res.Documentation)
+		require.Empty(t, res.Diagnostics)
+
+		res, err = compiler.Assist(ctx, src, cuex.Cursor{Line: 3, Column: 15})
+		require.NoError(t, err)
+		require.Equal(t, "#Apply", res.Path)
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		res, err := compiler.Assist(ctx, "a: 1\na: 2\n", cuex.Cursor{Line: 1, Column: 1})
+		require.NoError(t, err)
+		require.Len(t, res.Diagnostics, 1)
+		require.Equal(t, "a", res.Diagnostics[0].Path)
+		require.NotZero(t, res.Diagnostics[0].Line)
+	})
+}
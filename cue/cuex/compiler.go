@@ -26,13 +26,23 @@ import (
 	"cuelang.org/go/cue/cuecontext"
 	"cuelang.org/go/cue/parser"
 	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	"github.com/kubevela/pkg/cue/cuex/providers/base64"
+	"github.com/kubevela/pkg/cue/cuex/providers/crypto"
 	cueext "github.com/kubevela/pkg/cue/cuex/providers/cue"
+	"github.com/kubevela/pkg/cue/cuex/providers/format"
+	"github.com/kubevela/pkg/cue/cuex/providers/health"
 	"github.com/kubevela/pkg/cue/cuex/providers/http"
 	"github.com/kubevela/pkg/cue/cuex/providers/kube"
+	"github.com/kubevela/pkg/cue/cuex/providers/render"
+	"github.com/kubevela/pkg/cue/cuex/providers/secret"
+	velatime "github.com/kubevela/pkg/cue/cuex/providers/time"
+	"github.com/kubevela/pkg/cue/cuex/providers/topology"
 	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
 	"github.com/kubevela/pkg/cue/util"
 	"github.com/kubevela/pkg/util/runtime"
@@ -59,6 +69,7 @@ func (in *Compiler) CompileString(ctx context.Context, src string) (cue.Value, e
 type CompileConfig struct {
 	ResolveProviderFunctions bool
 	PreResolveMutators       []func(context.Context, string) (string, error)
+	Clock                    clock.PassiveClock
 }
 
 // NewCompileConfig create new CompileConfig
@@ -105,6 +116,20 @@ func (in *withExtraData) ApplyTo(cfg *CompileConfig) {
 	})
 }
 
+// WithClock set the time source used by provider functions during resolve
+func WithClock(c clock.PassiveClock) CompileOption {
+	return &withClock{clock: c}
+}
+
+type withClock struct {
+	clock clock.PassiveClock
+}
+
+// ApplyTo .
+func (in *withClock) ApplyTo(cfg *CompileConfig) {
+	cfg.Clock = in.clock
+}
+
 var _ CompileOption = DisableResolveProviderFunctions{}
 
 // DisableResolveProviderFunctions disable ResolveProviderFunctions
@@ -116,8 +141,15 @@ func (in DisableResolveProviderFunctions) ApplyTo(cfg *CompileConfig) {
 }
 
 // CompileStringWithOptions compile given cue string with extra options
-func (in *Compiler) CompileStringWithOptions(ctx context.Context, src string, opts ...CompileOption) (cue.Value, error) {
-	var err error
+func (in *Compiler) CompileStringWithOptions(ctx context.Context, src string, opts ...CompileOption) (_ cue.Value, err error) {
+	ctx = cuexruntime.WithSensitiveValues(ctx)
+	ctx, span := cuexruntime.StartSpan(ctx, "cuex.Compile")
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, cuexruntime.Redact(ctx, err.Error()))
+		}
+		span.End()
+	}()
 	cfg := NewCompileConfig(opts...)
 	bi := build.NewContext().NewInstance("", nil)
 	bi.Imports = in.PackageManager.GetImports()
@@ -135,15 +167,27 @@ func (in *Compiler) CompileStringWithOptions(ctx context.Context, src string, op
 	}
 	val := cuecontext.New().BuildInstance(bi)
 	if cfg.ResolveProviderFunctions {
+		if cfg.Clock != nil {
+			ctx = cuexruntime.WithClock(ctx, cfg.Clock)
+		}
 		return in.Resolve(ctx, val)
 	}
 	return val, nil
 }
 
 // Resolve runs the resolve process by calling provider functions
-func (in *Compiler) Resolve(ctx context.Context, value cue.Value) (cue.Value, error) {
-	newValue := value
+func (in *Compiler) Resolve(ctx context.Context, value cue.Value) (_ cue.Value, err error) {
+	ctx = cuexruntime.WithSensitiveValues(ctx)
+	ctx, span := cuexruntime.StartSpan(ctx, "cuex.Resolve")
 	executed := map[string]bool{}
+	defer func() {
+		span.SetAttributes(attribute.Int("cuex.resolve.calls", len(executed)))
+		if err != nil {
+			span.SetStatus(codes.Error, cuexruntime.Redact(ctx, err.Error()))
+		}
+		span.End()
+	}()
+	newValue := value
 	providers := in.PackageManager.GetProviders()
 	for {
 		if ddl, ok := ctx.Deadline(); ok && ddl.Before(time.Now()) {
@@ -174,9 +218,9 @@ func (in *Compiler) Resolve(ctx context.Context, value cue.Value) (cue.Value, er
 		if f == nil {
 			return newValue, ProviderFnNotFoundErr{Provider: prdName, Fn: fn}
 		}
-		val, err := f.Call(ctx, *next)
+		val, err := in.call(ctx, f, prdName, fn, *next)
 		if err != nil {
-			return newValue, NewFunctionCallError(val, err)
+			return newValue, err
 		}
 		newValue = newValue.FillPath(next.Path(), val)
 		executed[next.Path().String()] = true
@@ -184,6 +228,30 @@ func (in *Compiler) Resolve(ctx context.Context, value cue.Value) (cue.Value, er
 	return newValue, nil
 }
 
+func (in *Compiler) call(ctx context.Context, f cuexruntime.ProviderFn, prdName string, fn string, v cue.Value) (cue.Value, error) {
+	ctx, span := cuexruntime.StartSpan(ctx, prdName+"."+fn)
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("cuex.provider", prdName),
+		attribute.String("cuex.function", fn),
+		attribute.String("cuex.path", v.Path().String()),
+	)
+	if authorizer := cuexruntime.GetAuthorizer(ctx); authorizer != nil {
+		if err := authorizer.Authorize(ctx, prdName, fn); err != nil {
+			span.SetStatus(codes.Error, cuexruntime.Redact(ctx, err.Error()))
+			return v, err
+		}
+	}
+	val, err := f.Call(ctx, v)
+	if err != nil {
+		callErr := NewRedactedFunctionCallError(ctx, val, err)
+		callErr.Provider, callErr.Function = prdName, fn
+		span.SetStatus(codes.Error, callErr.Err.Error())
+		return val, callErr
+	}
+	return val, nil
+}
+
 // DefaultCompiler compiler for cuex to compile
 var DefaultCompiler = singleton.NewSingleton[*Compiler](func() *Compiler {
 	compiler := NewCompilerWithDefaultInternalPackages()
@@ -211,12 +279,24 @@ func NewCompilerWithInternalPackages(packages ...cuexruntime.Package) *Compiler
 
 // NewCompilerWithDefaultInternalPackages create compiler with default internal packages
 func NewCompilerWithDefaultInternalPackages() *Compiler {
-	return NewCompilerWithInternalPackages(
+	var compiler *Compiler
+	compileTopologyRules := func(ctx context.Context, rules string, data map[string]interface{}) (cue.Value, error) {
+		return compiler.CompileStringWithOptions(ctx, rules, WithExtraData("context", data))
+	}
+	compiler = NewCompilerWithInternalPackages(
 		base64.Package,
 		http.Package,
 		kube.Package,
 		cueext.Package,
+		secret.Package,
+		crypto.Package,
+		render.Package,
+		format.Package,
+		velatime.Package,
+		health.Package,
+		topology.NewPackage(compileTopologyRules),
 	)
+	return compiler
 }
 
 var (
@@ -230,7 +310,23 @@ var (
 func AddFlags(set *pflag.FlagSet) {
 	set.BoolVarP(&EnableExternalPackageForDefaultCompiler, "enable-external-cue-package", "", EnableExternalPackageForDefaultCompiler, "enable load external package for cuex default compiler")
 	set.BoolVarP(&EnableExternalPackageWatchForDefaultCompiler, "list-watch-external-cue-package", "", EnableExternalPackageWatchForDefaultCompiler, "enable watch external package changes for cuex default compiler")
+	set.BoolVarP(&health.EnableCustomHealthRules, "enable-custom-health-rules", "", health.EnableCustomHealthRules, "enable load custom health rules for the health package of cuex")
+	set.BoolVarP(&health.EnableCustomHealthRulesWatch, "list-watch-custom-health-rules", "", health.EnableCustomHealthRulesWatch, "enable watch custom health rules changes for the health package of cuex")
 	set.BoolVarP(&cuexruntime.DefaultClientInsecureSkipVerify, "cuex-external-provider-insecure-skip-verify", "", cuexruntime.DefaultClientInsecureSkipVerify, "Set if the default external provider client of cuex should skip insecure verify")
+	set.StringVarP(&cuexruntime.DefaultClientCAFile, "cuex-external-provider-ca-file", "", cuexruntime.DefaultClientCAFile, "ca file for verifying the external provider servers of cuex, overrides --cuex-external-provider-insecure-skip-verify")
+	set.StringVarP(&cuexruntime.DefaultClientCertFile, "cuex-external-provider-cert-file", "", cuexruntime.DefaultClientCertFile, "client certificate file sent to the external provider servers of cuex")
+	set.StringVarP(&cuexruntime.DefaultClientKeyFile, "cuex-external-provider-key-file", "", cuexruntime.DefaultClientKeyFile, "key file of the client certificate sent to the external provider servers of cuex")
+	set.StringVarP(&cuexruntime.DefaultClientTokenFile, "cuex-external-provider-token-file", "", cuexruntime.DefaultClientTokenFile, "file of the bearer token sent to the external provider servers of cuex, reloaded when changed")
+	set.StringVarP(&render.HelmChartDir, "cuex-helm-chart-dir", "", render.HelmChartDir, "directory of the local helm charts that can be rendered by the render package of cuex, rendering helm charts is disabled if empty")
+	cuexruntime.AddContextFlags(set)
+	cuexruntime.AddTracingFlags(set)
+}
+
+// SetupTracing set up the tracing of cuex with the options of the flags
+// registered by AddFlags. It should be called after the flags are parsed and
+// the returned function should be called to flush the spans before exit.
+func SetupTracing(ctx context.Context) (func(context.Context) error, error) {
+	return cuexruntime.SetupTracing(ctx, cuexruntime.DefaultTracingOptions)
 }
 
 // CompileString use cuex default compiler to compile cue string
@@ -19,6 +19,8 @@ package cuex_test
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -26,9 +28,15 @@ import (
 	"cuelang.org/go/cue/cuecontext"
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
 	kuberuntime "k8s.io/apimachinery/pkg/runtime"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	"github.com/kubevela/pkg/cue/cuex"
+	"github.com/kubevela/pkg/cue/cuex/providers"
 	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
 	"github.com/kubevela/pkg/cue/util"
 	"github.com/kubevela/pkg/util/runtime"
@@ -168,3 +176,123 @@ func TestWithExtraData(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveRedactSensitiveValues(t *testing.T) {
+	compiler := cuex.NewCompilerWithInternalPackages(
+		runtime.Must(cuexruntime.NewInternalPackage("test", "", map[string]cuexruntime.ProviderFn{
+			"read": cuexruntime.GenericProviderFn[providers.Params[string], providers.Returns[string]](func(ctx context.Context, t *providers.Params[string]) (*providers.Returns[string], error) {
+				cuexruntime.MarkSensitive(ctx, t.Params)
+				return &providers.Returns[string]{Returns: t.Params}, nil
+			}),
+			"err": cuexruntime.GenericProviderFn[providers.Params[string], providers.Returns[string]](func(ctx context.Context, t *providers.Params[string]) (*providers.Returns[string], error) {
+				return nil, fmt.Errorf("bad input %s", t.Params)
+			}),
+		})),
+	)
+	_, err := compiler.CompileString(context.Background(), `
+		read: {
+			#do: "read"
+			#provider: "test"
+			$params: "p@ss"
+		}
+		use: {
+			#do: "err"
+			#provider: "test"
+			$params: read.$returns
+		}
+	`)
+	require.Error(t, err)
+	callErr := &cuex.FunctionCallError{}
+	require.ErrorAs(t, err, callErr)
+	require.NotContains(t, err.Error(), "p@ss")
+	require.Contains(t, callErr.Value, `$params:   "<redacted>"`)
+	require.Equal(t, "bad input <redacted>", callErr.Err.Error())
+}
+
+func TestCompileWithClock(t *testing.T) {
+	compiler := cuex.NewCompilerWithDefaultInternalPackages()
+	now := time.Date(2023, 5, 1, 10, 30, 0, 0, time.UTC)
+	val, err := compiler.CompileStringWithOptions(context.Background(), `
+		import "vela/time"
+		now: time.#Now & {}
+		next: time.#CronNext & { $params: { schedule: "0 12 * * *", count: 2 } }
+	`, cuex.WithClock(clocktesting.NewFakePassiveClock(now)))
+	require.NoError(t, err)
+	s, err := val.LookupPath(cue.ParsePath("now.$returns")).String()
+	require.NoError(t, err)
+	require.Equal(t, "2023-05-01T10:30:00Z", s)
+	var next []string
+	require.NoError(t, val.LookupPath(cue.ParsePath("next.$returns")).Decode(&next))
+	require.Equal(t, []string{"2023-05-01T12:00:00Z", "2023-05-02T12:00:00Z"}, next)
+}
+
+func TestCompileSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder))
+	defer func(tp oteltrace.TracerProvider) { otel.SetTracerProvider(tp) }(otel.GetTracerProvider())
+	otel.SetTracerProvider(provider)
+
+	compiler := cuex.NewCompilerWithDefaultInternalPackages()
+	_, err := compiler.CompileString(context.Background(), `
+		import "vela/base64"
+		enc: base64.#Encode & { $params: "example" }
+	`)
+	require.NoError(t, err)
+
+	spans := map[string]tracesdk.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	require.Contains(t, spans, "cuex.Compile")
+	require.Contains(t, spans, "cuex.Resolve")
+	require.Contains(t, spans, "base64.encode")
+	require.Equal(t, spans["cuex.Compile"].SpanContext().SpanID(), spans["cuex.Resolve"].Parent().SpanID())
+	require.Equal(t, spans["cuex.Resolve"].SpanContext().SpanID(), spans["base64.encode"].Parent().SpanID())
+}
+
+func TestSetupTracing(t *testing.T) {
+	defer func(tp oteltrace.TracerProvider) { otel.SetTracerProvider(tp) }(otel.GetTracerProvider())
+	defer func(opts cuexruntime.TracingOptions) { cuexruntime.DefaultTracingOptions = opts }(cuexruntime.DefaultTracingOptions)
+	file := filepath.Join(t.TempDir(), "spans.json")
+	set := pflag.NewFlagSet("-", 0)
+	cuex.AddFlags(set)
+	require.NoError(t, set.Parse([]string{"--cuex-tracing-exporter=file", "--cuex-tracing-file=" + file}))
+	shutdown, err := cuex.SetupTracing(context.Background())
+	require.NoError(t, err)
+	_, err = cuex.NewCompilerWithDefaultInternalPackages().CompileString(context.Background(), `
+		import "vela/base64"
+		enc: base64.#Encode & { $params: "example" }
+	`)
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+	bs, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.Contains(t, string(bs), `"Name":"cuex.Compile"`)
+}
+
+func TestCompileSpansRedactSensitiveValues(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder))
+	defer func(tp oteltrace.TracerProvider) { otel.SetTracerProvider(tp) }(otel.GetTracerProvider())
+	otel.SetTracerProvider(provider)
+
+	ctx := cuexruntime.WithSensitiveValues(context.Background())
+	cuexruntime.MarkSensitive(ctx, "p@ss")
+	ctx = cuexruntime.WithAuthorizer(ctx, cuexruntime.AuthorizerFunc(func(context.Context, string, string) error {
+		return fmt.Errorf("token p@ss is not allowed")
+	}))
+	compiler := cuex.NewCompilerWithDefaultInternalPackages()
+	_, err := compiler.CompileString(ctx, `
+		import "vela/base64"
+		enc: base64.#Encode & { $params: "example" }
+	`)
+	require.Error(t, err)
+	statuses := map[string]string{}
+	for _, span := range recorder.Ended() {
+		statuses[span.Name()] = span.Status().Description
+	}
+	require.Len(t, statuses, 3)
+	for name, desc := range statuses {
+		require.Equal(t, "token <redacted> is not allowed", desc, name)
+	}
+}
@@ -17,10 +17,12 @@ limitations under the License.
 package cuex
 
 import (
+	"context"
 	"fmt"
 
 	"cuelang.org/go/cue"
 
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
 	"github.com/kubevela/pkg/cue/util"
 )
 
@@ -44,9 +46,11 @@ func (e ProviderFnNotFoundErr) Error() string {
 
 // FunctionCallError error for executing provider function
 type FunctionCallError struct {
-	Path  string
-	Value string
-	Err   error
+	Path     string
+	Value    string
+	Provider string
+	Function string
+	Err      error
 }
 
 // Error .
@@ -54,6 +58,11 @@ func (e FunctionCallError) Error() string {
 	return fmt.Sprintf("function call error for %s: %s (value: %s)", e.Path, e.Err.Error(), e.Value)
 }
 
+// Unwrap .
+func (e FunctionCallError) Unwrap() error {
+	return e.Err
+}
+
 // NewFunctionCallError create a new error for executing resolved function call
 func NewFunctionCallError(v cue.Value, err error) FunctionCallError {
 	path := v.Path().String()
@@ -64,6 +73,33 @@ func NewFunctionCallError(v cue.Value, err error) FunctionCallError {
 	return FunctionCallError{Path: path, Value: s, Err: err}
 }
 
+// NewRedactedFunctionCallError create a new error for executing resolved
+// function call, with the sensitive values recorded in the context redacted
+func NewRedactedFunctionCallError(ctx context.Context, v cue.Value, err error) FunctionCallError {
+	e := NewFunctionCallError(v, err)
+	e.Value = cuexruntime.Redact(ctx, e.Value)
+	if msg := cuexruntime.Redact(ctx, err.Error()); msg != err.Error() {
+		e.Err = redactedErr{error: err, msg: msg}
+	}
+	return e
+}
+
+// redactedErr wraps the error with sensitive values hidden from its message
+type redactedErr struct {
+	error
+	msg string
+}
+
+// Error .
+func (e redactedErr) Error() string {
+	return e.msg
+}
+
+// Unwrap .
+func (e redactedErr) Unwrap() error {
+	return e.error
+}
+
 // ResolveTimeoutErr error when Resolve process timeout
 type ResolveTimeoutErr struct{}
 
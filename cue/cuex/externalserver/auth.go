@@ -0,0 +1,208 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/emicklei/go-restful/v3"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/request/bearertoken"
+	"k8s.io/apiserver/pkg/authentication/request/union"
+	x509request "k8s.io/apiserver/pkg/authentication/request/x509"
+	"k8s.io/apiserver/pkg/authentication/token/cache"
+	"k8s.io/apiserver/pkg/authentication/token/tokenfile"
+	"k8s.io/apiserver/pkg/authentication/user"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubevela/pkg/util/singleton"
+)
+
+// FunctionAuthorizer decide if the caller can call the function of the server
+type FunctionAuthorizer interface {
+	Authorize(ctx context.Context, caller user.Info, fn string) error
+}
+
+// FunctionAuthorizerFunc function that implements FunctionAuthorizer
+type FunctionAuthorizerFunc func(ctx context.Context, caller user.Info, fn string) error
+
+// Authorize .
+func (f FunctionAuthorizerFunc) Authorize(ctx context.Context, caller user.Info, fn string) error {
+	return f(ctx, caller, fn)
+}
+
+const (
+	// FunctionACLAll the entry in FunctionACL that applies to all functions
+	FunctionACLAll = "*"
+	// FunctionACLGroupPrefix the prefix for the groups in FunctionACL
+	FunctionACLGroupPrefix = "group:"
+)
+
+// FunctionACL the FunctionAuthorizer that allows the users, or the groups with
+// the `group:` prefix, listed for each function. The `*` entry applies to all
+// functions.
+type FunctionACL map[string][]string
+
+// Authorize .
+func (in FunctionACL) Authorize(_ context.Context, caller user.Info, fn string) error {
+	subjects := append(append([]string{}, in[FunctionACLAll]...), in[fn]...)
+	if slices.Contains(subjects, caller.GetName()) {
+		return nil
+	}
+	for _, group := range caller.GetGroups() {
+		if slices.Contains(subjects, FunctionACLGroupPrefix+group) {
+			return nil
+		}
+	}
+	return fmt.Errorf("user %s cannot call function %s", caller.GetName(), fn)
+}
+
+// CallerFrom retrieve the authenticated caller of the provider function from
+// the context
+func CallerFrom(ctx context.Context) (user.Info, bool) {
+	return genericapirequest.UserFrom(ctx)
+}
+
+type tokenReviewAuthenticator struct {
+	cli       func() kubernetes.Interface
+	audiences []string
+}
+
+// NewTokenReviewAuthenticator create the token authenticator which creates
+// TokenReview with the kubernetes client
+func NewTokenReviewAuthenticator(cli kubernetes.Interface, audiences ...string) authenticator.Token {
+	return &tokenReviewAuthenticator{cli: func() kubernetes.Interface { return cli }, audiences: audiences}
+}
+
+// AuthenticateToken .
+func (in *tokenReviewAuthenticator) AuthenticateToken(ctx context.Context, token string) (*authenticator.Response, bool, error) {
+	review, err := in.cli().AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token, Audiences: in.audiences},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, false, err
+	}
+	if !review.Status.Authenticated {
+		if review.Status.Error != "" {
+			return nil, false, fmt.Errorf("token review failed: %s", review.Status.Error)
+		}
+		return nil, false, nil
+	}
+	info := &user.DefaultInfo{
+		Name:   review.Status.User.Username,
+		UID:    review.Status.User.UID,
+		Groups: review.Status.User.Groups,
+		Extra:  map[string][]string{},
+	}
+	for k, v := range review.Status.User.Extra {
+		info.Extra[k] = v
+	}
+	return &authenticator.Response{User: info, Audiences: review.Status.Audiences}, true, nil
+}
+
+// buildAuthenticator build the authenticator from the client ca file, the
+// token auth file and the token review settings of the server
+func (in *Server) buildAuthenticator() (authenticator.Request, error) {
+	var authenticators []authenticator.Request
+	if in.ClientCAFile != "" {
+		opts, err := x509request.NewStaticVerifierFromFile(in.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, x509request.NewDynamic(opts, x509request.CommonNameUserConversion))
+	}
+	if in.TokenAuthFile != "" {
+		tokens, err := tokenfile.NewCSV(in.TokenAuthFile)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, bearertoken.New(tokens))
+	}
+	if in.TokenReview {
+		var auth authenticator.Token = &tokenReviewAuthenticator{cli: singleton.StaticClient.Get, audiences: in.TokenReviewAudiences}
+		if in.TokenReviewCacheTTL > 0 {
+			auth = cache.New(auth, false, in.TokenReviewCacheTTL, in.TokenReviewCacheTTL)
+		}
+		authenticators = append(authenticators, bearertoken.New(auth))
+	}
+	if len(authenticators) == 0 {
+		return nil, nil
+	}
+	return union.New(authenticators...), nil
+}
+
+// TLSConfig returns the tls config for verifying the client certificates
+// against the client ca file, nil is returned if no client ca file is set
+func (in *Server) TLSConfig() (*tls.Config, error) {
+	if in.ClientCAFile == "" {
+		if in.RequireClientCert {
+			return nil, fmt.Errorf("client ca file is required for requiring the client certificates")
+		}
+		return nil, nil
+	}
+	bs, err := os.ReadFile(in.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bs) {
+		return nil, fmt.Errorf("no valid certificate found in %s", in.ClientCAFile)
+	}
+	cfg := &tls.Config{ClientCAs: pool, ClientAuth: tls.VerifyClientCertIfGiven, MinVersion: tls.VersionTLS12}
+	if in.RequireClientCert {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// authenticate authenticates and authorizes the caller of the function, the
+// caller is attached to the context of the request
+func (in *Server) authenticate(name string, fn restful.RouteFunction) restful.RouteFunction {
+	return func(request *restful.Request, response *restful.Response) {
+		var caller user.Info = &user.DefaultInfo{Name: user.Anonymous, Groups: []string{user.AllUnauthenticated}}
+		if in.Authenticator != nil {
+			resp, ok, err := in.Authenticator.AuthenticateRequest(request.Request)
+			if err == nil && !ok {
+				err = fmt.Errorf("unauthenticated")
+			}
+			if err != nil {
+				_ = response.WriteError(http.StatusUnauthorized, err)
+				return
+			}
+			caller = resp.User
+		}
+		ctx := genericapirequest.WithUser(request.Request.Context(), caller)
+		if in.Authorizer != nil {
+			if err := in.Authorizer.Authorize(ctx, caller, strings.TrimPrefix(name, "/")); err != nil {
+				_ = response.WriteError(http.StatusForbidden, err)
+				return
+			}
+		}
+		request.Request = request.Request.WithContext(ctx)
+		fn(request, response)
+	}
+}
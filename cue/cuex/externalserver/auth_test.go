@@ -0,0 +1,241 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalserver_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	kuberuntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/request/bearertoken"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/kubevela/pkg/cue/cuex/externalserver"
+	"github.com/kubevela/pkg/util/cert"
+	"github.com/kubevela/pkg/util/singleton"
+)
+
+func whoami(ctx context.Context, _ *val) (*val, error) {
+	caller, ok := externalserver.CallerFrom(ctx)
+	if !ok {
+		return nil, fmt.Errorf("no caller")
+	}
+	return &val{V: caller.GetName()}, nil
+}
+
+func newAuthTestServer() *externalserver.Server {
+	return externalserver.NewServer("/", map[string]externalserver.ServerProviderFn{
+		"foo":    externalserver.GenericServerProviderFn[val, val](foo),
+		"whoami": externalserver.GenericServerProviderFn[val, val](whoami),
+	})
+}
+
+func serve(t *testing.T, server *externalserver.Server) (string, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server.Addr = listener.Addr().String()
+	require.NoError(t, listener.Close())
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Serve(ctx) }()
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", server.Addr)
+		if err == nil {
+			_ = conn.Close()
+		}
+		return err == nil
+	}, 5*time.Second, 100*time.Millisecond)
+	return server.Addr, func() {
+		cancel()
+		require.NoError(t, <-errCh)
+	}
+}
+
+func call(t *testing.T, cli *http.Client, url string, token string) (int, string) {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(`{"v":"x"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := cli.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	bs, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(bs)
+}
+
+func TestServerTokenAuthentication(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "tokens.csv")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("alice-token,alice,1,\"dev\"\nbob-token,bob,2,\"ops\"\n"), 0600))
+	server := newAuthTestServer()
+	server.TLS = false
+	server.TokenAuthFile = tokenFile
+	server.Authorizer = externalserver.FunctionACL{
+		externalserver.FunctionACLAll: {"group:ops"},
+		"whoami":                      {"alice"},
+	}
+	addr, stop := serve(t, server)
+	defer stop()
+	endpoint := "http://" + addr
+
+	code, _ := call(t, http.DefaultClient, endpoint+"/whoami", "")
+	require.Equal(t, http.StatusUnauthorized, code)
+	code, _ = call(t, http.DefaultClient, endpoint+"/whoami", "bad-token")
+	require.Equal(t, http.StatusUnauthorized, code)
+	code, body := call(t, http.DefaultClient, endpoint+"/whoami", "alice-token")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, `{"v":"alice"}`, body)
+	code, _ = call(t, http.DefaultClient, endpoint+"/foo", "alice-token")
+	require.Equal(t, http.StatusForbidden, code)
+	code, _ = call(t, http.DefaultClient, endpoint+"/foo", "bob-token")
+	require.Equal(t, http.StatusOK, code)
+}
+
+func TestServerTokenReviewAuthentication(t *testing.T) {
+	cli := fake.NewSimpleClientset()
+	cli.PrependReactor("create", "tokenreviews", func(action clienttesting.Action) (bool, kuberuntime.Object, error) {
+		review := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		switch review.Spec.Token {
+		case "good-token":
+			review.Status = authenticationv1.TokenReviewStatus{
+				Authenticated: true,
+				User: authenticationv1.UserInfo{
+					Username: "system:serviceaccount:vela-system:kubevela",
+					Extra:    map[string]authenticationv1.ExtraValue{"k": {"v"}},
+				},
+			}
+		case "error-token":
+			review.Status = authenticationv1.TokenReviewStatus{Error: "token expired"}
+		}
+		return true, review, nil
+	})
+	server := newAuthTestServer()
+	server.Authenticator = bearertoken.New(externalserver.NewTokenReviewAuthenticator(cli))
+	svr := httptest.NewServer(server.Container)
+	defer svr.Close()
+
+	code, body := call(t, svr.Client(), svr.URL+"/whoami", "good-token")
+	require.Equal(t, http.StatusOK, code)
+	out := &val{}
+	require.NoError(t, json.Unmarshal([]byte(body), out))
+	require.Equal(t, "system:serviceaccount:vela-system:kubevela", out.V)
+	code, _ = call(t, svr.Client(), svr.URL+"/whoami", "error-token")
+	require.Equal(t, http.StatusUnauthorized, code)
+	code, _ = call(t, svr.Client(), svr.URL+"/whoami", "other-token")
+	require.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestServerTokenReviewAudiences(t *testing.T) {
+	reviews := 0
+	cli := fake.NewSimpleClientset()
+	cli.PrependReactor("create", "tokenreviews", func(action clienttesting.Action) (bool, kuberuntime.Object, error) {
+		reviews++
+		review := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		review.Status = authenticationv1.TokenReviewStatus{
+			Authenticated: len(review.Spec.Audiences) == 1 && review.Spec.Audiences[0] == "cuex",
+			User:          authenticationv1.UserInfo{Username: "dave"},
+			Audiences:     review.Spec.Audiences,
+		}
+		return true, review, nil
+	})
+	singleton.StaticClient.Set(cli)
+	server := newAuthTestServer()
+	server.TLS = false
+	server.TokenReview = true
+	server.TokenReviewAudiences = []string{"cuex"}
+	addr, stop := serve(t, server)
+	defer stop()
+
+	for i := 0; i < 2; i++ {
+		code, body := call(t, http.DefaultClient, "http://"+addr+"/whoami", "some-token")
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, `{"v":"dave"}`, body)
+	}
+	require.Equal(t, 1, reviews)
+}
+
+func TestServerMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	caCert, caKey, err := cert.NewTemplateBuilder("test-ca").AsCA().SelfSigned()
+	require.NoError(t, err)
+	serverCert, serverKey, err := cert.NewTemplateBuilder("server").
+		WithDNSNames("localhost").WithIPs(net.ParseIP("127.0.0.1")).WithValidity(time.Hour).SignedBy(caCert, caKey)
+	require.NoError(t, err)
+	clientCert, clientKey, err := cert.NewTemplateBuilder("carol").WithValidity(time.Hour).SignedBy(caCert, caKey)
+	require.NoError(t, err)
+	for name, data := range map[string][]byte{"ca.crt": caCert, "tls.crt": serverCert, "tls.key": serverKey} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0600))
+	}
+
+	server := newAuthTestServer()
+	server.CertFile, server.KeyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	server.ClientCAFile = filepath.Join(dir, "ca.crt")
+	server.RequireClientCert = true
+	addr, stop := serve(t, server)
+	defer stop()
+	endpoint := "https://" + addr
+
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(caCert))
+	pair, err := tls.X509KeyPair(clientCert, clientKey)
+	require.NoError(t, err)
+	cli := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs: pool, Certificates: []tls.Certificate{pair},
+	}}}
+	code, body := call(t, cli, endpoint+"/whoami", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, `{"v":"carol"}`, body)
+
+	cli = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	_, err = cli.Post(endpoint+"/whoami", "application/json", strings.NewReader(`{}`))
+	require.Error(t, err)
+}
+
+func TestServerRequireClientCertWithoutCA(t *testing.T) {
+	server := newAuthTestServer()
+	server.Addr = "127.0.0.1:0"
+	server.RequireClientCert = true
+	err := server.Serve(context.Background())
+	require.ErrorContains(t, err, "client ca file is required")
+}
+
+func TestServerInvalidCASecret(t *testing.T) {
+	for _, s := range []string{"vela-system", "/ca", "vela-system/", "a/b/c"} {
+		server := newAuthTestServer()
+		server.Addr = "127.0.0.1:0"
+		server.CASecret = s
+		err := server.Serve(context.Background())
+		require.ErrorContains(t, err, "invalid ca secret")
+	}
+}
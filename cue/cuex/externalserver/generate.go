@@ -0,0 +1,410 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalserver
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	goast "go/ast"
+	"go/parser"
+	gotoken "go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+	"cuelang.org/go/cue/token"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubevela/pkg/apis/cue/v1alpha1"
+)
+
+const (
+	doKey       = "#do"
+	providerKey = "#provider"
+	paramsKey   = "$params"
+	returnsKey  = "$returns"
+	usageTag    = "+usage="
+)
+
+// GenerateConfig the config for generating the cuex Package of the server
+type GenerateConfig struct {
+	// Protocol the protocol of the provider, decided by the TLS of the server
+	// if not set
+	Protocol v1alpha1.ProviderProtocol
+	// Docs the Go doc comments indexed by the name of the function, the name
+	// of the type or `Type.Field` for the struct field
+	Docs map[string]string
+}
+
+// GenerateOption the option for generating the cuex Package of the server
+type GenerateOption interface {
+	ApplyTo(cfg *GenerateConfig)
+}
+
+// WithProtocol set the protocol of the provider in the Package
+type WithProtocol v1alpha1.ProviderProtocol
+
+// ApplyTo .
+func (in WithProtocol) ApplyTo(cfg *GenerateConfig) {
+	cfg.Protocol = v1alpha1.ProviderProtocol(in)
+}
+
+// WithDocs set the Go doc comments used as the +usage of the generated fields,
+// which could be loaded by ParseGoDocs
+type WithDocs map[string]string
+
+// ApplyTo .
+func (in WithDocs) ApplyTo(cfg *GenerateConfig) {
+	if cfg.Docs == nil {
+		cfg.Docs = map[string]string{}
+	}
+	for k, v := range in {
+		cfg.Docs[k] = v
+	}
+}
+
+// GeneratePackage generate the Package for registering the functions of the
+// server to cuex. The name is used as the #provider of the definitions and the
+// path is the import path of the package in CUE.
+func (in *Server) GeneratePackage(name string, path string, endpoint string, opts ...GenerateOption) (*v1alpha1.Package, error) {
+	cfg := &GenerateConfig{Protocol: v1alpha1.ProtocolHTTP}
+	if in.TLS {
+		cfg.Protocol = v1alpha1.ProtocolHTTPS
+	}
+	for _, opt := range opts {
+		opt.ApplyTo(cfg)
+	}
+	pkgName := filepath.Base(path)
+	template, err := GenerateTemplate(pkgName, name, in.Fns, cfg.Docs)
+	if err != nil {
+		return nil, err
+	}
+	provider := &v1alpha1.Provider{Protocol: cfg.Protocol, Endpoint: endpoint}
+	for _, fn := range in.Fns {
+		if _, ok := fn.(NativeServerProviderFn); ok {
+			provider.Encoding = v1alpha1.EncodingCUE
+		}
+	}
+	return &v1alpha1.Package{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.GroupVersion.String(), Kind: "Package"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1alpha1.PackageSpec{
+			Path:      path,
+			Provider:  provider,
+			Templates: map[string]string{pkgName + ".cue": template},
+		},
+	}, nil
+}
+
+// GenerateTemplate generate the CUE template which contains one definition for
+// each function. The parameter and return types of the TypedServerProviderFn
+// are reflected as the $params and $returns of the definition.
+func GenerateTemplate(pkgName string, provider string, fns map[string]ServerProviderFn, docs map[string]string) (string, error) {
+	names := make([]string, 0, len(fns))
+	for name := range fns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	f := &ast.File{Decls: []ast.Decl{&ast.Package{Name: ast.NewIdent(pkgName)}}}
+	for _, name := range names {
+		g := &typeGenerator{docs: docs, visiting: map[reflect.Type]bool{}}
+		params, returns := ast.Expr(anyStruct()), ast.Expr(anyStruct())
+		var paramsDoc, returnsDoc string
+		if fn, ok := fns[name].(TypedServerProviderFn); ok {
+			paramsType, returnsType := fn.Signature()
+			params, returns = g.exprOf(paramsType), g.exprOf(returnsType)
+			paramsDoc, returnsDoc = docs[paramsType.Name()], docs[returnsType.Name()]
+		}
+		def := &ast.Field{
+			Label: ast.NewIdent(definitionName(name)),
+			Value: ast.NewStruct(
+				&ast.Field{Label: ast.NewIdent(doKey), Value: ast.NewString(name)},
+				&ast.Field{Label: ast.NewIdent(providerKey), Value: ast.NewString(provider)},
+				withUsage(&ast.Field{Label: ast.NewIdent(paramsKey), Value: params}, paramsDoc),
+				withUsage(&ast.Field{Label: ast.NewIdent(returnsKey), Value: returns}, returnsDoc),
+			),
+		}
+		f.Decls = append(f.Decls, withUsage(def, docs[funcName(fns[name])]))
+	}
+	bs, err := format.Node(f)
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
+
+type typeGenerator struct {
+	docs     map[string]string
+	visiting map[reflect.Type]bool
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func implements(t reflect.Type, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+func (in *typeGenerator) exprOf(t reflect.Type) ast.Expr {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType, implements(t, textMarshalerType):
+		return ast.NewIdent("string")
+	case implements(t, jsonMarshalerType):
+		return ast.NewIdent("_")
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return ast.NewIdent("bool")
+	case reflect.String:
+		return ast.NewIdent("string")
+	case reflect.Int:
+		return ast.NewIdent("int")
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint,
+		reflect.Float32, reflect.Float64:
+		return ast.NewIdent(t.Kind().String())
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return ast.NewIdent("bytes")
+		}
+		return ast.NewList(&ast.Ellipsis{Type: in.exprOf(t.Elem())})
+	case reflect.Map:
+		return ast.NewStruct(&ast.Field{
+			Label: ast.NewList(ast.NewIdent("string")),
+			Value: in.exprOf(t.Elem()),
+		})
+	case reflect.Struct:
+		if in.visiting[t] {
+			return anyStruct()
+		}
+		in.visiting[t] = true
+		defer delete(in.visiting, t)
+		return ast.NewStruct(in.fieldsOf(t)...)
+	default:
+		return ast.NewIdent("_")
+	}
+}
+
+func (in *typeGenerator) fieldsOf(t reflect.Type) []interface{} {
+	var fields []interface{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		ft := sf.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			fields = append(fields, in.fieldsOf(ft)...)
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		field := &ast.Field{Label: ast.NewString(name), Value: in.exprOf(sf.Type)}
+		if ast.IsValidIdent(name) && !strings.HasPrefix(name, "#") && !strings.HasPrefix(name, "_") {
+			field.Label = ast.NewIdent(name)
+		}
+		if sf.Type.Kind() == reflect.Pointer || strings.Contains(","+opts+",", ",omitempty,") {
+			field.Constraint = token.OPTION
+		}
+		ast.SetRelPos(field, token.Newline)
+		fields = append(fields, withUsage(field, in.docs[t.Name()+"."+sf.Name]))
+	}
+	return fields
+}
+
+func anyStruct() *ast.StructLit {
+	return ast.NewStruct(&ast.Ellipsis{})
+}
+
+func withUsage(field *ast.Field, doc string) *ast.Field {
+	if doc = strings.Join(strings.Fields(doc), " "); doc != "" {
+		ast.AddComment(field, &ast.CommentGroup{Doc: true, List: []*ast.Comment{{Text: "// " + usageTag + doc}}})
+	}
+	return field
+}
+
+// definitionName converts the function name like `list-tables` into the
+// definition name like `#ListTables`
+func definitionName(name string) string {
+	sb := strings.Builder{}
+	sb.WriteString("#")
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return sb.String()
+}
+
+func funcName(fn ServerProviderFn) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	name := runtime.FuncForPC(v.Pointer()).Name()
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// ParseGoDocs parse the doc comments from the Go source files or directories.
+// The docs are indexed by the name of the function, the name of the type or
+// `Type.Field` for the struct field.
+func ParseGoDocs(paths ...string) (map[string]string, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(p, "*.go"))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			if !strings.HasSuffix(match, "_test.go") {
+				files = append(files, match)
+			}
+		}
+	}
+	docs := map[string]string{}
+	fset := gotoken.NewFileSet()
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range f.Decls {
+			switch d := decl.(type) {
+			case *goast.FuncDecl:
+				if d.Recv == nil && d.Doc != nil {
+					docs[d.Name.Name] = trimDocName(d.Name.Name, d.Doc.Text())
+				}
+			case *goast.GenDecl:
+				for _, spec := range d.Specs {
+					ts, ok := spec.(*goast.TypeSpec)
+					if !ok {
+						continue
+					}
+					if doc := docText(ts.Doc, ts.Comment); doc != "" {
+						docs[ts.Name.Name] = trimDocName(ts.Name.Name, doc)
+					} else if len(d.Specs) == 1 && d.Doc != nil {
+						docs[ts.Name.Name] = trimDocName(ts.Name.Name, d.Doc.Text())
+					}
+					st, ok := ts.Type.(*goast.StructType)
+					if !ok {
+						continue
+					}
+					for _, field := range st.Fields.List {
+						doc := docText(field.Doc, field.Comment)
+						for _, name := range field.Names {
+							if doc != "" {
+								docs[ts.Name.Name+"."+name.Name] = trimDocName(name.Name, doc)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return docs, nil
+}
+
+// trimDocName trims the leading identifier of the Go doc comment, for example,
+// `Name the name of the table` becomes `The name of the table`
+func trimDocName(name string, doc string) string {
+	if trimmed, ok := strings.CutPrefix(doc, name+" "); ok && trimmed != "" {
+		return strings.ToUpper(trimmed[:1]) + trimmed[1:]
+	}
+	return doc
+}
+
+func docText(groups ...*goast.CommentGroup) string {
+	for _, group := range groups {
+		if group != nil {
+			return group.Text()
+		}
+	}
+	return ""
+}
+
+func (in *Server) newGenerateCommand() *cobra.Command {
+	var name, path, endpoint, protocol string
+	var sources []string
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate the cuex Package for the functions of the server",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opts []GenerateOption
+			if protocol != "" {
+				opts = append(opts, WithProtocol(protocol))
+			}
+			if len(sources) > 0 {
+				docs, err := ParseGoDocs(sources...)
+				if err != nil {
+					return err
+				}
+				opts = append(opts, WithDocs(docs))
+			}
+			pkg, err := in.GeneratePackage(name, path, endpoint, opts...)
+			if err != nil {
+				return err
+			}
+			bs, err := yaml.Marshal(pkg)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprint(cmd.OutOrStdout(), string(bs))
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&name, "name", "", name, "name of the Package, also used as the provider name")
+	cmd.Flags().StringVarP(&path, "path", "", path, "import path of the Package in CUE")
+	cmd.Flags().StringVarP(&endpoint, "endpoint", "", endpoint, "endpoint of the server")
+	cmd.Flags().StringVarP(&protocol, "protocol", "", protocol, "protocol of the provider, decided by --tls if not set")
+	cmd.Flags().StringSliceVarP(&sources, "go-source", "", sources, "Go source files or directories to read the doc comments from")
+	_ = cmd.MarkFlagRequired("name")
+	_ = cmd.MarkFlagRequired("path")
+	_ = cmd.MarkFlagRequired("endpoint")
+	return cmd
+}
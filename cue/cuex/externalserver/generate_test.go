@@ -0,0 +1,126 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalserver_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubevela/pkg/apis/cue/v1alpha1"
+	"github.com/kubevela/pkg/cue/cuex/externalserver"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/cue/util"
+)
+
+// tableQuery the query for listing tables
+type tableQuery struct {
+	// Database the name of the database
+	Database string `json:"db"`
+	// Limit the max number of tables
+	Limit  *int              `json:"limit,omitempty"`
+	Labels map[string]string `json:"labels"`
+	Since  time.Time         `json:"since"`
+	Parent *tableQuery       `json:"parent,omitempty"`
+}
+
+type table struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Raw     []byte   `json:"raw"`
+	Size    float64  `json:"size"`
+}
+
+type tableList struct {
+	Tables []table `json:"tables"`
+}
+
+// listTables list the tables in the database
+func listTables(_ context.Context, _ *tableQuery) (*tableList, error) {
+	return &tableList{}, nil
+}
+
+type rawFn struct{}
+
+func (in *rawFn) Call(_ *restful.Request, _ *restful.Response) {}
+
+func TestGeneratePackage(t *testing.T) {
+	server := externalserver.NewServer("/", map[string]externalserver.ServerProviderFn{
+		"list-tables": externalserver.GenericServerProviderFn[tableQuery, tableList](listTables),
+		"raw":         &rawFn{},
+	})
+	docs, err := externalserver.ParseGoDocs("generate_test.go")
+	require.NoError(t, err)
+	require.Equal(t, "The name of the database\n", docs["tableQuery.Database"])
+
+	pkg, err := server.GeneratePackage("mysql", "ext/db/mysql", "https://mysql-provider/", externalserver.WithDocs(docs))
+	require.NoError(t, err)
+	require.Equal(t, "Package", pkg.Kind)
+	require.Equal(t, v1alpha1.ProtocolHTTPS, pkg.Spec.Provider.Protocol)
+	template := pkg.Spec.Templates["mysql.cue"]
+	require.Contains(t, template, "// +usage=List the tables in the database")
+	require.Contains(t, template, "// +usage=The name of the database")
+
+	bi, err := util.BuildImport("ext/db/mysql", pkg.Spec.Templates)
+	require.NoError(t, err)
+	val := cuecontext.New().BuildInstance(bi)
+	require.NoError(t, val.Err())
+	def := val.LookupPath(cue.ParsePath("#ListTables"))
+	do, err := def.LookupPath(cue.ParsePath("#do")).String()
+	require.NoError(t, err)
+	require.Equal(t, "list-tables", do)
+	provider, err := def.LookupPath(cue.ParsePath("#provider")).String()
+	require.NoError(t, err)
+	require.Equal(t, "mysql", provider)
+	params := def.LookupPath(cue.ParsePath("$params"))
+	require.Equal(t, cue.StringKind, params.LookupPath(cue.ParsePath("db")).IncompleteKind())
+	require.Equal(t, cue.StringKind, params.LookupPath(cue.ParsePath("since")).IncompleteKind())
+	require.True(t, params.LookupPath(cue.MakePath(cue.Str("limit").Optional())).Exists())
+	require.NoError(t, params.FillPath(cue.ParsePath("labels.x"), "y").Err())
+	tables := def.LookupPath(cue.ParsePath("$returns.tables"))
+	require.Equal(t, cue.ListKind, tables.IncompleteKind())
+	require.True(t, val.LookupPath(cue.ParsePath("#Raw.$params")).Exists())
+
+	_, err = cuexruntime.NewExternalPackage(pkg)
+	require.NoError(t, err)
+
+	_, err = externalserver.ParseGoDocs("not-exist")
+	require.Error(t, err)
+}
+
+func TestGenerateCommand(t *testing.T) {
+	server := externalserver.NewServer("/", map[string]externalserver.ServerProviderFn{
+		"list-tables": externalserver.GenericServerProviderFn[tableQuery, tableList](listTables),
+	})
+	server.TLS = false
+	cmd := server.NewCommand()
+	buf := &bytes.Buffer{}
+	cmd.SetOut(buf)
+	cmd.SetArgs([]string{"generate", "--name=mysql", "--path=ext/db/mysql", "--endpoint=http://mysql-provider", "--go-source=."})
+	require.NoError(t, cmd.Execute())
+	pkg := &v1alpha1.Package{}
+	require.NoError(t, yaml.Unmarshal(buf.Bytes(), pkg))
+	require.Equal(t, v1alpha1.ProtocolHTTP, pkg.Spec.Provider.Protocol)
+	require.Contains(t, pkg.Spec.Templates["mysql.cue"], "#ListTables")
+}
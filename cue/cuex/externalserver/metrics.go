@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalserver
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/kubevela/pkg/monitor/metrics"
+)
+
+var (
+	// providerRequestDuration the latency of the provider function calls
+	providerRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: metrics.KubeVelaSubsystem,
+			Name:      "external_provider_request_duration_seconds",
+			Help:      "request duration of the functions in the external provider server",
+			Buckets:   metrics.FineGrainedBuckets,
+		}, []string{"function", "code"})
+	// providerRequestErrors the number of failed provider function calls
+	providerRequestErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: metrics.KubeVelaSubsystem,
+			Name:      "external_provider_request_errors_total",
+			Help:      "number of failed requests to the functions in the external provider server",
+		}, []string{"function", "code"})
+	// providerRequestsInFlight the number of provider function calls being served
+	providerRequestsInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: metrics.KubeVelaSubsystem,
+			Name:      "external_provider_requests_in_flight",
+			Help:      "number of requests being served by the functions in the external provider server",
+		}, []string{"function"})
+)
+
+func init() {
+	prometheus.MustRegister(providerRequestDuration, providerRequestErrors, providerRequestsInFlight)
+}
+
+// instrument records the metrics for calling the provider function
+func instrument(name string, fn restful.RouteFunction) restful.RouteFunction {
+	return func(request *restful.Request, response *restful.Response) {
+		inFlight := providerRequestsInFlight.WithLabelValues(name)
+		inFlight.Inc()
+		defer inFlight.Dec()
+		start := time.Now()
+		fn(request, response)
+		code := strconv.Itoa(response.StatusCode())
+		providerRequestDuration.WithLabelValues(name, code).Observe(time.Since(start).Seconds())
+		if response.StatusCode() >= 400 {
+			providerRequestErrors.WithLabelValues(name, code).Inc()
+		}
+	}
+}
@@ -18,16 +18,33 @@ package externalserver
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
-	"github.com/kubevela/pkg/cue/cuex/runtime"
+	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
 	"github.com/emicklei/go-restful/v3"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/klog/v2"
 
+	"github.com/kubevela/pkg/cue/cuex/runtime"
 	"github.com/kubevela/pkg/util/cert"
+	"github.com/kubevela/pkg/util/singleton"
 )
 
 // ServerProviderFn the function interface to process rest call
@@ -38,17 +55,38 @@ type ServerProviderFn interface {
 // GenericServerProviderFn generic function that implements ServerProviderFn interface
 type GenericServerProviderFn[T any, U any] func(context.Context, *T) (*U, error)
 
+// TypedServerProviderFn the ServerProviderFn that exposes the types of its
+// parameter and return value
+type TypedServerProviderFn interface {
+	ServerProviderFn
+	Signature() (params reflect.Type, returns reflect.Type)
+}
+
+var _ TypedServerProviderFn = GenericServerProviderFn[any, any](nil)
+
+// Signature returns the types of the parameter and the return value
+func (fn GenericServerProviderFn[T, U]) Signature() (reflect.Type, reflect.Type) {
+	return reflect.TypeOf((*T)(nil)).Elem(), reflect.TypeOf((*U)(nil)).Elem()
+}
+
 // Call handle rest call for given request
 func (fn GenericServerProviderFn[T, U]) Call(request *restful.Request, response *restful.Response) {
-	ctx := runtime.ContextFromHeaders(request.Request)
-	request.Request = request.Request.WithContext(ctx)
+	if !withPropagatedContext(request, response) {
+		return
+	}
 	bs, err := io.ReadAll(request.Request.Body)
 	if err != nil {
-		_ = response.WriteError(http.StatusBadRequest, err)
+		_ = response.WriteError(readBodyErrorCode(err), err)
 		return
 	}
 	params := new(T)
-	if err = json.Unmarshal(bs, params); err != nil {
+	if isCUE(request) {
+		bs, err = cueToJSON(bs)
+	}
+	if err == nil {
+		err = json.Unmarshal(bs, params)
+	}
+	if err != nil {
 		_ = response.WriteError(http.StatusBadRequest, err)
 		return
 	}
@@ -65,7 +103,100 @@ func (fn GenericServerProviderFn[T, U]) Call(request *restful.Request, response
 	return
 }
 
-const defaultAddr = ":8443"
+// withPropagatedContext attach the propagated context of the headers to the
+// request, and reject the request if the context is too large or not signed
+func withPropagatedContext(request *restful.Request, response *restful.Response) bool {
+	ctx := runtime.ContextFromHeaders(request.Request)
+	request.Request = request.Request.WithContext(ctx)
+	pCtx, _ := runtime.GetPropagatedContext(ctx)
+	switch err := pCtx.ValidationErr(); {
+	case errors.Is(err, runtime.ErrPropagatedContextTooLarge):
+		_ = response.WriteError(http.StatusRequestHeaderFieldsTooLarge, err)
+	case err != nil:
+		_ = response.WriteError(http.StatusUnauthorized, err)
+	default:
+		return true
+	}
+	return false
+}
+
+var _ ServerProviderFn = NativeServerProviderFn(nil)
+
+// NativeServerProviderFn native function that receives the $params as CUE
+// value and returns the value of $returns, which implements ServerProviderFn.
+// Unlike GenericServerProviderFn, the definitions, defaults and incomplete
+// values are kept when the Provider of the Package uses the CUE encoding.
+type NativeServerProviderFn func(context.Context, cue.Value) (cue.Value, error)
+
+// Call handle rest call for given request
+func (fn NativeServerProviderFn) Call(request *restful.Request, response *restful.Response) {
+	if !withPropagatedContext(request, response) {
+		return
+	}
+	bs, err := io.ReadAll(request.Request.Body)
+	if err != nil {
+		_ = response.WriteError(readBodyErrorCode(err), err)
+		return
+	}
+	params, err := runtime.UnmarshalCUE(cuecontext.New(), bs)
+	if err != nil {
+		_ = response.WriteError(http.StatusBadRequest, err)
+		return
+	}
+	ret, err := fn(request.Request.Context(), params)
+	if err != nil {
+		_ = response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	contentType := restful.MIME_JSON
+	if isCUE(request) {
+		contentType = runtime.ContentTypeCUE
+		bs, err = runtime.MarshalCUE(ret)
+	} else {
+		bs, err = ret.MarshalJSON()
+	}
+	if err != nil {
+		_ = response.WriteError(http.StatusInternalServerError, err)
+		return
+	}
+	response.Header().Set(restful.HEADER_ContentType, contentType)
+	_, _ = response.Write(bs)
+}
+
+func readBodyErrorCode(err error) int {
+	if maxBytesErr := (&http.MaxBytesError{}); errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// isCUE checks if the request body is encoded as CUE source
+func isCUE(request *restful.Request) bool {
+	return strings.HasPrefix(request.Request.Header.Get(restful.HEADER_ContentType), runtime.ContentTypeCUE)
+}
+
+func cueToJSON(bs []byte) ([]byte, error) {
+	value, err := runtime.UnmarshalCUE(cuecontext.New(), bs)
+	if err != nil {
+		return nil, err
+	}
+	return value.MarshalJSON()
+}
+
+const (
+	defaultAddr                = ":8443"
+	defaultReadTimeout         = 30 * time.Second
+	defaultWriteTimeout        = 2 * time.Minute
+	defaultShutdownTimeout     = 30 * time.Second
+	defaultMaxRequestBodySize  = 10 << 20
+	defaultCertValidity        = 24 * time.Hour
+	defaultTokenReviewCacheTTL = 10 * time.Second
+	defaultCertCommonName      = "kubevela-external-provider"
+
+	healthzPath = "/healthz"
+	readyzPath  = "/readyz"
+	metricsPath = "/metrics"
+)
 
 // Server the external provider server
 type Server struct {
@@ -76,21 +207,172 @@ type Server struct {
 	TLS      bool
 	CertFile string
 	KeyFile  string
+
+	ReadTimeout        time.Duration
+	WriteTimeout       time.Duration
+	ShutdownTimeout    time.Duration
+	MaxRequestBodySize int64
+
+	CertDir      string
+	CertHosts    []string
+	CertValidity time.Duration
+	CASecret     string
+
+	ClientCAFile      string
+	RequireClientCert bool
+	TokenAuthFile     string
+	TokenReview       bool
+	// TokenReviewAudiences the audiences of the TokenReview, the audiences of
+	// the kube-apiserver are used if empty
+	TokenReviewAudiences []string
+	// TokenReviewCacheTTL the duration for caching the results of the
+	// TokenReview, no cache if not positive
+	TokenReviewCacheTTL time.Duration
+	// Authenticator authenticates the callers, built from the client ca file,
+	// the token auth file and the token review settings if not set
+	Authenticator authenticator.Request
+	// Authorizer decide if the caller can call the function, all calls are
+	// allowed if not set
+	Authorizer FunctionAuthorizer
+
+	ready atomic.Bool
 }
 
 // ListenAndServe start the server
-func (in *Server) ListenAndServe() (err error) {
-	if in.TLS && (in.CertFile == "" || in.KeyFile == "") {
-		in.CertFile, in.KeyFile, err = cert.GenerateDefaultSelfSignedCertificateLocally()
-		if err != nil {
+func (in *Server) ListenAndServe() error {
+	return in.Serve(context.Background())
+}
+
+// Serve start the server and shutdown it gracefully when the context is done
+func (in *Server) Serve(ctx context.Context) (err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if in.Authenticator == nil {
+		if in.Authenticator, err = in.buildAuthenticator(); err != nil {
 			return err
 		}
 	}
-	svr := &http.Server{Addr: in.Addr, Handler: in.Container}
+	tlsConfig, err := in.TLSConfig()
+	if err != nil {
+		return err
+	}
 	if in.TLS {
-		return svr.ListenAndServeTLS(in.CertFile, in.KeyFile)
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		if tlsConfig.GetCertificate, err = in.startCertificateSource(ctx); err != nil {
+			return err
+		}
+	}
+	svr := &http.Server{
+		Addr:         in.Addr,
+		Handler:      in.Container,
+		ReadTimeout:  in.ReadTimeout,
+		WriteTimeout: in.WriteTimeout,
+		TLSConfig:    tlsConfig,
+	}
+	listener, err := net.Listen("tcp", in.Addr)
+	if err != nil {
+		return err
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		if in.TLS {
+			errCh <- svr.ServeTLS(listener, "", "")
+		} else {
+			errCh <- svr.Serve(listener)
+		}
+	}()
+	in.ready.Store(true)
+	defer in.ready.Store(false)
+	select {
+	case err = <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	in.ready.Store(false)
+	klog.Infof("shutting down external provider server")
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), in.ShutdownTimeout)
+	defer cancelShutdown()
+	if err = svr.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err = <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// certificateSource serves the certificate and keeps it up to date
+type certificateSource interface {
+	GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error)
+	Start(ctx context.Context) error
+}
+
+// startCertificateSource reloads the certificate from the cert file and key
+// file when they change. If they are not set, the certificate is issued from
+// the managed CA and rotated before expiry.
+func (in *Server) startCertificateSource(ctx context.Context) (func(*tls.ClientHelloInfo) (*tls.Certificate, error), error) {
+	var source certificateSource
+	if in.CertFile != "" && in.KeyFile != "" {
+		reloader, err := cert.NewReloader(in.CertFile, in.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		source = reloader
+	} else {
+		var dnsNames []string
+		var ips []net.IP
+		for _, host := range in.CertHosts {
+			if ip := net.ParseIP(host); ip != nil {
+				ips = append(ips, ip)
+			} else {
+				dnsNames = append(dnsNames, host)
+			}
+		}
+		manager := cert.NewManager(defaultCertCommonName, dnsNames, ips)
+		manager.CertValidity = in.CertValidity
+		if in.CertDir != "" {
+			manager.CAStore, manager.Dir = cert.FileCAStore(in.CertDir), in.CertDir
+		}
+		if in.CASecret != "" {
+			namespace, name, ok := strings.Cut(in.CASecret, "/")
+			if !ok || namespace == "" || name == "" || strings.Contains(name, "/") {
+				return nil, fmt.Errorf("invalid ca secret %q, expected namespace/name", in.CASecret)
+			}
+			manager.CAStore = &cert.SecretCAStore{Client: singleton.KubeClient.Get(), Namespace: namespace, Name: name}
+		}
+		if _, err := manager.Rotate(ctx); err != nil {
+			return nil, err
+		}
+		source = manager
+	}
+	go func() {
+		_ = source.Start(ctx)
+	}()
+	return source.GetCertificate, nil
+}
+
+func (in *Server) healthz(w http.ResponseWriter, _ *http.Request) {
+	_, _ = w.Write([]byte("ok"))
+}
+
+func (in *Server) readyz(w http.ResponseWriter, _ *http.Request) {
+	if !in.ready.Load() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok"))
+}
+
+// limitBody restricts the size of the request body
+func (in *Server) limitBody(fn restful.RouteFunction) restful.RouteFunction {
+	return func(request *restful.Request, response *restful.Response) {
+		if in.MaxRequestBodySize > 0 {
+			request.Request.Body = http.MaxBytesReader(response, request.Request.Body, in.MaxRequestBodySize)
+		}
+		fn(request, response)
 	}
-	return svr.ListenAndServe()
 }
 
 // AddFlags set flags
@@ -99,33 +381,76 @@ func (in *Server) AddFlags(set *pflag.FlagSet) {
 	set.BoolVarP(&in.TLS, "tls", "", in.TLS, "enable tls server")
 	set.StringVarP(&in.CertFile, "cert-file", "", in.CertFile, "tls certificate path")
 	set.StringVarP(&in.KeyFile, "key-file", "", in.KeyFile, "tls key path")
+	set.StringVarP(&in.CertDir, "cert-dir", "", in.CertDir, "directory to store the generated ca and certificate when --cert-file and --key-file are not set")
+	set.StringSliceVarP(&in.CertHosts, "cert-hosts", "", in.CertHosts, "dns names and ips of the generated certificate")
+	set.DurationVarP(&in.CertValidity, "cert-validity", "", in.CertValidity, "validity of the generated certificate, which is rotated before expiry")
+	set.StringVarP(&in.CASecret, "ca-secret", "", in.CASecret, "namespace/name of the Secret to store the ca of the generated certificate")
+	set.DurationVarP(&in.ReadTimeout, "read-timeout", "", in.ReadTimeout, "max duration for reading the entire request")
+	set.DurationVarP(&in.WriteTimeout, "write-timeout", "", in.WriteTimeout, "max duration before timing out writes of the response")
+	set.DurationVarP(&in.ShutdownTimeout, "shutdown-timeout", "", in.ShutdownTimeout, "max duration for waiting the in-flight requests when shutting down")
+	set.StringVarP(&in.ClientCAFile, "client-ca-file", "", in.ClientCAFile, "ca file for verifying the client certificates, the common name of the certificate is used as the caller")
+	set.BoolVarP(&in.RequireClientCert, "require-client-cert", "", in.RequireClientCert, "reject the connections without valid client certificates, requires --client-ca-file")
+	set.StringVarP(&in.TokenAuthFile, "token-auth-file", "", in.TokenAuthFile, "csv file of the static bearer tokens in the format of token,user,uid,\"group1,group2\"")
+	set.BoolVarP(&in.TokenReview, "authentication-token-review", "", in.TokenReview, "authenticate the bearer tokens through the TokenReview of kubernetes")
+	set.StringSliceVarP(&in.TokenReviewAudiences, "token-review-audiences", "", in.TokenReviewAudiences, "audiences of the TokenReview, the audiences of the kube-apiserver are used if empty")
+	set.DurationVarP(&in.TokenReviewCacheTTL, "token-review-cache-ttl", "", in.TokenReviewCacheTTL, "duration for caching the results of the TokenReview, no cache if not positive")
+	set.Int64VarP(&in.MaxRequestBodySize, "max-request-body-size", "", in.MaxRequestBodySize, "max size in bytes of the request body, no limit if not positive")
+	runtime.AddContextFlags(set)
+	runtime.AddTracingFlags(set)
 }
 
 // NewCommand create start command
 func (in *Server) NewCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return in.ListenAndServe()
+			ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+			shutdown, err := runtime.SetupTracing(ctx, runtime.DefaultTracingOptions)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				ctx, cancel := context.WithTimeout(context.Background(), in.ShutdownTimeout)
+				defer cancel()
+				_ = shutdown(ctx)
+			}()
+			return in.Serve(ctx)
 		},
 	}
 	in.AddFlags(cmd.Flags())
+	cmd.AddCommand(in.newGenerateCommand())
 	return cmd
 }
 
 // NewServer create a server for serving as cuex external
 func NewServer(path string, fns map[string]ServerProviderFn) *Server {
-	container := restful.NewContainer()
-	ws := &restful.WebService{}
-	ws.Path(path).Consumes(restful.MIME_JSON).Produces(restful.MIME_JSON)
-	for name, fn := range fns {
-		ws.Route(ws.POST(name).To(fn.Call))
-	}
-	container.Add(ws)
-	return &Server{
+	server := &Server{
 		Fns:       fns,
-		Container: container,
+		Container: restful.NewContainer(),
 
 		Addr: defaultAddr,
 		TLS:  true,
+
+		ReadTimeout:        defaultReadTimeout,
+		WriteTimeout:       defaultWriteTimeout,
+		ShutdownTimeout:    defaultShutdownTimeout,
+		MaxRequestBodySize: defaultMaxRequestBodySize,
+
+		CertHosts:    []string{"localhost", "127.0.0.1"},
+		CertValidity: defaultCertValidity,
+
+		TokenReviewCacheTTL: defaultTokenReviewCacheTTL,
+	}
+	ws := &restful.WebService{}
+	ws.Path(path).
+		Consumes(restful.MIME_JSON, runtime.ContentTypeCUE).
+		Produces(restful.MIME_JSON, runtime.ContentTypeCUE)
+	for name, fn := range fns {
+		ws.Route(ws.POST(name).To(instrument(name, server.limitBody(server.authenticate(name, fn.Call)))))
 	}
+	server.Container.Add(ws)
+	server.Container.HandleWithFilter(healthzPath, http.HandlerFunc(server.healthz))
+	server.Container.HandleWithFilter(readyzPath, http.HandlerFunc(server.readyz))
+	server.Container.HandleWithFilter(metricsPath, promhttp.Handler())
+	return server
 }
@@ -26,14 +26,18 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"io"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
 	"github.com/emicklei/go-restful/v3"
 	"github.com/stretchr/testify/require"
 
+	"github.com/kubevela/pkg/apis/cue/v1alpha1"
 	"github.com/kubevela/pkg/cue/cuex/externalserver"
 	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
 )
@@ -201,3 +205,162 @@ func propagationCheck(ctx context.Context, input *null) (*propagationCheckResp,
 		Context: mapCtx,
 	}, nil
 }
+
+func TestExternalServerLifecycle(t *testing.T) {
+	server := externalserver.NewServer("/", map[string]externalserver.ServerProviderFn{
+		"foo": externalserver.GenericServerProviderFn[val, val](foo),
+	})
+	server.TLS = false
+	server.MaxRequestBodySize = 16
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server.Addr = listener.Addr().String()
+	require.NoError(t, listener.Close())
+	endpoint := "http://" + server.Addr
+
+	recorder := httptest.NewRecorder()
+	server.Container.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Serve(ctx) }()
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(endpoint + "/readyz")
+		return err == nil && resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 100*time.Millisecond)
+	resp, err := http.Get(endpoint + "/healthz")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Post(endpoint+"/foo", restful.MIME_JSON, bytes.NewReader([]byte(`{"v":"value"}`)))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = http.Post(endpoint+"/foo", restful.MIME_JSON, bytes.NewReader([]byte(`{"v":"too-large-value"}`)))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	resp, err = http.Get(endpoint + "/metrics")
+	require.NoError(t, err)
+	bs, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(bs), `kubevela_external_provider_request_duration_seconds_count{code="200",function="foo"}`)
+	require.Contains(t, string(bs), `kubevela_external_provider_request_errors_total{code="413",function="foo"} 1`)
+	require.Contains(t, string(bs), `kubevela_external_provider_requests_in_flight{function="foo"} 0`)
+
+	cancel()
+	select {
+	case err = <-errCh:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server is not shutdown")
+	}
+	recorder = httptest.NewRecorder()
+	server.Container.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
+
+func nativeFn(_ context.Context, params cue.Value) (cue.Value, error) {
+	if !params.LookupPath(cue.ParsePath("#Config")).Exists() {
+		return params, fmt.Errorf("definition not found")
+	}
+	return params.Context().CompileString(`{
+		name: *"default" | string
+		replicas: int
+	}`).FillPath(cue.ParsePath("config"), params.LookupPath(cue.ParsePath("config"))), nil
+}
+
+func TestNativeServerProviderFn(t *testing.T) {
+	server := externalserver.NewServer("/", map[string]externalserver.ServerProviderFn{
+		"native": externalserver.NativeServerProviderFn(nativeFn),
+		"foo":    externalserver.GenericServerProviderFn[val, val](foo),
+	})
+	svr := httptest.NewServer(server.Container)
+	defer svr.Close()
+
+	call := func(fn string, encoding v1alpha1.ProviderEncoding, src string) (cue.Value, error) {
+		prd := cuexruntime.ExternalProviderFn{
+			Provider: v1alpha1.Provider{Protocol: v1alpha1.ProtocolHTTP, Endpoint: svr.URL, Encoding: encoding},
+			Fn:       fn,
+		}
+		out, err := prd.Call(context.Background(), cuecontext.New().CompileString(src))
+		return out.LookupPath(cue.ParsePath("$returns")), err
+	}
+	params := `$params: {
+		#Config: {size: *1 | int}
+		config: #Config
+	}`
+
+	ret, err := call("native", v1alpha1.EncodingCUE, params)
+	require.NoError(t, err)
+	name, err := ret.LookupPath(cue.ParsePath("name")).String()
+	require.NoError(t, err)
+	require.Equal(t, "default", name)
+	require.Error(t, ret.LookupPath(cue.ParsePath("replicas")).Validate(cue.Concrete(true)))
+	size, err := ret.LookupPath(cue.ParsePath("config.size")).Int64()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), size)
+	require.NoError(t, ret.LookupPath(cue.ParsePath("config")).FillPath(cue.ParsePath("size"), 3).Err())
+
+	_, err = call("native", v1alpha1.EncodingJSON, params)
+	require.Error(t, err)
+
+	ret, err = call("foo", v1alpha1.EncodingCUE, `$params: v: *"x" | string`)
+	require.NoError(t, err)
+	v, err := ret.LookupPath(cue.ParsePath("v")).String()
+	require.NoError(t, err)
+	require.Equal(t, "foo", v)
+
+	resp, err := http.Post(svr.URL+"/native", cuexruntime.ContentTypeCUE, bytes.NewReader([]byte(`a: `)))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	pkg, err := server.GeneratePackage("native", "ext/native", svr.URL)
+	require.NoError(t, err)
+	require.Equal(t, v1alpha1.EncodingCUE, pkg.Spec.Provider.Encoding)
+}
+
+func TestExternalServerSignedContext(t *testing.T) {
+	cuexruntime.ContextSigningKey = []byte("secret")
+	defer func() { cuexruntime.ContextSigningKey = nil }()
+	appCtx := cuexruntime.AppContext{AppName: "app-name", Namespace: "a-namespace"}
+	fn := externalserver.GenericServerProviderFn[null, propagationCheckResp](propagationCheck)
+	for name, tt := range map[string]struct {
+		Mutate func(http.Header)
+		Code   int
+	}{
+		"signed": {Code: http.StatusOK},
+		"tampered": {
+			Mutate: func(header http.Header) { header.Set("X-Vela-Context-Signature", "aW52YWxpZA==") },
+			Code:   http.StatusUnauthorized,
+		},
+		"unsigned": {
+			Mutate: func(header http.Header) { header.Del("X-Vela-Context-Signature") },
+			Code:   http.StatusUnauthorized,
+		},
+		"too large": {
+			Mutate: func(header http.Header) { cuexruntime.MaxPropagatedContextSize = 8 },
+			Code:   http.StatusRequestHeaderFieldsTooLarge,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() { cuexruntime.MaxPropagatedContextSize = 4096 }()
+			ctx, span, err := cuexruntime.StartSpanWithAppContext(context.Background(), "span", appCtx)
+			require.NoError(t, err)
+			defer span.End()
+			httpReq := httptest.NewRequest(http.MethodPost, "/propagationCheck", bytes.NewReader([]byte("{}")))
+			cuexruntime.TraceHeaderPropagator{}.Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+			if tt.Mutate != nil {
+				tt.Mutate(httpReq.Header)
+			}
+			httpResp := httptest.NewRecorder()
+			fn.Call(restful.NewRequest(httpReq), restful.NewResponse(httpResp))
+			require.Equal(t, tt.Code, httpResp.Code)
+			if tt.Code == http.StatusOK {
+				var response propagationCheckResp
+				require.NoError(t, json.Unmarshal(httpResp.Body.Bytes(), &response))
+				require.Equal(t, "app-name", response.Context["appName"])
+			}
+		})
+	}
+}
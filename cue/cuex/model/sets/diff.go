@@ -0,0 +1,342 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sets
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/format"
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pkg/errors"
+	jsonpatchv2 "gomodules.xyz/jsonpatch/v2"
+)
+
+// Diff compute the minimal patch between base and target, so that
+// StrategyUnify(base, patch, options...) equals to target. The patchKey and
+// patchStrategy are taken from the comment tags of base and target and the
+// patch metadata. If UnifyByJSONPatch or UnifyByJSONMergePatch is given, the
+// patch follows the RFC 6902 or RFC 7396.
+func Diff(base, target cue.Value, options ...UnifyOption) (cue.Value, error) {
+	params := newUnifyParams(options...)
+	switch params.PatchStrategy {
+	case StrategyJSONPatch:
+		return jsonPatchDiff(base, target)
+	case StrategyJSONMergePatch:
+		return jsonMergePatchDiff(base, target)
+	}
+	meta := params.PatchMeta
+	if meta == nil {
+		meta = resolvePatchMeta(params.PatchMetaResolver, base)
+	}
+	d := &differ{meta: meta}
+	decls, err := d.diffStruct(base, target)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	bs, err := format.Node(&ast.File{Decls: decls})
+	if err != nil {
+		return cue.Value{}, errors.Wrapf(err, "failed to format the patch")
+	}
+	patch := base.Context().CompileBytes(bs)
+	return patch, patch.Err()
+}
+
+type differ struct {
+	meta PatchMetaLookup
+}
+
+// diffStruct compute the patch fields of the struct
+func (d *differ) diffStruct(base, target cue.Value, paths ...string) ([]ast.Decl, error) {
+	var decls []ast.Decl
+	it, err := target.Fields()
+	if err != nil {
+		return nil, err
+	}
+	for it.Next() {
+		label := it.Selector().Unquoted()
+		subPaths := append(append([]string{}, paths...), label)
+		b := base.LookupPath(cue.MakePath(it.Selector()))
+		if !b.Exists() {
+			field, err := newPatchField(label, it.Value(), nil)
+			if err != nil {
+				return nil, err
+			}
+			decls = append(decls, field)
+			continue
+		}
+		fields, err := d.diffField(label, b, it.Value(), subPaths...)
+		if err != nil {
+			return nil, err
+		}
+		decls = append(decls, fields...)
+	}
+	if it, err = base.Fields(); err != nil {
+		return nil, err
+	}
+	for it.Next() {
+		if target.LookupPath(cue.MakePath(it.Selector())).Exists() {
+			continue
+		}
+		decls = append(decls, newField(it.Selector().Unquoted(), ast.NewStruct(newField(DirectivePatch, ast.NewString(PatchDirectiveDelete)))))
+	}
+	return decls, nil
+}
+
+// diffField compute the patch fields for the field exists in both base and
+// target, the $setElementOrder directive may be returned with the field
+func (d *differ) diffField(label string, base, target cue.Value, paths ...string) ([]ast.Decl, error) {
+	if base.Equals(target) {
+		return nil, nil
+	}
+	baseKind, targetKind := base.IncompleteKind(), target.IncompleteKind()
+	switch {
+	case baseKind == cue.StructKind && targetKind == cue.StructKind:
+		decls, err := d.diffStruct(base, target, paths...)
+		if err != nil || len(decls) == 0 {
+			return nil, err
+		}
+		return []ast.Decl{newField(label, ast.NewStruct(declsToElts(decls)...))}, nil
+	case baseKind == cue.ListKind && targetKind == cue.ListKind:
+		tags := findCommentTag(target.Doc())
+		for k, v := range findCommentTag(base.Doc()) {
+			if _, ok := tags[k]; !ok {
+				tags[k] = v
+			}
+		}
+		key, strategy := patchMetaOf(d.meta, tags, paths...)
+		if strategy != StrategyReplace && key != "" {
+			decls, ok, err := d.diffList(label, strings.Split(key, ",")[0], base, target, paths...)
+			if err != nil || ok {
+				return decls, err
+			}
+		}
+		field, err := newPatchField(label, target, map[string]string{TagPatchStrategy: StrategyReplace})
+		if err != nil {
+			return nil, err
+		}
+		return []ast.Decl{field}, nil
+	case targetKind == cue.ListKind:
+		return nil, errors.Errorf("cannot patch %s from %s to list", formatPath(paths...), baseKind)
+	default:
+		field, err := newPatchField(label, target, map[string]string{TagPatchStrategy: StrategyRetainKeys})
+		if err != nil {
+			return nil, err
+		}
+		return []ast.Decl{field}, nil
+	}
+}
+
+type keyedItem struct {
+	keyValue string
+	key      ast.Expr
+	value    cue.Value
+}
+
+// diffList compute the patch of the list merged by patchKey, false is
+// returned if the items cannot be identified by the patchKey
+func (d *differ) diffList(label, key string, base, target cue.Value, paths ...string) ([]ast.Decl, bool, error) {
+	baseItems, ok := keyedItems(base, key)
+	if !ok {
+		return nil, false, nil
+	}
+	targetItems, ok := keyedItems(target, key)
+	if !ok {
+		return nil, false, nil
+	}
+	baseIndex, targetIndex := map[string]int{}, map[string]int{}
+	for i, item := range baseItems {
+		baseIndex[item.keyValue] = i
+	}
+	for i, item := range targetItems {
+		targetIndex[item.keyValue] = i
+	}
+
+	var elts, added []ast.Expr
+	var order []string
+	for _, item := range baseItems {
+		if _, ok := targetIndex[item.keyValue]; !ok {
+			elts = append(elts, ast.NewStruct(newField(key, item.key), newField(DirectivePatch, ast.NewString(PatchDirectiveDelete))))
+			continue
+		}
+		order = append(order, item.keyValue)
+	}
+	for i, item := range targetItems {
+		bi, ok := baseIndex[item.keyValue]
+		if !ok {
+			expr, err := syntaxOf(item.value)
+			if err != nil {
+				return nil, false, err
+			}
+			added = append(added, expr)
+			order = append(order, item.keyValue)
+			continue
+		}
+		decls, err := d.diffStruct(baseItems[bi].value, item.value, append(append([]string{}, paths...), fmt.Sprint(i))...)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(decls) > 0 {
+			elts = append(elts, ast.NewStruct(declsToElts(append([]ast.Decl{newField(key, item.key)}, decls...))...))
+		}
+	}
+	elts = append(elts, added...)
+
+	var decls []ast.Decl
+	if len(elts) > 0 {
+		field := newField(label, ast.NewList(elts...))
+		addTagComments(field, map[string]string{TagPatchKey: key})
+		decls = append(decls, field)
+	}
+	if strings.Join(order, "\n") != strings.Join(keyValues(targetItems), "\n") {
+		var orderElts []ast.Expr
+		for _, item := range targetItems {
+			orderElts = append(orderElts, ast.NewStruct(newField(key, item.key)))
+		}
+		decls = append(decls, newField(DirectiveSetElementOrder+"/"+label, ast.NewList(orderElts...)))
+	}
+	return decls, true, nil
+}
+
+func keyedItems(list cue.Value, key string) ([]keyedItem, bool) {
+	iter, err := list.List()
+	if err != nil {
+		return nil, false
+	}
+	var items []keyedItem
+	seen := map[string]bool{}
+	for iter.Next() {
+		if iter.Value().IncompleteKind() != cue.StructKind {
+			return nil, false
+		}
+		kv := iter.Value().LookupPath(cue.ParsePath(key))
+		if !kv.Exists() || !kv.IsConcrete() {
+			return nil, false
+		}
+		expr, err := syntaxOf(kv)
+		if err != nil {
+			return nil, false
+		}
+		lit, ok := expr.(*ast.BasicLit)
+		if !ok || seen[lit.Value] {
+			return nil, false
+		}
+		seen[lit.Value] = true
+		items = append(items, keyedItem{keyValue: lit.Value, key: lit, value: iter.Value()})
+	}
+	return items, true
+}
+
+func keyValues(items []keyedItem) []string {
+	var values []string
+	for _, item := range items {
+		values = append(values, item.keyValue)
+	}
+	return values
+}
+
+// nolint:staticcheck
+func syntaxOf(v cue.Value) (ast.Expr, error) {
+	node := v.Syntax(cue.Final(), cue.Docs(true))
+	expr, ok := node.(ast.Expr)
+	if !ok {
+		return nil, errors.Errorf("unexpected syntax %T of the value", node)
+	}
+	return expr, nil
+}
+
+func newPatchField(label string, v cue.Value, tags map[string]string) (*ast.Field, error) {
+	expr, err := syntaxOf(v)
+	if err != nil {
+		return nil, err
+	}
+	field := newField(label, expr)
+	addTagComments(field, tags)
+	return field, nil
+}
+
+func newField(label string, value ast.Expr) *ast.Field {
+	var l ast.Label = ast.NewString(label)
+	if ast.IsValidIdent(label) && !strings.HasPrefix(label, "#") && !strings.HasPrefix(label, "_") {
+		l = ast.NewIdent(label)
+	}
+	return &ast.Field{Label: l, Value: value}
+}
+
+func addTagComments(field *ast.Field, tags map[string]string) {
+	for _, k := range []string{TagPatchKey, TagPatchStrategy} {
+		if v, ok := tags[k]; ok {
+			ast.AddComment(field, &ast.CommentGroup{Doc: true, List: []*ast.Comment{{Text: "// +" + k + "=" + v}}})
+		}
+	}
+}
+
+func declsToElts(decls []ast.Decl) []interface{} {
+	elts := make([]interface{}, 0, len(decls))
+	for _, decl := range decls {
+		elts = append(elts, decl)
+	}
+	return elts
+}
+
+func jsonDocs(base, target cue.Value) ([]byte, []byte, error) {
+	baseJSON, err := base.MarshalJSON()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to marshal base")
+	}
+	targetJSON, err := target.MarshalJSON()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to marshal target")
+	}
+	return baseJSON, targetJSON, nil
+}
+
+func jsonMergePatchDiff(base, target cue.Value) (cue.Value, error) {
+	baseJSON, targetJSON, err := jsonDocs(base, target)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	patch, err := jsonpatch.CreateMergePatch(baseJSON, targetJSON)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	v := base.Context().CompileBytes(patch)
+	return v, v.Err()
+}
+
+func jsonPatchDiff(base, target cue.Value) (cue.Value, error) {
+	baseJSON, targetJSON, err := jsonDocs(base, target)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	operations, err := jsonpatchv2.CreatePatch(baseJSON, targetJSON)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	if operations == nil {
+		operations = []jsonpatchv2.Operation{}
+	}
+	patch, err := json.Marshal(map[string]interface{}{"operations": operations})
+	if err != nil {
+		return cue.Value{}, err
+	}
+	v := base.Context().CompileBytes(patch)
+	return v, v.Err()
+}
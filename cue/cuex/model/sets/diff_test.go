@@ -0,0 +1,132 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sets
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	testCases := map[string]struct {
+		base    string
+		target  string
+		options []UnifyOption
+		patch   string
+	}{
+		"no change": {
+			base:   `a: 1, b: {c: [1, 2]}`,
+			target: `a: 1, b: {c: [1, 2]}`,
+			patch:  `{}`,
+		},
+		"add, change and remove fields": {
+			base:   `a: 1, b: {c: 1, d: 2}, e: "x"`,
+			target: `a: 2, b: {c: 1, f: 3}, e: "x"`,
+			patch:  `{"a": 2, "b": {"f": 3, "d": {"$patch": "delete"}}}`,
+		},
+		"change kind": {
+			base:   `a: {b: 1}`,
+			target: `a: "b"`,
+			patch:  `{"a": "b"}`,
+		},
+		"replace list without patchKey": {
+			base:   `args: ["a", "b"], items: [{x: 1}]`,
+			target: `args: ["a"], items: [{x: 1}]`,
+			patch:  `{"args": ["a"]}`,
+		},
+		"merge list by patchKey": {
+			base: `
+// +patchKey=name
+containers: [{name: "a", image: "x"}, {name: "b", image: "y"}, {name: "c", image: "z"}]`,
+			target: `
+// +patchKey=name
+containers: [{name: "a", image: "x"}, {name: "c", image: "w"}, {name: "d", image: "v"}]`,
+			patch: `{"containers": [{"name": "b", "$patch": "delete"}, {"name": "c", "image": "w"}, {"name": "d", "image": "v"}]}`,
+		},
+		"reorder list by patchKey": {
+			base: `
+// +patchKey=name
+containers: [{name: "a"}, {name: "b"}]`,
+			target: `
+// +patchKey=name
+containers: [{name: "c"}, {name: "b"}, {name: "a"}]`,
+			patch: `{"containers": [{"name": "c"}], "$setElementOrder/containers": [{"name": "c"}, {"name": "b"}, {"name": "a"}]}`,
+		},
+		"nested change in list item": {
+			base: `
+// +patchKey=name
+containers: [{name: "a", env: {A: "1", B: "2"}, ports: [80]}]`,
+			target: `
+// +patchKey=name
+containers: [{name: "a", env: {A: "1"}, ports: [80, 443]}]`,
+			patch: `{"containers": [{"name": "a", "env": {"B": {"$patch": "delete"}}, "ports": [80, 443]}]}`,
+		},
+		"patch meta of built-in kind": {
+			base: `
+apiVersion: "v1"
+kind: "Pod"
+spec: containers: [{name: "main", image: "nginx"}, {name: "sidecar", image: "envoy"}]`,
+			target: `
+apiVersion: "v1"
+kind: "Pod"
+spec: containers: [{name: "main", image: "nginx:1.25"}, {name: "sidecar", image: "envoy"}]`,
+			patch:   `{"spec": {"containers": [{"name": "main", "image": "nginx:1.25"}]}}`,
+			options: []UnifyOption{UnifyWithPatchMetaResolver{DefaultPatchMetaRegistry}},
+		},
+		"json merge patch": {
+			base:    `a: 1, b: {c: 1, d: 2}, e: [1, 2]`,
+			target:  `a: 2, b: {c: 1}, e: [1]`,
+			options: []UnifyOption{UnifyByJSONMergePatch{}},
+			patch:   `{"a": 2, "b": {"d": null}, "e": [1]}`,
+		},
+		"json patch": {
+			base:    `a: 1, b: {c: 1, d: 2}`,
+			target:  `a: 1, b: {c: 1}`,
+			options: []UnifyOption{UnifyByJSONPatch{}},
+			patch:   `{"operations": [{"op": "remove", "path": "/b/d"}]}`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := cuecontext.New()
+			base, target := ctx.CompileString(tc.base), ctx.CompileString(tc.target)
+			patch, err := Diff(base, target, tc.options...)
+			r.NoError(err)
+			bs, err := patch.MarshalJSON()
+			r.NoError(err)
+			r.JSONEq(tc.patch, string(bs))
+
+			ret, err := StrategyUnify(base, patch, tc.options...)
+			r.NoError(err)
+			expected, err := target.MarshalJSON()
+			r.NoError(err)
+			actual, err := ret.MarshalJSON()
+			r.NoError(err)
+			r.JSONEq(string(expected), string(actual))
+		})
+	}
+}
+
+func TestDiffError(t *testing.T) {
+	ctx := cuecontext.New()
+	_, err := Diff(ctx.CompileString(`a: 1`), ctx.CompileString(`a: [1]`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cannot patch a")
+}
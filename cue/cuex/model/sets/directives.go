@@ -0,0 +1,508 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sets
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/literal"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DirectivePatch the $patch directive of the strategic merge patch, the
+	// value can be merge, replace or delete
+	DirectivePatch = "$patch"
+	// DirectiveRetainKeys the $retainKeys directive of the strategic merge
+	// patch, only the listed keys are retained in base
+	DirectiveRetainKeys = "$retainKeys"
+	// DirectiveSetElementOrder the $setElementOrder/<field> directive of the
+	// strategic merge patch, it specifies the order of the list items
+	DirectiveSetElementOrder = "$setElementOrder"
+	// DirectiveDeleteFromPrimitiveList the $deleteFromPrimitiveList/<field>
+	// directive of the strategic merge patch, the listed values are removed
+	// from the primitive list in base
+	DirectiveDeleteFromPrimitiveList = "$deleteFromPrimitiveList"
+
+	// PatchDirectiveMerge merge the value, this is the default behaviour
+	PatchDirectiveMerge = "merge"
+	// PatchDirectiveReplace replace the value in base with the one in patch
+	PatchDirectiveReplace = "replace"
+	// PatchDirectiveDelete delete the value from base
+	PatchDirectiveDelete = "delete"
+)
+
+func isDirective(label string) bool {
+	return strings.HasPrefix(label, "$")
+}
+
+type elementOrder struct {
+	paths []string
+	order []ast.Expr
+}
+
+// directiveHandler handle the strategic merge directives. The directives
+// are removed from the patch before the patch strategies are applied, and
+// the element orders are applied after that.
+type directiveHandler struct {
+	exp    *explainer
+	meta   PatchMetaLookup
+	orders []elementOrder
+}
+
+func newDirectiveHandler(exp *explainer, meta PatchMetaLookup) *directiveHandler {
+	return &directiveHandler{exp: exp, meta: meta}
+}
+
+func (h *directiveHandler) preprocess() interceptor {
+	return func(baseNode ast.Node, patchNode ast.Node) error {
+		h.orders = nil
+		directive, err := h.processStruct(baseNode, patchNode)
+		if err != nil {
+			return err
+		}
+		switch directive {
+		case PatchDirectiveDelete:
+			if decls := declsOf(patchNode); decls != nil {
+				*decls = nil
+			}
+			fallthrough
+		case PatchDirectiveReplace:
+			if decls := declsOf(baseNode); decls != nil {
+				*decls = nil
+			}
+		}
+		return nil
+	}
+}
+
+func (h *directiveHandler) applyElementOrders() interceptor {
+	return func(baseNode ast.Node, patchNode ast.Node) error {
+		for _, o := range h.orders {
+			baseList, _ := lookUpList(baseNode, o.paths...)
+			patchList, _ := lookUpList(patchNode, o.paths...)
+			if baseList == nil && patchList == nil {
+				continue
+			}
+			reorderList(baseList, patchList, o.order, h.listPatchKey(baseNode, patchNode, o.paths))
+			h.exp.recordField(ActionMerged, DirectiveSetElementOrder, o.paths...)
+		}
+		return nil
+	}
+}
+
+// listPatchKey find the patchKey of the list at the given path from the
+// comment tags of the list field in patch or base, or from the patch meta
+func (h *directiveHandler) listPatchKey(baseNode ast.Node, patchNode ast.Node, paths []string) string {
+	tags := map[string]string{}
+	for _, node := range []ast.Node{baseNode, patchNode} {
+		parent, err := lookUp(node, paths[:len(paths)-1]...)
+		if err != nil {
+			continue
+		}
+		if field := findField(declsOf(parent), paths[len(paths)-1]); field != nil {
+			for k, v := range findCommentTag(field.Comments()) {
+				tags[k] = v
+			}
+		}
+	}
+	key, _ := patchMetaOf(h.meta, tags, paths...)
+	return key
+}
+
+// processStruct remove the directives from the patch struct and apply them
+// to the base struct, the $patch directive is returned to the caller
+func (h *directiveHandler) processStruct(base, patch ast.Node, paths ...string) (string, error) {
+	patchDecls := declsOf(patch)
+	if patchDecls == nil {
+		return "", nil
+	}
+	baseDecls := declsOf(base)
+	directive := ""
+	var retainKeys []string
+	hasRetainKeys := false
+	var decls []ast.Decl
+	for _, decl := range *patchDecls {
+		field, ok := decl.(*ast.Field)
+		if !ok {
+			decls = append(decls, decl)
+			continue
+		}
+		label := strings.Trim(LabelStr(field.Label), `"`)
+		switch {
+		case label == DirectivePatch:
+			s, err := stringValue(field.Value)
+			if err != nil {
+				return "", errors.Wrapf(err, "invalid %s in %s", DirectivePatch, formatPath(paths...))
+			}
+			if s != PatchDirectiveMerge && s != PatchDirectiveReplace && s != PatchDirectiveDelete {
+				return "", errors.Errorf("unknown %s directive %q in %s", DirectivePatch, s, formatPath(paths...))
+			}
+			directive = s
+		case label == DirectiveRetainKeys:
+			keys, err := stringListValue(field.Value)
+			if err != nil {
+				return "", errors.Wrapf(err, "invalid %s in %s", DirectiveRetainKeys, formatPath(paths...))
+			}
+			retainKeys, hasRetainKeys = keys, true
+		case strings.HasPrefix(label, DirectiveSetElementOrder+"/"):
+			list, ok := peelCloseExpr(field.Value).(*ast.ListLit)
+			if !ok {
+				return "", errors.Errorf("invalid %s in %s: must be a list", label, formatPath(paths...))
+			}
+			name := strings.TrimPrefix(label, DirectiveSetElementOrder+"/")
+			h.orders = append(h.orders, elementOrder{paths: append(append([]string{}, paths...), name), order: list.Elts})
+		case strings.HasPrefix(label, DirectiveDeleteFromPrimitiveList+"/"):
+			list, ok := peelCloseExpr(field.Value).(*ast.ListLit)
+			if !ok {
+				return "", errors.Errorf("invalid %s in %s: must be a list", label, formatPath(paths...))
+			}
+			name := strings.TrimPrefix(label, DirectiveDeleteFromPrimitiveList+"/")
+			if baseField := findField(baseDecls, name); baseField != nil {
+				if baseList, ok := peelCloseExpr(baseField.Value).(*ast.ListLit); ok {
+					deleteFromList(baseList, list.Elts)
+					h.exp.recordField(ActionRemoved, DirectiveDeleteFromPrimitiveList, append(paths, name)...)
+				}
+			}
+		default:
+			decls = append(decls, decl)
+		}
+	}
+	*patchDecls = decls
+	if directive == PatchDirectiveDelete {
+		return directive, nil
+	}
+
+	if hasRetainKeys && baseDecls != nil {
+		var kept []ast.Decl
+		for _, decl := range *baseDecls {
+			if field, ok := decl.(*ast.Field); ok && !slices.Contains(retainKeys, strings.Trim(LabelStr(field.Label), `"`)) {
+				h.exp.recordField(ActionRemoved, DirectiveRetainKeys, append(paths, strings.Trim(LabelStr(field.Label), `"`))...)
+				continue
+			}
+			kept = append(kept, decl)
+		}
+		*baseDecls = kept
+	}
+
+	decls = nil
+	for _, decl := range *patchDecls {
+		field, ok := decl.(*ast.Field)
+		if !ok {
+			decls = append(decls, decl)
+			continue
+		}
+		label := strings.Trim(LabelStr(field.Label), `"`)
+		subPaths := append(append([]string{}, paths...), label)
+		baseField := findField(baseDecls, label)
+		switch val := peelCloseExpr(field.Value).(type) {
+		case *ast.StructLit:
+			var baseValue ast.Node
+			if baseField != nil {
+				baseValue = baseField.Value
+			}
+			d, err := h.processStruct(baseValue, val, subPaths...)
+			if err != nil {
+				return "", err
+			}
+			switch d {
+			case PatchDirectiveDelete:
+				h.exp.recordField(ActionRemoved, DirectivePatch+"="+d, subPaths...)
+				if baseField != nil {
+					*baseDecls = removeDecl(*baseDecls, baseField)
+				}
+				continue
+			case PatchDirectiveReplace:
+				h.exp.recordField(ActionReplaced, DirectivePatch+"="+d, subPaths...)
+				if baseField != nil {
+					baseField.Value = ast.NewStruct()
+				}
+			}
+		case *ast.ListLit:
+			if err := h.processList(baseField, field, val, subPaths...); err != nil {
+				return "", err
+			}
+		}
+		decls = append(decls, decl)
+	}
+	*patchDecls = decls
+	return directive, nil
+}
+
+// processList handle the $patch directives in the items of the patch list
+func (h *directiveHandler) processList(baseField *ast.Field, field *ast.Field, patchList *ast.ListLit, paths ...string) error {
+	var baseList *ast.ListLit
+	if baseField != nil {
+		baseList, _ = peelCloseExpr(baseField.Value).(*ast.ListLit)
+	}
+	key, _ := patchMetaOf(h.meta, findCommentTag(field.Comments()), paths...)
+	replace := false
+	var elts []ast.Expr
+	for i, elt := range patchList.Elts {
+		item, ok := peelCloseExpr(elt).(*ast.StructLit)
+		if !ok {
+			elts = append(elts, elt)
+			continue
+		}
+		var baseItem ast.Node
+		baseIdx := -1
+		keyValue, hasKey := keyValueOf(item, key)
+		if hasKey && baseList != nil {
+			baseIdx = findListItem(baseList, key, keyValue)
+			if baseIdx >= 0 {
+				baseItem = baseList.Elts[baseIdx]
+			}
+		}
+		d, err := h.processStruct(baseItem, item, append(paths, strconv.Itoa(i))...)
+		if err != nil {
+			return err
+		}
+		switch d {
+		case PatchDirectiveReplace:
+			replace = true
+			continue
+		case PatchDirectiveDelete:
+			if !hasKey {
+				return errors.Errorf("cannot find the patchKey of the item to delete in %s", formatPath(paths...))
+			}
+			h.exp.recordListItem(ActionRemoved, key, keyValue, baseIdx, -1, paths...)
+			if baseIdx >= 0 {
+				baseList.Elts = append(baseList.Elts[:baseIdx], baseList.Elts[baseIdx+1:]...)
+			}
+			continue
+		}
+		elts = append(elts, elt)
+	}
+	if len(elts) == 0 && len(patchList.Elts) > 0 {
+		elts = append(elts, &ast.Ellipsis{})
+	}
+	patchList.Elts = elts
+	if replace {
+		h.exp.recordField(ActionReplaced, DirectivePatch+"="+PatchDirectiveReplace, paths...)
+		if baseField != nil {
+			baseField.Value = ast.NewList(&ast.Ellipsis{})
+		}
+	}
+	return nil
+}
+
+// reorderList reorder the aligned base and patch list by the given order,
+// the struct items are identified by the patchKey. Same as the strategic
+// merge patch, the base items not in the order keep their relative positions
+// to the ordered items in base, and the other items are put at the end.
+func reorderList(baseList, patchList *ast.ListLit, order []ast.Expr, key string) {
+	var baseElts, patchElts []ast.Expr
+	if baseList != nil {
+		baseElts = trimEllipsis(baseList.Elts)
+	}
+	if patchList != nil {
+		patchElts = trimEllipsis(patchList.Elts)
+	}
+	identity := func(expr ast.Expr) (string, bool) {
+		if lit, ok := peelCloseExpr(expr).(*ast.BasicLit); ok {
+			return lit.Value, true
+		}
+		if key == "" {
+			return "", false
+		}
+		return keyValueOf(expr, key)
+	}
+	rank := map[string]int{}
+	for i, o := range order {
+		if id, ok := identity(o); ok {
+			rank[id] = i
+		}
+	}
+
+	n := len(baseElts)
+	if len(patchElts) > n {
+		n = len(patchElts)
+	}
+	ranks := make([]int, n)
+	for i := 0; i < n; i++ {
+		ranks[i] = len(order) + i
+		for _, elts := range [][]ast.Expr{patchElts, baseElts} {
+			if i >= len(elts) {
+				continue
+			}
+			if id, ok := identity(elts[i]); ok {
+				if r, ok := rank[id]; ok {
+					ranks[i] = r
+					break
+				}
+			}
+		}
+	}
+	var ordered, others []int
+	for i := 0; i < n; i++ {
+		if ranks[i] < len(order) {
+			ordered = append(ordered, i)
+		} else {
+			others = append(others, i)
+		}
+	}
+	sort.SliceStable(ordered, func(a, b int) bool { return ranks[ordered[a]] < ranks[ordered[b]] })
+	// insert the other items before the first ordered item behind them in base
+	idx := make([]int, 0, n)
+	for len(others) > 0 || len(ordered) > 0 {
+		if len(ordered) == 0 || (len(others) > 0 && others[0] < len(baseElts) && ordered[0] < len(baseElts) && others[0] < ordered[0]) {
+			idx, others = append(idx, others[0]), others[1:]
+		} else {
+			idx, ordered = append(idx, ordered[0]), ordered[1:]
+		}
+	}
+
+	reorder := func(list *ast.ListLit, elts []ast.Expr) {
+		if list == nil {
+			return
+		}
+		var nElts []ast.Expr
+		for _, i := range idx {
+			if i < len(elts) {
+				nElts = append(nElts, elts[i])
+			} else {
+				nElts = append(nElts, ast.NewIdent("_"))
+			}
+		}
+		if len(list.Elts) > 0 && isEllipsis(list.Elts[len(list.Elts)-1]) {
+			nElts = append(nElts, &ast.Ellipsis{})
+		}
+		list.Elts = nElts
+	}
+	reorder(baseList, baseElts)
+	reorder(patchList, patchElts)
+}
+
+func declsOf(node ast.Node) *[]ast.Decl {
+	switch v := peelCloseExpr(node).(type) {
+	case *ast.File:
+		return &v.Decls
+	case *ast.StructLit:
+		return &v.Elts
+	}
+	return nil
+}
+
+func findField(decls *[]ast.Decl, label string) *ast.Field {
+	if decls == nil {
+		return nil
+	}
+	for _, decl := range *decls {
+		if field, ok := decl.(*ast.Field); ok && strings.Trim(LabelStr(field.Label), `"`) == label {
+			return field
+		}
+	}
+	return nil
+}
+
+func removeDecl(decls []ast.Decl, target ast.Decl) []ast.Decl {
+	var nDecls []ast.Decl
+	for _, decl := range decls {
+		if decl != target {
+			nDecls = append(nDecls, decl)
+		}
+	}
+	return nDecls
+}
+
+func lookUpList(node ast.Node, paths ...string) (*ast.ListLit, error) {
+	n, err := lookUp(node, paths...)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := n.(*ast.ListLit)
+	if !ok {
+		return nil, notFoundErr
+	}
+	return list, nil
+}
+
+func keyValueOf(item ast.Node, key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+	nodev, err := lookUp(item, strings.Split(key, ".")...)
+	if err != nil {
+		return "", false
+	}
+	lit, ok := nodev.(*ast.BasicLit)
+	if !ok {
+		return "", false
+	}
+	return lit.Value, true
+}
+
+func findListItem(list *ast.ListLit, key, value string) int {
+	for i, elt := range list.Elts {
+		if v, ok := keyValueOf(elt, key); ok && v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+func deleteFromList(list *ast.ListLit, values []ast.Expr) {
+	deleted := map[string]bool{}
+	for _, v := range values {
+		if lit, ok := peelCloseExpr(v).(*ast.BasicLit); ok {
+			deleted[lit.Value] = true
+		}
+	}
+	var elts []ast.Expr
+	for _, elt := range list.Elts {
+		if lit, ok := peelCloseExpr(elt).(*ast.BasicLit); ok && deleted[lit.Value] {
+			continue
+		}
+		elts = append(elts, elt)
+	}
+	list.Elts = elts
+}
+
+func trimEllipsis(elts []ast.Expr) []ast.Expr {
+	if len(elts) > 0 && isEllipsis(elts[len(elts)-1]) {
+		return elts[:len(elts)-1]
+	}
+	return elts
+}
+
+func stringValue(expr ast.Expr) (string, error) {
+	lit, ok := peelCloseExpr(expr).(*ast.BasicLit)
+	if !ok {
+		return "", errors.New("must be a string")
+	}
+	return literal.Unquote(lit.Value)
+}
+
+func stringListValue(expr ast.Expr) ([]string, error) {
+	list, ok := peelCloseExpr(expr).(*ast.ListLit)
+	if !ok {
+		return nil, errors.New("must be a list of string")
+	}
+	var values []string
+	for _, elt := range trimEllipsis(list.Elts) {
+		s, err := stringValue(elt)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, s)
+	}
+	return values, nil
+}
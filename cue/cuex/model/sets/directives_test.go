@@ -0,0 +1,248 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sets
+
+import (
+	"fmt"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// smpObject describes the patch meta of the test cases for comparing with
+// the strategic merge patch of kubernetes
+type smpObject struct {
+	A          int               `json:"a,omitempty"`
+	B          map[string]int    `json:"b,omitempty"`
+	C          int               `json:"c,omitempty"`
+	Containers []smpContainer    `json:"containers,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	Strategy   *smpStrategy      `json:"strategy,omitempty" patchStrategy:"retainKeys"`
+	Finalizers []string          `json:"finalizers,omitempty" patchStrategy:"merge"`
+	Spec       *smpSpec          `json:"spec,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+type smpContainer struct {
+	Name      string                       `json:"name"`
+	Image     string                       `json:"image,omitempty"`
+	Cmd       string                       `json:"cmd,omitempty"`
+	Resources map[string]map[string]string `json:"resources,omitempty"`
+	Env       map[string]string            `json:"env,omitempty"`
+}
+
+type smpStrategy struct {
+	Type          string `json:"type,omitempty"`
+	RollingUpdate *struct {
+		MaxSurge string `json:"maxSurge,omitempty"`
+	} `json:"rollingUpdate,omitempty"`
+}
+
+type smpSpec struct {
+	Ports []struct {
+		Name string `json:"name"`
+		Port int    `json:"port"`
+	} `json:"ports,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+}
+
+func TestStrategyUnifyDirectives(t *testing.T) {
+	testCases := map[string]struct {
+		base   string
+		patch  string
+		result string
+		err    string
+		// the result or error of the strategic merge patch if different
+		smpResult string
+		smpErr    string
+	}{
+		"delete map": {
+			base:      `a: 1, b: {c: 1, d: 2}`,
+			patch:     `b: {$patch: "delete"}`,
+			result:    `{"a": 1}`,
+			smpResult: `{"a": 1, "b": {}}`,
+		},
+		"delete missing map": {
+			base:   `a: 1`,
+			patch:  `b: {$patch: "delete"}`,
+			result: `{"a": 1}`,
+		},
+		"replace map": {
+			base:   `a: 1, b: {c: 1, d: 2}`,
+			patch:  `b: {$patch: "replace", e: 3}`,
+			result: `{"a": 1, "b": {"e": 3}}`,
+		},
+		"replace root": {
+			base:   `a: 1, b: 2`,
+			patch:  `$patch: "replace", c: 3`,
+			result: `{"c": 3}`,
+		},
+		"merge map": {
+			base:   `b: {c: 1}`,
+			patch:  `b: {$patch: "merge", d: 2}`,
+			result: `{"b": {"c": 1, "d": 2}}`,
+			smpErr: `unknown patch type: merge`,
+		},
+		"delete list item by patchKey": {
+			base: `
+// +patchKey=name
+containers: [{name: "a", image: "x"}, {name: "b", image: "y"}, {name: "c", image: "z"}]`,
+			patch: `
+// +patchKey=name
+containers: [{name: "b", $patch: "delete"}, {name: "c", cmd: "w"}]`,
+			result: `{"containers": [{"name": "a", "image": "x"}, {"name": "c", "image": "z", "cmd": "w"}]}`,
+		},
+		"delete list item without patchKey": {
+			base:  `items: [{name: "a"}, {name: "b"}]`,
+			patch: `items: [{name: "a", $patch: "delete"}]`,
+			err:   `cannot find the patchKey of the item to delete in items`,
+		},
+		"replace list": {
+			base: `
+// +patchKey=name
+containers: [{name: "a", image: "x"}, {name: "b", image: "y"}]`,
+			patch: `
+// +patchKey=name
+containers: [{name: "c", image: "z"}, {$patch: "replace"}]`,
+			result: `{"containers": [{"name": "c", "image": "z"}]}`,
+		},
+		"nested directive in list item": {
+			base: `
+// +patchKey=name
+containers: [{name: "a", resources: {limits: cpu: "1"}, env: {A: "1"}}]`,
+			patch: `
+// +patchKey=name
+containers: [{name: "a", resources: {$patch: "replace", requests: cpu: "2"}}]`,
+			result: `{"containers": [{"name": "a", "resources": {"requests": {"cpu": "2"}}, "env": {"A": "1"}}]}`,
+		},
+		"retainKeys": {
+			base:   `strategy: {type: "RollingUpdate", rollingUpdate: maxSurge: "30%"}`,
+			patch:  `strategy: {$retainKeys: ["type"], type: "RollingUpdate"}`,
+			result: `{"strategy": {"type": "RollingUpdate"}}`,
+		},
+		"deleteFromPrimitiveList": {
+			base:   `finalizers: ["a", "b", "c"]`,
+			patch:  `"$deleteFromPrimitiveList/finalizers": ["b"]`,
+			result: `{"finalizers": ["a", "c"]}`,
+		},
+		"setElementOrder with patchKey": {
+			base: `
+// +patchKey=name
+containers: [{name: "a", image: "x"}, {name: "b", image: "y"}]`,
+			patch: `
+"$setElementOrder/containers": [{name: "c"}, {name: "b"}, {name: "a"}]
+// +patchKey=name
+containers: [{name: "c", image: "z"}, {name: "a", cmd: "w"}]`,
+			result: `{"containers": [{"name": "c", "image": "z"}, {"name": "b", "image": "y"}, {"name": "a", "image": "x", "cmd": "w"}]}`,
+		},
+		"setElementOrder with items not in order": {
+			base: `
+// +patchKey=name
+containers: [{name: "a", image: "x"}, {name: "b", image: "y"}, {name: "c", image: "z"}]`,
+			patch: `
+"$setElementOrder/containers": [{name: "c"}, {name: "d"}, {name: "a"}]
+// +patchKey=name
+containers: [{name: "d", image: "w"}]`,
+			result: `{"containers": [{"name": "b", "image": "y"}, {"name": "c", "image": "z"}, {"name": "d", "image": "w"}, {"name": "a", "image": "x"}]}`,
+		},
+		"setElementOrder of primitive list": {
+			base:   `finalizers: ["a", "b", "c"]`,
+			patch:  `"$setElementOrder/finalizers": ["c", "a"]`,
+			result: `{"finalizers": ["b", "c", "a"]}`,
+		},
+		"setElementOrder in nested struct": {
+			base: `
+spec: {
+	// +patchKey=name
+	ports: [{name: "http", port: 80}, {name: "https", port: 443}]
+}`,
+			patch: `
+spec: "$setElementOrder/ports": [{name: "https"}, {name: "http"}]`,
+			result: `{"spec": {"ports": [{"name": "https", "port": 443}, {"name": "http", "port": 80}]}}`,
+		},
+		"unknown patch directive": {
+			base:  `a: {b: 1}`,
+			patch: `a: {$patch: "remove"}`,
+			err:   `unknown $patch directive "remove" in a`,
+		},
+		"invalid retainKeys": {
+			base:  `a: {b: 1}`,
+			patch: `a: {$retainKeys: "b"}`,
+			err:   `invalid $retainKeys in a`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := cuecontext.New()
+			base := ctx.CompileString(tc.base)
+			patch := ctx.CompileString(tc.patch)
+			r.NoError(base.Err())
+			r.NoError(patch.Err())
+			v, err := StrategyUnify(base, patch)
+			if tc.err != "" {
+				r.Error(err)
+				r.Contains(err.Error(), tc.err)
+				return
+			}
+			r.NoError(err)
+			bs, err := v.MarshalJSON()
+			r.NoError(err)
+			r.JSONEq(tc.result, string(bs), fmt.Sprintf("result: %s", bs))
+
+			// the same result as the strategic merge patch of kubernetes
+			baseJSON, err := base.MarshalJSON()
+			r.NoError(err)
+			patchJSON, err := patch.MarshalJSON()
+			r.NoError(err)
+			smp, err := strategicpatch.StrategicMergePatch(baseJSON, patchJSON, smpObject{})
+			if tc.smpErr != "" {
+				r.ErrorContains(err, tc.smpErr)
+				return
+			}
+			r.NoError(err)
+			if tc.smpResult == "" {
+				tc.smpResult = tc.result
+			}
+			r.JSONEq(tc.smpResult, string(smp), fmt.Sprintf("strategic merge patch result: %s", smp))
+		})
+	}
+}
+
+func TestStrategyUnifyDirectivesExplain(t *testing.T) {
+	r := require.New(t)
+	ctx := cuecontext.New()
+	base := ctx.CompileString(`
+a: {b: 1}
+c: {d: 1}
+finalizers: ["x", "y"]`)
+	patch := ctx.CompileString(`
+a: {$patch: "delete"}
+c: {$patch: "replace", e: 2}
+"$deleteFromPrimitiveList/finalizers": ["x"]`)
+	exp := &Explanation{}
+	_, err := StrategyUnify(base, patch, UnifyWithExplanation{exp})
+	r.NoError(err)
+	actions := map[string]ExplainAction{}
+	for _, e := range exp.Entries {
+		actions[e.Path] = e.Action
+	}
+	r.Equal(ActionRemoved, actions["a"])
+	r.Equal(ActionReplaced, actions["c"])
+	r.Equal(ActionRemoved, actions["finalizers"])
+	r.NotContains(actions, "$deleteFromPrimitiveList/finalizers")
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sets
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue"
+	cueerrors "cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/literal"
+)
+
+// ExplainAction the action taken on a field during StrategyUnify
+type ExplainAction string
+
+const (
+	// ActionMerged the field exists in both base and patch and is merged
+	ActionMerged ExplainAction = "merged"
+	// ActionReplaced the field in base is replaced by the one in patch
+	ActionReplaced ExplainAction = "replaced"
+	// ActionRetained the field only exists in base and is retained
+	ActionRetained ExplainAction = "retained"
+	// ActionAdded the field only exists in patch and is added
+	ActionAdded ExplainAction = "added"
+	// ActionRemoved the field is removed from base by patch
+	ActionRemoved ExplainAction = "removed"
+)
+
+// strategyCUEUnify the fields are unified by CUE
+const strategyCUEUnify = "unify"
+
+// ExplainEntry describes how one field is handled during StrategyUnify
+type ExplainEntry struct {
+	Path     string        `json:"path"`
+	Action   ExplainAction `json:"action"`
+	Strategy string        `json:"strategy"`
+	BasePos  string        `json:"basePos,omitempty"`
+	PatchPos string        `json:"patchPos,omitempty"`
+}
+
+// Conflict describes a conflict found when unifying base and patch
+type Conflict struct {
+	Path      string   `json:"path"`
+	Message   string   `json:"message"`
+	Positions []string `json:"positions,omitempty"`
+}
+
+// Explanation the structured report of StrategyUnify
+type Explanation struct {
+	Entries   []ExplainEntry `json:"entries"`
+	Conflicts []Conflict     `json:"conflicts,omitempty"`
+}
+
+// UnifyWithExplanation fill the given Explanation during StrategyUnify
+type UnifyWithExplanation struct {
+	*Explanation
+}
+
+// ApplyToOption apply to option
+func (op UnifyWithExplanation) ApplyToOption(params *UnifyParams) {
+	params.Explanation = op.Explanation
+}
+
+type explainer struct {
+	base, patch cue.Value
+	explanation *Explanation
+	handled     map[string]bool
+	// listItems maps the path of merged list items in the result to the
+	// index in patch and the patchKey label
+	listItems map[string]listItem
+}
+
+type listItem struct {
+	patchIdx int
+	label    string
+}
+
+func newExplainer(base, patch cue.Value, explanation *Explanation) *explainer {
+	if explanation == nil {
+		return nil
+	}
+	explanation.Entries, explanation.Conflicts = []ExplainEntry{}, nil
+	return &explainer{base: base, patch: patch, explanation: explanation, handled: map[string]bool{}, listItems: map[string]listItem{}}
+}
+
+// formatPath format the labels into a readable path such as a.b[0].c
+func formatPath(labels ...string) string {
+	sb := strings.Builder{}
+	for _, label := range labels {
+		if _, err := strconv.Atoi(label); err == nil || strings.HasPrefix(label, "[") {
+			if !strings.HasPrefix(label, "[") {
+				label = "[" + label + "]"
+			}
+			sb.WriteString(label)
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteString(".")
+		}
+		sb.WriteString(label)
+	}
+	return sb.String()
+}
+
+func toCuePath(labels ...string) cue.Path {
+	var sels []cue.Selector
+	for _, label := range labels {
+		if idx, err := strconv.Atoi(label); err == nil {
+			sels = append(sels, cue.Index(idx))
+		} else {
+			sels = append(sels, cue.Str(label))
+		}
+	}
+	return cue.MakePath(sels...)
+}
+
+func posOf(v cue.Value) string {
+	if pos := v.Pos(); pos.IsValid() {
+		return pos.String()
+	}
+	return ""
+}
+
+func (in *explainer) record(entry ExplainEntry) {
+	if in == nil {
+		return
+	}
+	in.explanation.Entries = append(in.explanation.Entries, entry)
+}
+
+// recordField record the action for the field at the given labels, the
+// field is skipped when walking values later
+func (in *explainer) recordField(action ExplainAction, strategy string, labels ...string) {
+	if in == nil {
+		return
+	}
+	in.handled[formatPath(labels...)] = true
+	in.record(ExplainEntry{
+		Path:     formatPath(labels...),
+		Action:   action,
+		Strategy: strategy,
+		BasePos:  posOf(in.base.LookupPath(toCuePath(labels...))),
+		PatchPos: posOf(in.patch.LookupPath(toCuePath(labels...))),
+	})
+}
+
+// recordListItem record the action for the list item merged by patchKey
+func (in *explainer) recordListItem(action ExplainAction, key string, keyValue string, baseIdx, patchIdx int, labels ...string) {
+	if in == nil {
+		return
+	}
+	in.handled[formatPath(labels...)] = true
+	if s, err := literal.Unquote(keyValue); err == nil {
+		keyValue = s
+	}
+	label := "[" + key + "=" + keyValue + "]"
+	if baseIdx >= 0 && patchIdx >= 0 {
+		in.listItems[formatPath(append(labels, strconv.Itoa(baseIdx))...)] = listItem{patchIdx: patchIdx, label: label}
+	}
+	entry := ExplainEntry{
+		Path:     formatPath(append(labels, label)...),
+		Action:   action,
+		Strategy: TagPatchKey + "=" + key,
+	}
+	if baseIdx >= 0 {
+		entry.BasePos = posOf(in.base.LookupPath(toCuePath(append(labels, strconv.Itoa(baseIdx))...)))
+	}
+	if patchIdx >= 0 {
+		entry.PatchPos = posOf(in.patch.LookupPath(toCuePath(append(labels, strconv.Itoa(patchIdx))...)))
+	}
+	in.record(entry)
+}
+
+// explainValues walk the fields of base and patch which are not handled by
+// patch strategies, and record them with the given strategy
+func (in *explainer) explainValues(strategy string, base, patch cue.Value, labels ...string) {
+	if in == nil {
+		return
+	}
+	it, err := patch.Fields()
+	if err != nil {
+		return
+	}
+	for it.Next() {
+		sel := it.Selector()
+		_labels := append(append([]string{}, labels...), sel.Unquoted())
+		if in.handled[formatPath(_labels...)] || isDirective(sel.Unquoted()) {
+			continue
+		}
+		b := base.LookupPath(cue.MakePath(sel))
+		switch {
+		case strategy == StrategyJSONMergePatch && it.Value().IsNull():
+			in.record(ExplainEntry{Path: formatPath(_labels...), Action: ActionRemoved, Strategy: strategy, BasePos: posOf(b), PatchPos: posOf(it.Value())})
+		case !b.Exists():
+			in.record(ExplainEntry{Path: formatPath(_labels...), Action: ActionAdded, Strategy: strategy, PatchPos: posOf(it.Value())})
+		case b.IncompleteKind() == cue.StructKind && it.Value().IncompleteKind() == cue.StructKind:
+			in.explainValues(strategy, b, it.Value(), _labels...)
+		case strategy == StrategyJSONMergePatch:
+			in.record(ExplainEntry{Path: formatPath(_labels...), Action: ActionReplaced, Strategy: strategy, BasePos: posOf(b), PatchPos: posOf(it.Value())})
+		default:
+			in.record(ExplainEntry{Path: formatPath(_labels...), Action: ActionMerged, Strategy: strategy, BasePos: posOf(b), PatchPos: posOf(it.Value())})
+		}
+	}
+	if it, err = base.Fields(); err != nil {
+		return
+	}
+	for it.Next() {
+		sel := it.Selector()
+		_labels := append(append([]string{}, labels...), sel.Unquoted())
+		if in.handled[formatPath(_labels...)] || patch.LookupPath(cue.MakePath(sel)).Exists() {
+			continue
+		}
+		in.record(ExplainEntry{Path: formatPath(_labels...), Action: ActionRetained, Strategy: strategy, BasePos: posOf(it.Value())})
+	}
+}
+
+// explainJSONPatch record the operations of the json patch
+func (in *explainer) explainJSONPatch(operations cue.Value) {
+	if in == nil {
+		return
+	}
+	var ops []struct {
+		Op   string `json:"op"`
+		Path string `json:"path"`
+	}
+	if err := operations.Decode(&ops); err != nil {
+		return
+	}
+	actions := map[string]ExplainAction{"add": ActionAdded, "copy": ActionAdded, "remove": ActionRemoved, "replace": ActionReplaced, "move": ActionReplaced}
+	for i, op := range ops {
+		action, ok := actions[op.Op]
+		if !ok {
+			continue
+		}
+		in.record(ExplainEntry{Path: op.Path, Action: action, Strategy: StrategyJSONPatch, PatchPos: posOf(operations.LookupPath(cue.MakePath(cue.Index(i))))})
+	}
+}
+
+// explainError record the conflicts in the error of unify. The paths of
+// merged list items are rewritten with their patchKey, and the positions are
+// looked up from base and patch.
+func (in *explainer) explainError(err error) {
+	if in == nil || err == nil {
+		return
+	}
+	for _, e := range cueerrors.Errors(err) {
+		format, args := e.Msg()
+		conflict := Conflict{Message: fmt.Sprintf(format, args...)}
+		var display, patchLabels []string
+		for i, label := range e.Path() {
+			if item, ok := in.listItems[formatPath(e.Path()[:i+1]...)]; ok {
+				display = append(display, item.label)
+				patchLabels = append(patchLabels, strconv.Itoa(item.patchIdx))
+				continue
+			}
+			display = append(display, label)
+			patchLabels = append(patchLabels, label)
+		}
+		conflict.Path = formatPath(display...)
+		for _, pos := range cueerrors.Positions(e) {
+			if pos.IsValid() {
+				conflict.Positions = append(conflict.Positions, pos.String())
+			}
+		}
+		for _, v := range []cue.Value{in.base.LookupPath(toCuePath(e.Path()...)), in.patch.LookupPath(toCuePath(patchLabels...))} {
+			if pos := posOf(v); pos != "" {
+				conflict.Positions = append(conflict.Positions, pos)
+			}
+		}
+		in.explanation.Conflicts = append(in.explanation.Conflicts, conflict)
+	}
+}
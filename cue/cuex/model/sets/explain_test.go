@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sets
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrategyUnifyExplanation(t *testing.T) {
+	ctx := cuecontext.New()
+	base := ctx.CompileString(`
+replicas: 1
+labels: app: "web"
+// +patchKey=name
+containers: [{name: "main", image: "nginx"}, {name: "sidecar", image: "envoy"}]
+// +patchStrategy=replace
+args: ["a"]
+resources: {limits: memory: "1Gi", requests: cpu: "1"}
+`, cue.Filename("base.cue"))
+	patch := ctx.CompileString(`
+labels: env: "prod"
+// +patchKey=name
+containers: [{name: "main", image: "nginx:1.25"}, {name: "log", image: "fluentd"}]
+// +patchStrategy=replace
+args: ["b"]
+// +patchStrategy=retainKeys
+resources: {limits: cpu: "2"}
+`, cue.Filename("patch.cue"))
+	exp := &Explanation{}
+	_, err := StrategyUnify(base, patch, UnifyWithExplanation{exp})
+	require.Error(t, err)
+	entries := map[string]ExplainEntry{}
+	for _, entry := range exp.Entries {
+		entries[entry.Path] = entry
+	}
+	require.Equal(t, ExplainEntry{Path: "labels.env", Action: ActionAdded, Strategy: "unify", PatchPos: "patch.cue:2:9"}, entries["labels.env"])
+	require.Equal(t, ActionRetained, entries["labels.app"].Action)
+	require.Equal(t, ActionRetained, entries["replicas"].Action)
+	require.Equal(t, ExplainEntry{
+		Path: "containers[name=main]", Action: ActionMerged, Strategy: "patchKey=name",
+		BasePos: "base.cue:5:14", PatchPos: "patch.cue:4:14",
+	}, entries["containers[name=main]"])
+	require.Equal(t, ActionRetained, entries["containers[name=sidecar]"].Action)
+	require.Equal(t, ActionAdded, entries["containers[name=log]"].Action)
+	require.Equal(t, ExplainEntry{
+		Path: "args", Action: ActionReplaced, Strategy: "replace",
+		BasePos: "base.cue:7:1", PatchPos: "patch.cue:6:1",
+	}, entries["args"])
+	require.Equal(t, ActionReplaced, entries["resources"].Action)
+	require.Equal(t, StrategyRetainKeys, entries["resources"].Strategy)
+	_, found := entries["containers"]
+	require.False(t, found)
+
+	require.Equal(t, 1, len(exp.Conflicts))
+	require.Equal(t, "containers[name=main].image", exp.Conflicts[0].Path)
+	require.Contains(t, exp.Conflicts[0].Message, "conflicting values")
+	require.Equal(t, []string{"base.cue:5:29", "patch.cue:4:29"}, exp.Conflicts[0].Positions)
+
+	exp = &Explanation{}
+	_, err = StrategyUnify(ctx.CompileString(`a: 1, b: {c: 2, d: 3}`), ctx.CompileString(`b: {c: null, e: 4}, a: 2`), UnifyByJSONMergePatch{}, UnifyWithExplanation{exp})
+	require.NoError(t, err)
+	require.Equal(t, []ExplainAction{ActionRemoved, ActionAdded, ActionRetained, ActionReplaced}, actionsOf(exp))
+	require.Empty(t, exp.Conflicts)
+
+	exp = &Explanation{}
+	_, err = StrategyUnify(ctx.CompileString(`a: 1, b: 2`), ctx.CompileString(`operations: [{op: "remove", path: "/a"}, {op: "add", path: "/c", value: 3}, {op: "test", path: "/b", value: 2}]`), UnifyByJSONPatch{}, UnifyWithExplanation{exp})
+	require.NoError(t, err)
+	require.Equal(t, []ExplainAction{ActionRemoved, ActionAdded}, actionsOf(exp))
+	require.Equal(t, "/c", exp.Entries[1].Path)
+}
+
+func actionsOf(exp *Explanation) []ExplainAction {
+	var actions []ExplainAction
+	for _, entry := range exp.Entries {
+		actions = append(actions, entry.Action)
+	}
+	return actions
+}
@@ -50,7 +50,10 @@ var (
 
 // UnifyParams params for unify
 type UnifyParams struct {
-	PatchStrategy string
+	PatchStrategy     string
+	Explanation       *Explanation
+	PatchMeta         PatchMetaLookup
+	PatchMetaResolver PatchMetaResolver
 }
 
 // UnifyOption defines the option for unify
@@ -75,7 +78,7 @@ func (op UnifyByJSONMergePatch) ApplyToOption(params *UnifyParams) {
 }
 
 func newUnifyParams(options ...UnifyOption) *UnifyParams {
-	params := &UnifyParams{}
+	params := &UnifyParams{PatchMetaResolver: DefaultPatchMetaRegistry}
 	for _, op := range options {
 		op.ApplyToOption(params)
 	}
@@ -94,8 +97,17 @@ func CreateUnifyOptionsForPatcher(patcher cue.Value) (options []UnifyOption) {
 
 type interceptor func(baseNode ast.Node, patchNode ast.Node) error
 
-func listMergeProcess(field *ast.Field, key string, baseList, patchList *ast.ListLit) {
+// listItemRecorder record the action on list items merged by patchKey, the
+// index is -1 if the item does not exist in base or patch
+type listItemRecorder func(action ExplainAction, key, keyValue string, baseIdx, patchIdx int)
+
+func listMergeProcess(field *ast.Field, key string, baseList, patchList *ast.ListLit, recorder listItemRecorder) {
 	kmaps := map[string]ast.Expr{}
+	type patchItem struct {
+		key, keyValue string
+		idx           int
+	}
+	patchItems := map[string]patchItem{}
 	nElts := []ast.Expr{}
 	keys := strings.Split(key, ",")
 	for _, key := range keys {
@@ -114,6 +126,7 @@ func listMergeProcess(field *ast.Field, key string, baseList, patchList *ast.Lis
 				return
 			}
 			kmaps[fmt.Sprintf(key, blit.Value)] = patchList.Elts[i]
+			patchItems[fmt.Sprintf(key, blit.Value)] = patchItem{key: key, keyValue: blit.Value, idx: i}
 		}
 		if !foundPatch {
 			if len(patchList.Elts) == 0 {
@@ -140,20 +153,26 @@ func listMergeProcess(field *ast.Field, key string, baseList, patchList *ast.Lis
 
 			k := fmt.Sprintf(key, blit.Value)
 			if v, ok := kmaps[k]; ok {
+				action := ActionMerged
 				if hasStrategyRetainKeys {
 					baseList.Elts[i] = ast.NewStruct()
+					action = ActionReplaced
 				}
+				recorder(action, key, blit.Value, i, patchItems[k].idx)
 				nElts = append(nElts, v)
 				delete(kmaps, k)
 			} else {
+				recorder(ActionRetained, key, blit.Value, i, -1)
 				nElts = append(nElts, ast.NewStruct())
 			}
 
 		}
 	}
 	for _, elt := range patchList.Elts {
-		for _, v := range kmaps {
+		for k, v := range kmaps {
 			if elt == v {
+				item := patchItems[k]
+				recorder(ActionAdded, item.key, item.keyValue, -1, item.idx)
 				nElts = append(nElts, v)
 				break
 			}
@@ -164,7 +183,7 @@ func listMergeProcess(field *ast.Field, key string, baseList, patchList *ast.Lis
 	patchList.Elts = nElts
 }
 
-func strategyPatchHandle() interceptor {
+func strategyPatchHandle(exp *explainer, meta PatchMetaLookup) interceptor {
 	return func(baseNode ast.Node, patchNode ast.Node) error {
 		walker := newWalker(func(node ast.Node, ctx walkCtx) {
 			field, ok := node.(*ast.Field)
@@ -176,19 +195,12 @@ func strategyPatchHandle() interceptor {
 
 			switch val := value.(type) {
 			case *ast.ListLit:
-				key := ctx.Tags()[TagPatchKey]
-				patchStrategy := ""
-				tags := findCommentTag(field.Comments())
-				for tk, tv := range tags {
-					if tk == TagPatchKey {
-						key = tv
-					}
-					if tk == TagPatchStrategy {
-						patchStrategy = tv
-					}
+				paths := append(ctx.Pos(), LabelStr(field.Label))
+				key, patchStrategy := patchMetaOf(meta, findCommentTag(field.Comments()), paths...)
+				if key == "" {
+					key = ctx.Tags()[TagPatchKey]
 				}
 
-				paths := append(ctx.Pos(), LabelStr(field.Label))
 				baseSubNode, err := lookUp(baseNode, paths...)
 				if err != nil {
 					if errors.Is(err, notFoundErr) {
@@ -202,8 +214,11 @@ func strategyPatchHandle() interceptor {
 				}
 				if patchStrategy == StrategyReplace {
 					baselist.Elts = val.Elts
+					exp.recordField(ActionReplaced, StrategyReplace, paths...)
 				} else if key != "" {
-					listMergeProcess(field, key, baselist, val)
+					listMergeProcess(field, key, baselist, val, func(action ExplainAction, key, keyValue string, baseIdx, patchIdx int) {
+						exp.recordListItem(action, key, keyValue, baseIdx, patchIdx, paths...)
+					})
 				}
 
 			default:
@@ -211,6 +226,7 @@ func strategyPatchHandle() interceptor {
 					return
 				}
 
+				exp.recordField(ActionReplaced, StrategyRetainKeys, append(ctx.Pos(), LabelStr(field.Label))...)
 				srcNode, _ := lookUp(baseNode, ctx.Pos()...)
 				if srcNode != nil {
 					switch v := srcNode.(type) {
@@ -259,9 +275,11 @@ func IsJSONPatch(patcher cue.Value) bool {
 	return tags[TagPatchStrategy] == StrategyJSONPatch
 }
 
-// StrategyUnify unify the objects by the strategy
+// StrategyUnify unify the objects by the strategy. If UnifyWithExplanation is
+// given, the actions taken on each field and the conflicts are reported.
 func StrategyUnify(base, patch cue.Value, options ...UnifyOption) (ret cue.Value, err error) {
 	params := newUnifyParams(options...)
+	exp := newExplainer(base, patch, params.Explanation)
 	var patchOpts []interceptor
 	if params.PatchStrategy == StrategyJSONMergePatch || params.PatchStrategy == StrategyJSONPatch {
 		_, err := OpenBaiscLit(base)
@@ -269,9 +287,24 @@ func StrategyUnify(base, patch cue.Value, options ...UnifyOption) (ret cue.Value
 			return base, err
 		}
 	} else {
-		patchOpts = []interceptor{strategyPatchHandle()}
+		meta := params.PatchMeta
+		if meta == nil {
+			meta = resolvePatchMeta(params.PatchMetaResolver, base)
+		}
+		directives := newDirectiveHandler(exp, meta)
+		patchOpts = []interceptor{directives.preprocess(), strategyPatchHandle(exp, meta), directives.applyElementOrders()}
+	}
+	ret, err = strategyUnify(base, patch, params, patchOpts...)
+	switch params.PatchStrategy {
+	case StrategyJSONPatch:
+		exp.explainJSONPatch(patch.LookupPath(cue.ParsePath("operations")))
+	case StrategyJSONMergePatch:
+		exp.explainValues(StrategyJSONMergePatch, base, patch)
+	default:
+		exp.explainValues(strategyCUEUnify, base, patch)
 	}
-	return strategyUnify(base, patch, params, patchOpts...)
+	exp.explainError(err)
+	return ret, err
 }
 
 // nolint:staticcheck
@@ -0,0 +1,302 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sets
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"cuelang.org/go/cue"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+const (
+	// StrategyMerge notes on the strategic merge patch will merge the list items by patchKey
+	StrategyMerge = "merge"
+)
+
+// PatchMeta the strategic merge metadata of a field
+type PatchMeta struct {
+	PatchKey   string
+	Strategies []string
+}
+
+// PatchMetaLookup lookup the PatchMeta of the field at the given path, the
+// path contains the field labels and the list indices
+type PatchMetaLookup interface {
+	LookupPatchMeta(paths ...string) (PatchMeta, bool)
+}
+
+// PatchMetaResolver resolve the PatchMetaLookup of the given kind, nil is
+// returned if the kind is unknown
+type PatchMetaResolver interface {
+	ResolvePatchMeta(gvk schema.GroupVersionKind) PatchMetaLookup
+}
+
+// UnifyWithPatchMeta use the given PatchMetaLookup to find the patchKey and
+// patchStrategy of the fields which have no comment tags
+type UnifyWithPatchMeta struct {
+	PatchMetaLookup
+}
+
+// ApplyToOption apply to option
+func (op UnifyWithPatchMeta) ApplyToOption(params *UnifyParams) {
+	params.PatchMeta = op.PatchMetaLookup
+}
+
+// UnifyWithPatchMetaResolver resolve the PatchMetaLookup by the apiVersion
+// and kind of the base value. DefaultPatchMetaRegistry is used by default, set
+// nil to only use the comment tags.
+type UnifyWithPatchMetaResolver struct {
+	PatchMetaResolver
+}
+
+// ApplyToOption apply to option
+func (op UnifyWithPatchMetaResolver) ApplyToOption(params *UnifyParams) {
+	params.PatchMetaResolver = op.PatchMetaResolver
+}
+
+// PatchMetaRegistry resolve the PatchMeta from the Go types registered in
+// the scheme and the OpenAPI schemas of the added CRDs
+type PatchMetaRegistry struct {
+	scheme *runtime.Scheme
+	mu     sync.RWMutex
+	crds   map[schema.GroupVersionKind]PatchMetaLookup
+}
+
+// DefaultPatchMetaRegistry the default PatchMetaRegistry for the kubernetes
+// built-in kinds
+var DefaultPatchMetaRegistry = NewPatchMetaRegistry(clientgoscheme.Scheme)
+
+// NewPatchMetaRegistry create a PatchMetaRegistry with the given scheme
+func NewPatchMetaRegistry(scheme *runtime.Scheme) *PatchMetaRegistry {
+	return &PatchMetaRegistry{scheme: scheme, crds: map[schema.GroupVersionKind]PatchMetaLookup{}}
+}
+
+// AddCRD add the OpenAPI schemas of all the versions of the CRD
+func (in *PatchMetaRegistry) AddCRD(crd *apiextensionsv1.CustomResourceDefinition) error {
+	lookups := map[schema.GroupVersionKind]PatchMetaLookup{}
+	for _, version := range crd.Spec.Versions {
+		if version.Schema == nil || version.Schema.OpenAPIV3Schema == nil {
+			continue
+		}
+		lookup, err := NewPatchMetaFromSchemaProps(version.Schema.OpenAPIV3Schema)
+		if err != nil {
+			return err
+		}
+		lookups[schema.GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind}] = lookup
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	for gvk, lookup := range lookups {
+		in.crds[gvk] = lookup
+	}
+	return nil
+}
+
+// RemoveCRD remove the OpenAPI schemas of the CRD
+func (in *PatchMetaRegistry) RemoveCRD(crd *apiextensionsv1.CustomResourceDefinition) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	for _, version := range crd.Spec.Versions {
+		delete(in.crds, schema.GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind})
+	}
+}
+
+// ResolvePatchMeta implements PatchMetaResolver
+func (in *PatchMetaRegistry) ResolvePatchMeta(gvk schema.GroupVersionKind) PatchMetaLookup {
+	in.mu.RLock()
+	lookup, ok := in.crds[gvk]
+	in.mu.RUnlock()
+	if ok {
+		return lookup
+	}
+	if in.scheme == nil {
+		return nil
+	}
+	obj, err := in.scheme.New(gvk)
+	if err != nil {
+		return nil
+	}
+	lookup, err = NewPatchMetaFromStruct(obj)
+	if err != nil {
+		return nil
+	}
+	return lookup
+}
+
+type structPatchMeta struct {
+	meta strategicpatch.LookupPatchMeta
+}
+
+// NewPatchMetaFromStruct create the PatchMetaLookup from the struct tags of
+// the Go type, such as `patchStrategy:"merge" patchMergeKey:"name"`
+func NewPatchMetaFromStruct(obj interface{}) (PatchMetaLookup, error) {
+	meta, err := strategicpatch.NewPatchMetaFromStruct(obj)
+	if err != nil {
+		return nil, err
+	}
+	return &structPatchMeta{meta: meta}, nil
+}
+
+// LookupPatchMeta implements PatchMetaLookup
+func (in *structPatchMeta) LookupPatchMeta(paths ...string) (PatchMeta, bool) {
+	var meta strategicpatch.LookupPatchMeta = in.meta
+	var pm strategicpatch.PatchMeta
+	var err error
+	for i, p := range paths {
+		if isIndex(p) {
+			continue
+		}
+		if i+1 < len(paths) && isIndex(paths[i+1]) {
+			meta, pm, err = meta.LookupPatchMetadataForSlice(p)
+		} else {
+			meta, pm, err = meta.LookupPatchMetadataForStruct(p)
+		}
+		if err != nil || meta == nil {
+			return PatchMeta{}, false
+		}
+	}
+	return PatchMeta{PatchKey: pm.GetPatchMergeKey(), Strategies: pm.GetPatchStrategies()}, true
+}
+
+type schemaPatchMeta struct {
+	schema map[string]interface{}
+}
+
+// NewPatchMetaFromOpenAPI create the PatchMetaLookup from the OpenAPI v3
+// schema, the x-kubernetes-patch-merge-key, x-kubernetes-patch-strategy,
+// x-kubernetes-list-type and x-kubernetes-list-map-keys extensions are used
+func NewPatchMetaFromOpenAPI(schema map[string]interface{}) PatchMetaLookup {
+	return &schemaPatchMeta{schema: schema}
+}
+
+// NewPatchMetaFromSchemaProps create the PatchMetaLookup from the OpenAPI
+// schema of the CRD
+func NewPatchMetaFromSchemaProps(props *apiextensionsv1.JSONSchemaProps) (PatchMetaLookup, error) {
+	bs, err := json.Marshal(props)
+	if err != nil {
+		return nil, err
+	}
+	schema := map[string]interface{}{}
+	if err = json.Unmarshal(bs, &schema); err != nil {
+		return nil, err
+	}
+	return NewPatchMetaFromOpenAPI(schema), nil
+}
+
+// LookupPatchMeta implements PatchMetaLookup
+func (in *schemaPatchMeta) LookupPatchMeta(paths ...string) (PatchMeta, bool) {
+	props := in.schema
+	for _, p := range paths {
+		var next interface{}
+		if isIndex(p) {
+			next = props["items"]
+		} else if properties, ok := props["properties"].(map[string]interface{}); ok && properties[p] != nil {
+			next = properties[p]
+		} else {
+			next = props["additionalProperties"]
+		}
+		if props, _ = next.(map[string]interface{}); props == nil {
+			return PatchMeta{}, false
+		}
+	}
+	meta := PatchMeta{}
+	if key, ok := props["x-kubernetes-patch-merge-key"].(string); ok {
+		meta.PatchKey = key
+	}
+	if strategy, ok := props["x-kubernetes-patch-strategy"].(string); ok {
+		meta.Strategies = strings.Split(strategy, ",")
+	}
+	switch props["x-kubernetes-list-type"] {
+	case "map":
+		keys, _ := props["x-kubernetes-list-map-keys"].([]interface{})
+		if meta.PatchKey == "" && len(keys) == 1 {
+			meta.PatchKey, _ = keys[0].(string)
+		}
+		// the items identified by composite keys cannot be merged by a
+		// single patchKey, replace the whole list instead
+		if len(meta.Strategies) == 0 && meta.PatchKey == "" && len(keys) > 1 {
+			meta.Strategies = []string{StrategyReplace}
+		}
+		if len(meta.Strategies) == 0 {
+			meta.Strategies = []string{StrategyMerge}
+		}
+	case "atomic":
+		if len(meta.Strategies) == 0 {
+			meta.Strategies = []string{StrategyReplace}
+		}
+	}
+	return meta, true
+}
+
+// patchMetaOf find the patchKey and patchStrategy of the list field, the
+// comment tags take precedence over the given PatchMetaLookup
+func patchMetaOf(lookup PatchMetaLookup, tags map[string]string, paths ...string) (key string, strategy string) {
+	key, strategy = tags[TagPatchKey], tags[TagPatchStrategy]
+	if lookup == nil || (key != "" && strategy != "") {
+		return key, strategy
+	}
+	labels := make([]string, len(paths))
+	for i, p := range paths {
+		labels[i] = strings.Trim(p, `"`)
+	}
+	meta, ok := lookup.LookupPatchMeta(labels...)
+	if !ok {
+		return key, strategy
+	}
+	if key == "" && slices.Contains(meta.Strategies, StrategyMerge) {
+		key = meta.PatchKey
+	}
+	// the retainKeys strategy only takes effect with the $retainKeys directive
+	if strategy == "" && slices.Contains(meta.Strategies, StrategyReplace) {
+		strategy = StrategyReplace
+	}
+	return key, strategy
+}
+
+// resolvePatchMeta resolve the PatchMetaLookup by the apiVersion and kind of the value
+func resolvePatchMeta(resolver PatchMetaResolver, v cue.Value) PatchMetaLookup {
+	if resolver == nil {
+		return nil
+	}
+	apiVersion, err := v.LookupPath(cue.ParsePath("apiVersion")).String()
+	if err != nil {
+		return nil
+	}
+	kind, err := v.LookupPath(cue.ParsePath("kind")).String()
+	if err != nil {
+		return nil
+	}
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil
+	}
+	return resolver.ResolvePatchMeta(gv.WithKind(kind))
+}
+
+func isIndex(label string) bool {
+	_, err := strconv.Atoi(label)
+	return err == nil
+}
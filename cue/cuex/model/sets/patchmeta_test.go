@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sets
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestStrategyUnifyWithPatchMeta(t *testing.T) {
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "example.com",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Kind: "Example"},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{{
+				Name: "v1",
+				Schema: &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+					Type: "object",
+					Properties: map[string]apiextensionsv1.JSONSchemaProps{
+						"spec": {
+							Type: "object",
+							Properties: map[string]apiextensionsv1.JSONSchemaProps{
+								"ports": {
+									Type:         "array",
+									XListType:    ptr.To("map"),
+									XListMapKeys: []string{"port"},
+									Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{
+										Type: "object",
+									}},
+								},
+								"args": {
+									Type:      "array",
+									XListType: ptr.To("atomic"),
+								},
+								"containerPorts": {
+									Type:         "array",
+									XListType:    ptr.To("map"),
+									XListMapKeys: []string{"containerPort", "protocol"},
+									Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{
+										Type: "object",
+									}},
+								},
+							},
+						},
+					},
+				}},
+			}},
+		},
+	}
+	registry := NewPatchMetaRegistry(nil)
+	require.NoError(t, registry.AddCRD(crd))
+
+	testCases := map[string]struct {
+		base    string
+		patch   string
+		options []UnifyOption
+		result  string
+		err     string
+	}{
+		"built-in kind": {
+			base: `
+apiVersion: "apps/v1"
+kind: "Deployment"
+spec: template: spec: containers: [{name: "main", image: "nginx"}, {name: "sidecar", image: "envoy"}]`,
+			patch:   `spec: template: spec: containers: [{name: "sidecar", args: ["-v"]}]`,
+			options: []UnifyOption{UnifyWithPatchMetaResolver{DefaultPatchMetaRegistry}},
+			result:  `{"apiVersion": "apps/v1", "kind": "Deployment", "spec": {"template": {"spec": {"containers": [{"name": "main", "image": "nginx"}, {"name": "sidecar", "image": "envoy", "args": ["-v"]}]}}}}`,
+		},
+		"built-in kind by default": {
+			base: `
+apiVersion: "apps/v1"
+kind: "Deployment"
+spec: template: spec: containers: [{name: "main", image: "nginx"}, {name: "sidecar", image: "envoy"}]`,
+			patch:  `spec: template: spec: containers: [{name: "sidecar", args: ["-v"]}]`,
+			result: `{"apiVersion": "apps/v1", "kind": "Deployment", "spec": {"template": {"spec": {"containers": [{"name": "main", "image": "nginx"}, {"name": "sidecar", "image": "envoy", "args": ["-v"]}]}}}}`,
+		},
+		"built-in kind without resolver": {
+			base: `
+apiVersion: "apps/v1"
+kind: "Deployment"
+spec: template: spec: containers: [{name: "main", image: "nginx"}, {name: "sidecar", image: "envoy"}]`,
+			patch:   `spec: template: spec: containers: [{name: "sidecar", args: ["-v"]}]`,
+			options: []UnifyOption{UnifyWithPatchMetaResolver{nil}},
+			err:     `conflicting values "sidecar" and "main"`,
+		},
+		"built-in kind with delete directive": {
+			base: `
+apiVersion: "v1"
+kind: "Pod"
+spec: containers: [{name: "main", image: "nginx"}, {name: "sidecar", image: "envoy"}]`,
+			patch:   `spec: containers: [{name: "main", $patch: "delete"}]`,
+			options: []UnifyOption{UnifyWithPatchMetaResolver{DefaultPatchMetaRegistry}},
+			result:  `{"apiVersion": "v1", "kind": "Pod", "spec": {"containers": [{"name": "sidecar", "image": "envoy"}]}}`,
+		},
+		"comment tags take precedence": {
+			base: `
+apiVersion: "v1"
+kind: "Pod"
+spec: containers: [{name: "main", image: "nginx"}]`,
+			patch: `
+// +patchStrategy=replace
+spec: containers: [{name: "other", image: "busybox"}]`,
+			options: []UnifyOption{UnifyWithPatchMetaResolver{DefaultPatchMetaRegistry}},
+			result:  `{"apiVersion": "v1", "kind": "Pod", "spec": {"containers": [{"name": "other", "image": "busybox"}]}}`,
+		},
+		"crd list map keys": {
+			base: `
+apiVersion: "example.com/v1"
+kind: "Example"
+spec: {
+	ports: [{port: 80, name: "http"}, {port: 443, name: "https"}]
+	args: ["a", "b"]
+}`,
+			patch:   `spec: {ports: [{port: 443, protocol: "TCP"}], args: ["c"]}`,
+			options: []UnifyOption{UnifyWithPatchMetaResolver{registry}},
+			result:  `{"apiVersion": "example.com/v1", "kind": "Example", "spec": {"ports": [{"port": 80, "name": "http"}, {"port": 443, "name": "https", "protocol": "TCP"}], "args": ["c"]}}`,
+		},
+		"crd composite list map keys": {
+			base: `
+apiVersion: "example.com/v1"
+kind: "Example"
+spec: containerPorts: [{containerPort: 53, protocol: "TCP"}, {containerPort: 53, protocol: "UDP"}]`,
+			patch:   `spec: containerPorts: [{containerPort: 53, protocol: "UDP", name: "dns"}]`,
+			options: []UnifyOption{UnifyWithPatchMetaResolver{registry}},
+			result:  `{"apiVersion": "example.com/v1", "kind": "Example", "spec": {"containerPorts": [{"containerPort": 53, "protocol": "UDP", "name": "dns"}]}}`,
+		},
+		"explicit patch meta": {
+			base:  `spec: ports: [{port: 80, name: "http"}, {port: 443, name: "https"}]`,
+			patch: `spec: ports: [{port: 443, protocol: "TCP"}]`,
+			options: []UnifyOption{UnifyWithPatchMeta{NewPatchMetaFromOpenAPI(map[string]interface{}{
+				"properties": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"properties": map[string]interface{}{
+							"ports": map[string]interface{}{
+								"x-kubernetes-patch-merge-key": "port",
+								"x-kubernetes-patch-strategy":  "merge",
+							},
+						},
+					},
+				},
+			})}},
+			result: `{"spec": {"ports": [{"port": 80, "name": "http"}, {"port": 443, "name": "https", "protocol": "TCP"}]}}`,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+			ctx := cuecontext.New()
+			v, err := StrategyUnify(ctx.CompileString(tc.base), ctx.CompileString(tc.patch), tc.options...)
+			if tc.err != "" {
+				r.ErrorContains(err, tc.err)
+				return
+			}
+			r.NoError(err)
+			bs, err := v.MarshalJSON()
+			r.NoError(err)
+			r.JSONEq(tc.result, string(bs))
+		})
+	}
+}
+
+func TestPatchMetaLookup(t *testing.T) {
+	r := require.New(t)
+	lookup := DefaultPatchMetaRegistry.ResolvePatchMeta(appsv1.SchemeGroupVersion.WithKind("Deployment"))
+	r.NotNil(lookup)
+	meta, ok := lookup.LookupPatchMeta("spec", "template", "spec", "containers", "0", "env")
+	r.True(ok)
+	r.Equal("name", meta.PatchKey)
+	r.Contains(meta.Strategies, StrategyMerge)
+	_, ok = lookup.LookupPatchMeta("spec", "unknown")
+	r.False(ok)
+	r.Nil(DefaultPatchMetaRegistry.ResolvePatchMeta(appsv1.SchemeGroupVersion.WithKind("Unknown")))
+}
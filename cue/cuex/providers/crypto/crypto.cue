@@ -0,0 +1,234 @@
+package crypto
+
+#Hash: {
+	#do:       "hash"
+	#provider: "crypto"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The hash algorithm to use
+		algorithm: *"sha256" | "sha512" | "sha1"
+		// +usage=The data to hash
+		data: string
+		// +usage=The encoding of the digest
+		encoding: *"hex" | "base64"
+	}
+
+	// +usage=The digest of the data
+	$returns?: string
+}
+
+#HMAC: {
+	#do:       "hmac"
+	#provider: "crypto"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The hash algorithm to use
+		algorithm: *"sha256" | "sha512" | "sha1"
+		// +usage=The secret key for the hmac
+		key: string
+		// +usage=The data to sign
+		data: string
+		// +usage=The encoding of the signature
+		encoding: *"hex" | "base64"
+	}
+
+	// +usage=The signature of the data
+	$returns?: string
+}
+
+#UUID: {
+	#do:       "uuid"
+	#provider: "crypto"
+
+	// +usage=The params of this action
+	$params: {}
+
+	// +usage=The generated random uuid
+	$returns?: string
+}
+
+#Password: {
+	#do:       "password"
+	#provider: "crypto"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The length of the password
+		length: *16 | int & >0 & <=1024
+		// +usage=Whether to use lowercase letters
+		lower: *true | bool
+		// +usage=Whether to use uppercase letters
+		upper: *true | bool
+		// +usage=Whether to use digits
+		digits: *true | bool
+		// +usage=The symbols to use, empty for no symbols
+		symbols: *"" | string
+		// +usage=The minimum number of characters from each enabled charset
+		minPerCharset: *1 | int & >=0
+		// +usage=The characters never to use, such as ambiguous ones like "0O1lI"
+		exclude: *"" | string
+	}
+
+	// +usage=The generated password, it is marked as sensitive
+	$returns?: string
+}
+
+#KeyPair: {
+	// +usage=The certificate in pem format
+	cert: string
+	// +usage=The private key in pem format
+	key: string
+}
+
+#CA: {
+	#do:       "ca"
+	#provider: "crypto"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The common name of the ca
+		commonName: string
+		// +usage=The organization of the ca
+		organization: *[] | [...string]
+		// +usage=The validity of the ca in days
+		validityDays: *3650 | int & >0
+		// +usage=The algorithm of the private key
+		keyAlgorithm: *"RSA" | "ECDSA-P256" | "ECDSA-P384" | "Ed25519"
+		// +usage=The size of the rsa private key
+		keySize: *2048 | 3072 | 4096
+	}
+
+	// +usage=The generated ca, the private key is marked as sensitive
+	$returns?: #KeyPair
+}
+
+#Certificate: {
+	#do:       "certificate"
+	#provider: "crypto"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The common name of the certificate
+		commonName: string
+		// +usage=The organization of the certificate
+		organization: *[] | [...string]
+		// +usage=The dns names of the certificate
+		dnsNames: *[] | [...string]
+		// +usage=The ip addresses of the certificate
+		ipAddresses: *[] | [...string]
+		// +usage=The validity of the certificate in days
+		validityDays: *365 | int & >0
+		// +usage=The algorithm of the private key
+		keyAlgorithm: *"RSA" | "ECDSA-P256" | "ECDSA-P384" | "Ed25519"
+		// +usage=The size of the rsa private key
+		keySize: *2048 | 3072 | 4096
+		// +usage=The ca to sign the certificate, if not set, the certificate will be self-signed
+		ca?: #KeyPair
+	}
+
+	// +usage=The generated certificate, the private key is marked as sensitive
+	$returns?: #KeyPair
+}
+
+#Bcrypt: {
+	#do:       "bcrypt"
+	#provider: "crypto"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The password to hash
+		password: string
+		// +usage=The cost of the bcrypt hash
+		cost: *10 | int & >=4 & <=14
+	}
+
+	// +usage=The bcrypt hash of the password
+	$returns?: string
+}
+
+#BcryptCompare: {
+	#do:       "bcryptCompare"
+	#provider: "crypto"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The bcrypt hash
+		hash: string
+		// +usage=The password to compare with the hash
+		password: string
+	}
+
+	// +usage=Whether the password matches the hash
+	$returns?: bool
+}
+
+#Htpasswd: {
+	#do:       "htpasswd"
+	#provider: "crypto"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The name of the user
+		username: string
+		// +usage=The password of the user
+		password: string
+		// +usage=The cost of the bcrypt hash
+		cost: *10 | int & >=4 & <=14
+	}
+
+	// +usage=The htpasswd line for the user, in the format of username:bcrypt-hash
+	$returns?: string
+}
+
+#JWTSign: {
+	#do:       "jwtSign"
+	#provider: "crypto"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The claims of the token
+		claims: {...}
+		// +usage=The signing algorithm
+		algorithm: *"HS256" | "HS384" | "HS512" | "RS256" | "RS384" | "RS512" | "ES256" | "ES384" | "ES512" | "EdDSA"
+		// +usage=The signing key, the secret for HS algorithms or the private key in pem format for others
+		key: string
+		// +usage=The extra headers of the token, alg and typ cannot be overridden
+		header?: {
+			alg?: _|_
+			typ?: _|_
+			...
+		}
+	}
+
+	// +usage=The signed token, it is marked as sensitive
+	$returns?: string
+}
+
+#JWTVerify: {
+	#do:       "jwtVerify"
+	#provider: "crypto"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The token to verify
+		token: string
+		// +usage=The verifying key, the secret for HS algorithms or the public key or certificate in pem format for others
+		key: string
+		// +usage=The allowed signing algorithms, which must not be empty
+		algorithms: *["HS256"] | [string, ...string]
+	}
+
+	// +usage=The result of the verification
+	$returns?: {
+		// +usage=Whether the token is valid
+		valid: bool
+		// +usage=The claims of the token
+		claims: {...}
+		// +usage=The header of the token
+		header: {...}
+		// +usage=The reason if the token is invalid
+		error?: string
+	}
+}
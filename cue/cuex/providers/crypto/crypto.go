@@ -0,0 +1,533 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypto
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"hash"
+	"math/big"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	_ "embed"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/kubevela/pkg/cue/cuex/providers"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/util/cert"
+	"github.com/kubevela/pkg/util/runtime"
+)
+
+// StringReturns is the returns for functions returning string
+type StringReturns providers.Returns[string]
+
+// BoolReturns is the returns for functions returning bool
+type BoolReturns providers.Returns[bool]
+
+// HashVars is the vars for hash and hmac
+type HashVars struct {
+	Algorithm string `json:"algorithm"`
+	Key       string `json:"key,omitempty"`
+	Data      string `json:"data"`
+	Encoding  string `json:"encoding"`
+}
+
+// HashParams is the params for hash and hmac
+type HashParams providers.Params[HashVars]
+
+func newHash(algorithm string) (func() hash.Hash, error) {
+	switch algorithm {
+	case "sha1":
+		return sha1.New, nil
+	case "", "sha256":
+		return sha256.New, nil
+	case "sha512":
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm %s", algorithm)
+	}
+}
+
+func encode(bs []byte, encoding string) (string, error) {
+	switch encoding {
+	case "", "hex":
+		return hex.EncodeToString(bs), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(bs), nil
+	default:
+		return "", fmt.Errorf("unsupported encoding %s", encoding)
+	}
+}
+
+// Hash computes the digest of the data
+func Hash(_ context.Context, hashParams *HashParams) (*StringReturns, error) {
+	params := hashParams.Params
+	fn, err := newHash(params.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	h := fn()
+	_, _ = h.Write([]byte(params.Data))
+	s, err := encode(h.Sum(nil), params.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	return &StringReturns{Returns: s}, nil
+}
+
+// HMAC computes the hmac signature of the data with the key. The key is marked
+// as sensitive.
+func HMAC(ctx context.Context, hashParams *HashParams) (*StringReturns, error) {
+	params := hashParams.Params
+	cuexruntime.MarkSensitive(ctx, params.Key)
+	fn, err := newHash(params.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	h := hmac.New(fn, []byte(params.Key))
+	_, _ = h.Write([]byte(params.Data))
+	s, err := encode(h.Sum(nil), params.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	return &StringReturns{Returns: s}, nil
+}
+
+// UUIDParams is the params for uuid
+type UUIDParams providers.Params[struct{}]
+
+// UUID generates a random uuid
+func UUID(_ context.Context, _ *UUIDParams) (*StringReturns, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	return &StringReturns{Returns: id.String()}, nil
+}
+
+const (
+	lowerCharset = "abcdefghijklmnopqrstuvwxyz"
+	upperCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitCharset = "0123456789"
+)
+
+const (
+	// MaxPasswordLength the max length of the generated password
+	MaxPasswordLength = 1024
+	// MaxBcryptCost the max cost of the bcrypt hash, for both hashing and
+	// comparing
+	MaxBcryptCost = 14
+)
+
+// RSAKeySizes the supported sizes of the generated RSA private key
+var RSAKeySizes = []int{2048, 3072, 4096}
+
+// PasswordVars is the vars for generating password
+type PasswordVars struct {
+	Length        int    `json:"length"`
+	Lower         bool   `json:"lower"`
+	Upper         bool   `json:"upper"`
+	Digits        bool   `json:"digits"`
+	Symbols       string `json:"symbols"`
+	MinPerCharset int    `json:"minPerCharset"`
+	Exclude       string `json:"exclude"`
+}
+
+// PasswordParams is the params for generating password
+type PasswordParams providers.Params[PasswordVars]
+
+func randomInt(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
+
+// Password generates a random password that contains at least MinPerCharset
+// characters from each enabled charset. The password is marked as sensitive.
+func Password(ctx context.Context, passwordParams *PasswordParams) (*StringReturns, error) {
+	params := passwordParams.Params
+	var charsets []string
+	for _, charset := range []struct {
+		enabled bool
+		chars   string
+	}{
+		{params.Lower, lowerCharset},
+		{params.Upper, upperCharset},
+		{params.Digits, digitCharset},
+		{params.Symbols != "", params.Symbols},
+	} {
+		if !charset.enabled {
+			continue
+		}
+		chars := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(params.Exclude, r) {
+				return -1
+			}
+			return r
+		}, charset.chars)
+		if chars == "" {
+			return nil, fmt.Errorf("charset %q is empty after excluding %q", charset.chars, params.Exclude)
+		}
+		charsets = append(charsets, chars)
+	}
+	if len(charsets) == 0 {
+		return nil, fmt.Errorf("no charset enabled for password")
+	}
+	if params.Length > MaxPasswordLength {
+		return nil, fmt.Errorf("password length %d exceeds %d", params.Length, MaxPasswordLength)
+	}
+	if params.Length <= 0 || params.Length < params.MinPerCharset*len(charsets) {
+		return nil, fmt.Errorf("password length %d is not enough for %d charsets with at least %d characters each", params.Length, len(charsets), params.MinPerCharset)
+	}
+	pick := func(chars string) (rune, error) {
+		runes := []rune(chars)
+		i, err := randomInt(len(runes))
+		if err != nil {
+			return 0, err
+		}
+		return runes[i], nil
+	}
+	var password []rune
+	for _, chars := range charsets {
+		for i := 0; i < params.MinPerCharset; i++ {
+			r, err := pick(chars)
+			if err != nil {
+				return nil, err
+			}
+			password = append(password, r)
+		}
+	}
+	all := strings.Join(charsets, "")
+	for len(password) < params.Length {
+		r, err := pick(all)
+		if err != nil {
+			return nil, err
+		}
+		password = append(password, r)
+	}
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
+		if err != nil {
+			return nil, err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+	cuexruntime.MarkSensitive(ctx, string(password))
+	return &StringReturns{Returns: string(password)}, nil
+}
+
+// KeyPair is the certificate and private key in pem format
+type KeyPair struct {
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+}
+
+// CertificateVars is the vars for generating ca or certificate
+type CertificateVars struct {
+	CommonName   string   `json:"commonName"`
+	Organization []string `json:"organization"`
+	DNSNames     []string `json:"dnsNames"`
+	IPAddresses  []string `json:"ipAddresses"`
+	ValidityDays int      `json:"validityDays"`
+	KeyAlgorithm string   `json:"keyAlgorithm"`
+	KeySize      int      `json:"keySize"`
+	CA           *KeyPair `json:"ca,omitempty"`
+}
+
+// CertificateParams is the params for generating ca or certificate
+type CertificateParams providers.Params[CertificateVars]
+
+// CertificateReturns is the returns for generating ca or certificate
+type CertificateReturns providers.Returns[KeyPair]
+
+func newKeyPairReturns(ctx context.Context, certData []byte, keyData []byte) *CertificateReturns {
+	cuexruntime.MarkSensitive(ctx, string(keyData))
+	return &CertificateReturns{Returns: KeyPair{Cert: string(certData), Key: string(keyData)}}
+}
+
+func newTemplateBuilder(params CertificateVars) (*cert.TemplateBuilder, error) {
+	algorithm := cert.KeyAlgorithm(params.KeyAlgorithm)
+	if (algorithm == cert.KeyAlgorithmRSA || algorithm == "") && !slices.Contains(RSAKeySizes, params.KeySize) {
+		return nil, fmt.Errorf("rsa key size %d is not supported, should be one of %v", params.KeySize, RSAKeySizes)
+	}
+	var ips []net.IP
+	for _, s := range params.IPAddresses {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid ip address %s", s)
+		}
+		ips = append(ips, ip)
+	}
+	return cert.NewTemplateBuilder(params.CommonName).
+		WithOrganization(params.Organization...).
+		WithDNSNames(params.DNSNames...).
+		WithIPs(ips...).
+		WithValidity(time.Duration(params.ValidityDays) * 24 * time.Hour).
+		WithKeyAlgorithm(algorithm).
+		WithRSAKeySize(params.KeySize), nil
+}
+
+// CA generates a self-signed ca. The private key is marked as sensitive.
+func CA(ctx context.Context, certParams *CertificateParams) (*CertificateReturns, error) {
+	builder, err := newTemplateBuilder(certParams.Params)
+	if err != nil {
+		return nil, err
+	}
+	certData, keyData, err := builder.AsCA().SelfSigned()
+	if err != nil {
+		return nil, err
+	}
+	return newKeyPairReturns(ctx, certData, keyData), nil
+}
+
+// Certificate generates a certificate signed by the given ca, or a
+// self-signed one if no ca given. The private key is marked as sensitive.
+func Certificate(ctx context.Context, certParams *CertificateParams) (*CertificateReturns, error) {
+	params := certParams.Params
+	builder, err := newTemplateBuilder(params)
+	if err != nil {
+		return nil, err
+	}
+	var certData, keyData []byte
+	if params.CA != nil {
+		certData, keyData, err = builder.SignedBy([]byte(params.CA.Cert), []byte(params.CA.Key))
+	} else {
+		certData, keyData, err = builder.SelfSigned()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newKeyPairReturns(ctx, certData, keyData), nil
+}
+
+// BcryptVars is the vars for bcrypt and htpasswd
+type BcryptVars struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password"`
+	Hash     string `json:"hash,omitempty"`
+	Cost     int    `json:"cost,omitempty"`
+}
+
+// BcryptParams is the params for bcrypt and htpasswd
+type BcryptParams providers.Params[BcryptVars]
+
+// Bcrypt hashes the password with bcrypt. The password is marked as sensitive.
+func Bcrypt(ctx context.Context, bcryptParams *BcryptParams) (*StringReturns, error) {
+	params := bcryptParams.Params
+	cuexruntime.MarkSensitive(ctx, params.Password)
+	if params.Cost > MaxBcryptCost {
+		return nil, fmt.Errorf("bcrypt cost %d exceeds %d", params.Cost, MaxBcryptCost)
+	}
+	bs, err := bcrypt.GenerateFromPassword([]byte(params.Password), params.Cost)
+	if err != nil {
+		return nil, err
+	}
+	return &StringReturns{Returns: string(bs)}, nil
+}
+
+// BcryptCompare checks whether the password matches the bcrypt hash. The
+// password is marked as sensitive.
+func BcryptCompare(ctx context.Context, bcryptParams *BcryptParams) (*BoolReturns, error) {
+	params := bcryptParams.Params
+	cuexruntime.MarkSensitive(ctx, params.Password)
+	if cost, err := bcrypt.Cost([]byte(params.Hash)); err == nil && cost > MaxBcryptCost {
+		return nil, fmt.Errorf("bcrypt cost %d of the hash exceeds %d", cost, MaxBcryptCost)
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(params.Hash), []byte(params.Password))
+	return &BoolReturns{Returns: err == nil}, nil
+}
+
+// Htpasswd generates the htpasswd line for the user with bcrypt hash
+func Htpasswd(ctx context.Context, bcryptParams *BcryptParams) (*StringReturns, error) {
+	params := bcryptParams.Params
+	if params.Username == "" || strings.Contains(params.Username, ":") {
+		return nil, fmt.Errorf("invalid htpasswd username %q", params.Username)
+	}
+	ret, err := Bcrypt(ctx, bcryptParams)
+	if err != nil {
+		return nil, err
+	}
+	return &StringReturns{Returns: params.Username + ":" + ret.Returns}, nil
+}
+
+// JWTVars is the vars for signing and verifying jwt
+type JWTVars struct {
+	Token      string         `json:"token,omitempty"`
+	Claims     jwt.MapClaims  `json:"claims,omitempty"`
+	Header     map[string]any `json:"header,omitempty"`
+	Algorithm  string         `json:"algorithm,omitempty"`
+	Algorithms []string       `json:"algorithms,omitempty"`
+	Key        string         `json:"key"`
+}
+
+// JWTParams is the params for signing and verifying jwt
+type JWTParams providers.Params[JWTVars]
+
+// JWTVerifyResult is the result of verifying jwt
+type JWTVerifyResult struct {
+	Valid  bool           `json:"valid"`
+	Claims jwt.MapClaims  `json:"claims"`
+	Header map[string]any `json:"header"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// JWTVerifyReturns is the returns for verifying jwt
+type JWTVerifyReturns providers.Returns[JWTVerifyResult]
+
+func jwtSigningKey(method jwt.SigningMethod, key string) (any, error) {
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return []byte(key), nil
+	case *jwt.SigningMethodRSA:
+		return jwt.ParseRSAPrivateKeyFromPEM([]byte(key))
+	case *jwt.SigningMethodECDSA:
+		return jwt.ParseECPrivateKeyFromPEM([]byte(key))
+	case *jwt.SigningMethodEd25519:
+		return jwt.ParseEdPrivateKeyFromPEM([]byte(key))
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %s", method.Alg())
+	}
+}
+
+// isPEMPublicKey checks whether the key is a public key or certificate in pem
+// format, which is published and must never be used as the HMAC secret
+func isPEMPublicKey(key string) bool {
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return false
+	}
+	if _, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return true
+	}
+	if _, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return true
+	}
+	_, err := x509.ParseCertificate(block.Bytes)
+	return err == nil
+}
+
+func jwtVerifyingKey(method jwt.SigningMethod, key string) (any, error) {
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if isPEMPublicKey(key) {
+			return nil, fmt.Errorf("public key cannot be used for the signing algorithm %s", method.Alg())
+		}
+		return []byte(key), nil
+	case *jwt.SigningMethodRSA:
+		return jwt.ParseRSAPublicKeyFromPEM([]byte(key))
+	case *jwt.SigningMethodECDSA:
+		return jwt.ParseECPublicKeyFromPEM([]byte(key))
+	case *jwt.SigningMethodEd25519:
+		return jwt.ParseEdPublicKeyFromPEM([]byte(key))
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %s", method.Alg())
+	}
+}
+
+// JWTSign signs the claims into a jwt. The key and the token are marked as
+// sensitive.
+func JWTSign(ctx context.Context, jwtParams *JWTParams) (*StringReturns, error) {
+	params := jwtParams.Params
+	cuexruntime.MarkSensitive(ctx, params.Key)
+	method := jwt.GetSigningMethod(params.Algorithm)
+	if method == nil {
+		return nil, fmt.Errorf("unsupported signing algorithm %s", params.Algorithm)
+	}
+	key, err := jwtSigningKey(method, params.Key)
+	if err != nil {
+		return nil, err
+	}
+	token := jwt.NewWithClaims(method, params.Claims)
+	for k, v := range params.Header {
+		if k == "alg" || k == "typ" {
+			return nil, fmt.Errorf("header %s of the token cannot be overridden", k)
+		}
+		token.Header[k] = v
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		return nil, err
+	}
+	cuexruntime.MarkSensitive(ctx, s)
+	return &StringReturns{Returns: s}, nil
+}
+
+// JWTVerify verifies the jwt with the key and returns its claims. Invalid
+// tokens are reported in the returned result instead of failing the call. The
+// key is marked as sensitive unless it is a public key.
+func JWTVerify(ctx context.Context, jwtParams *JWTParams) (*JWTVerifyReturns, error) {
+	params := jwtParams.Params
+	if len(params.Algorithms) == 0 {
+		return nil, fmt.Errorf("algorithms are required for verifying jwt")
+	}
+	if !isPEMPublicKey(params.Key) {
+		cuexruntime.MarkSensitive(ctx, params.Key)
+	}
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(params.Algorithms))
+	token, err := parser.ParseWithClaims(params.Token, claims, func(token *jwt.Token) (interface{}, error) {
+		return jwtVerifyingKey(token.Method, params.Key)
+	})
+	result := JWTVerifyResult{Claims: claims, Header: map[string]any{}}
+	if token != nil {
+		result.Header = token.Header
+		result.Valid = token.Valid
+	}
+	if err != nil {
+		result.Valid = false
+		result.Error = err.Error()
+	}
+	return &JWTVerifyReturns{Returns: result}, nil
+}
+
+// ProviderName .
+const ProviderName = "crypto"
+
+//go:embed crypto.cue
+var template string
+
+// Package .
+var Package = runtime.Must(cuexruntime.NewInternalPackage(ProviderName, template, map[string]cuexruntime.ProviderFn{
+	"hash":          cuexruntime.GenericProviderFn[HashParams, StringReturns](Hash),
+	"hmac":          cuexruntime.GenericProviderFn[HashParams, StringReturns](HMAC),
+	"uuid":          cuexruntime.GenericProviderFn[UUIDParams, StringReturns](UUID),
+	"password":      cuexruntime.GenericProviderFn[PasswordParams, StringReturns](Password),
+	"ca":            cuexruntime.GenericProviderFn[CertificateParams, CertificateReturns](CA),
+	"certificate":   cuexruntime.GenericProviderFn[CertificateParams, CertificateReturns](Certificate),
+	"bcrypt":        cuexruntime.GenericProviderFn[BcryptParams, StringReturns](Bcrypt),
+	"bcryptCompare": cuexruntime.GenericProviderFn[BcryptParams, BoolReturns](BcryptCompare),
+	"htpasswd":      cuexruntime.GenericProviderFn[BcryptParams, StringReturns](Htpasswd),
+	"jwtSign":       cuexruntime.GenericProviderFn[JWTParams, StringReturns](JWTSign),
+	"jwtVerify":     cuexruntime.GenericProviderFn[JWTParams, JWTVerifyReturns](JWTVerify),
+}))
@@ -0,0 +1,233 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crypto_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/pkg/cue/cuex/providers/crypto"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/util/cert"
+)
+
+func TestHash(t *testing.T) {
+	ctx := context.Background()
+	ret, err := crypto.Hash(ctx, &crypto.HashParams{Params: crypto.HashVars{Data: "example"}})
+	require.NoError(t, err)
+	require.Equal(t, "50d858e0985ecc7f60418aaf0cc5ab587f42c2570a884095a9e8ccacd0f6545c", ret.Returns)
+	ret, err = crypto.Hash(ctx, &crypto.HashParams{Params: crypto.HashVars{Algorithm: "sha1", Data: "example", Encoding: "base64"}})
+	require.NoError(t, err)
+	require.Equal(t, "w0mcJylzCn+AfvuGdqkty2+KP48=", ret.Returns)
+	ret, err = crypto.HMAC(ctx, &crypto.HashParams{Params: crypto.HashVars{Algorithm: "sha256", Key: "key", Data: "example"}})
+	require.NoError(t, err)
+	require.Len(t, ret.Returns, 64)
+
+	_, err = crypto.Hash(ctx, &crypto.HashParams{Params: crypto.HashVars{Algorithm: "md4"}})
+	require.Error(t, err)
+	_, err = crypto.HMAC(ctx, &crypto.HashParams{Params: crypto.HashVars{Algorithm: "md4"}})
+	require.Error(t, err)
+	_, err = crypto.Hash(ctx, &crypto.HashParams{Params: crypto.HashVars{Encoding: "base32"}})
+	require.Error(t, err)
+}
+
+func TestRandom(t *testing.T) {
+	ctx := cuexruntime.WithSensitiveValues(context.Background())
+	id, err := crypto.UUID(ctx, &crypto.UUIDParams{})
+	require.NoError(t, err)
+	require.Len(t, id.Returns, 36)
+
+	ret, err := crypto.Password(ctx, &crypto.PasswordParams{Params: crypto.PasswordVars{
+		Length: 20, Lower: true, Upper: true, Digits: true, Symbols: "!@#", MinPerCharset: 2, Exclude: "0O1lI",
+	}})
+	require.NoError(t, err)
+	require.Len(t, ret.Returns, 20)
+	require.False(t, strings.ContainsAny(ret.Returns, "0O1lI"))
+	for _, charset := range []string{"abcdefghijkmnopqrstuvwxyz", "ABCDEFGHJKLMNPQRSTUVWXYZ", "23456789", "!@#"} {
+		cnt := 0
+		for _, r := range ret.Returns {
+			if strings.ContainsRune(charset, r) {
+				cnt++
+			}
+		}
+		require.GreaterOrEqual(t, cnt, 2)
+	}
+	require.Equal(t, "<redacted>", cuexruntime.Redact(ctx, ret.Returns))
+
+	for _, params := range []crypto.PasswordVars{
+		{Length: 8},
+		{Length: 2, Lower: true, Upper: true, Digits: true, MinPerCharset: 1},
+		{Length: 8, Digits: true, Exclude: "0123456789"},
+		{Length: crypto.MaxPasswordLength + 1, Lower: true},
+	} {
+		_, err = crypto.Password(ctx, &crypto.PasswordParams{Params: params})
+		require.Error(t, err)
+	}
+}
+
+func TestCertificate(t *testing.T) {
+	ctx := cuexruntime.WithSensitiveValues(context.Background())
+	ca, err := crypto.CA(ctx, &crypto.CertificateParams{Params: crypto.CertificateVars{CommonName: "ca", ValidityDays: 1, KeySize: 2048}})
+	require.NoError(t, err)
+	require.Equal(t, "<redacted>", cuexruntime.Redact(ctx, ca.Returns.Key))
+	leaf, err := crypto.Certificate(ctx, &crypto.CertificateParams{Params: crypto.CertificateVars{
+		CommonName: "leaf", DNSNames: []string{"example.com"}, IPAddresses: []string{"10.0.0.1"},
+		ValidityDays: 1, KeySize: 2048, CA: &ca.Returns,
+	}})
+	require.NoError(t, err)
+	caCert, err := cert.ParseCertificatePEM([]byte(ca.Returns.Cert))
+	require.NoError(t, err)
+	leafCert, err := cert.ParseCertificatePEM([]byte(leaf.Returns.Cert))
+	require.NoError(t, err)
+	require.NoError(t, leafCert.CheckSignatureFrom(caCert))
+	require.Equal(t, "10.0.0.1", leafCert.IPAddresses[0].String())
+
+	_, err = crypto.Certificate(ctx, &crypto.CertificateParams{Params: crypto.CertificateVars{CommonName: "self", ValidityDays: 1, KeySize: 2048}})
+	require.NoError(t, err)
+	ca, err = crypto.CA(ctx, &crypto.CertificateParams{Params: crypto.CertificateVars{CommonName: "ca", ValidityDays: 1, KeyAlgorithm: "ECDSA-P256"}})
+	require.NoError(t, err)
+	leaf, err = crypto.Certificate(ctx, &crypto.CertificateParams{Params: crypto.CertificateVars{
+		CommonName: "leaf", ValidityDays: 30, KeyAlgorithm: "Ed25519", CA: &ca.Returns,
+	}})
+	require.NoError(t, err)
+	caCert, err = cert.ParseCertificatePEM([]byte(ca.Returns.Cert))
+	require.NoError(t, err)
+	leafCert, err = cert.ParseCertificatePEM([]byte(leaf.Returns.Cert))
+	require.NoError(t, err)
+	require.NoError(t, leafCert.CheckSignatureFrom(caCert))
+	require.Equal(t, x509.Ed25519, leafCert.PublicKeyAlgorithm)
+	require.Equal(t, caCert.NotAfter, leafCert.NotAfter)
+	_, err = crypto.CA(ctx, &crypto.CertificateParams{Params: crypto.CertificateVars{KeyAlgorithm: "DSA"}})
+	require.Error(t, err)
+	_, err = crypto.Certificate(ctx, &crypto.CertificateParams{Params: crypto.CertificateVars{IPAddresses: []string{"bad"}}})
+	require.Error(t, err)
+	_, err = crypto.CA(ctx, &crypto.CertificateParams{Params: crypto.CertificateVars{KeySize: -1}})
+	require.Error(t, err)
+	_, err = crypto.CA(ctx, &crypto.CertificateParams{Params: crypto.CertificateVars{CommonName: "ca", ValidityDays: 1, KeySize: 1 << 20}})
+	require.ErrorContains(t, err, "rsa key size 1048576 is not supported")
+}
+
+func TestBcrypt(t *testing.T) {
+	ctx := context.Background()
+	hash, err := crypto.Bcrypt(ctx, &crypto.BcryptParams{Params: crypto.BcryptVars{Password: "pass", Cost: 4}})
+	require.NoError(t, err)
+	ok, err := crypto.BcryptCompare(ctx, &crypto.BcryptParams{Params: crypto.BcryptVars{Password: "pass", Hash: hash.Returns}})
+	require.NoError(t, err)
+	require.True(t, ok.Returns)
+	ok, err = crypto.BcryptCompare(ctx, &crypto.BcryptParams{Params: crypto.BcryptVars{Password: "bad", Hash: hash.Returns}})
+	require.NoError(t, err)
+	require.False(t, ok.Returns)
+	_, err = crypto.Bcrypt(ctx, &crypto.BcryptParams{Params: crypto.BcryptVars{Password: "pass", Cost: crypto.MaxBcryptCost + 1}})
+	require.Error(t, err)
+	_, err = crypto.BcryptCompare(ctx, &crypto.BcryptParams{Params: crypto.BcryptVars{Password: "pass", Hash: strings.Replace(hash.Returns, "$04$", "$31$", 1)}})
+	require.ErrorContains(t, err, "bcrypt cost 31 of the hash exceeds 14")
+
+	line, err := crypto.Htpasswd(ctx, &crypto.BcryptParams{Params: crypto.BcryptVars{Username: "admin", Password: "pass", Cost: 4}})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(line.Returns, "admin:$2a$04$"))
+	_, err = crypto.Htpasswd(ctx, &crypto.BcryptParams{Params: crypto.BcryptVars{Username: "a:b", Password: "pass"}})
+	require.Error(t, err)
+	_, err = crypto.Htpasswd(ctx, &crypto.BcryptParams{Params: crypto.BcryptVars{Username: "admin", Password: strings.Repeat("x", 100)}})
+	require.Error(t, err)
+}
+
+func TestJWT(t *testing.T) {
+	ctx := context.Background()
+	token, err := crypto.JWTSign(ctx, &crypto.JWTParams{Params: crypto.JWTVars{
+		Claims: map[string]any{"sub": "admin"}, Algorithm: "HS256", Key: "secret", Header: map[string]any{"kid": "k1"},
+	}})
+	require.NoError(t, err)
+	ret, err := crypto.JWTVerify(ctx, &crypto.JWTParams{Params: crypto.JWTVars{Token: token.Returns, Key: "secret", Algorithms: []string{"HS256"}}})
+	require.NoError(t, err)
+	require.True(t, ret.Returns.Valid)
+	require.Equal(t, "admin", ret.Returns.Claims["sub"])
+	require.Equal(t, "k1", ret.Returns.Header["kid"])
+
+	ret, err = crypto.JWTVerify(ctx, &crypto.JWTParams{Params: crypto.JWTVars{Token: token.Returns, Key: "bad", Algorithms: []string{"HS256"}}})
+	require.NoError(t, err)
+	require.False(t, ret.Returns.Valid)
+	require.NotEmpty(t, ret.Returns.Error)
+	ret, err = crypto.JWTVerify(ctx, &crypto.JWTParams{Params: crypto.JWTVars{Token: token.Returns, Key: "secret", Algorithms: []string{"RS256"}}})
+	require.NoError(t, err)
+	require.False(t, ret.Returns.Valid)
+	for _, header := range []string{"alg", "typ"} {
+		_, err = crypto.JWTSign(ctx, &crypto.JWTParams{Params: crypto.JWTVars{
+			Claims: map[string]any{"sub": "admin"}, Algorithm: "HS256", Key: "secret", Header: map[string]any{header: "none"},
+		}})
+		require.ErrorContains(t, err, "cannot be overridden")
+	}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privBytes, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	pubBytes, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	token, err = crypto.JWTSign(ctx, &crypto.JWTParams{Params: crypto.JWTVars{
+		Claims: map[string]any{"sub": "admin"}, Algorithm: "EdDSA", Key: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privBytes})),
+	}})
+	require.NoError(t, err)
+	ret, err = crypto.JWTVerify(ctx, &crypto.JWTParams{Params: crypto.JWTVars{
+		Token: token.Returns, Key: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes})), Algorithms: []string{"EdDSA"},
+	}})
+	require.NoError(t, err)
+	require.True(t, ret.Returns.Valid)
+
+	_, err = crypto.JWTSign(ctx, &crypto.JWTParams{Params: crypto.JWTVars{Algorithm: "none"}})
+	require.Error(t, err)
+	_, err = crypto.JWTSign(ctx, &crypto.JWTParams{Params: crypto.JWTVars{Algorithm: "RS256", Key: "bad"}})
+	require.Error(t, err)
+	_, err = crypto.JWTVerify(ctx, &crypto.JWTParams{Params: crypto.JWTVars{Token: token.Returns, Key: "secret"}})
+	require.ErrorContains(t, err, "algorithms are required")
+
+	// the public key must not be accepted as the HMAC secret
+	pubPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}))
+	token, err = crypto.JWTSign(ctx, &crypto.JWTParams{Params: crypto.JWTVars{
+		Claims: map[string]any{"sub": "admin"}, Algorithm: "HS256", Key: pubPEM,
+	}})
+	require.NoError(t, err)
+	ret, err = crypto.JWTVerify(ctx, &crypto.JWTParams{Params: crypto.JWTVars{
+		Token: token.Returns, Key: pubPEM, Algorithms: []string{"EdDSA", "HS256"},
+	}})
+	require.NoError(t, err)
+	require.False(t, ret.Returns.Valid)
+	require.Contains(t, ret.Returns.Error, "public key cannot be used")
+}
+
+func TestMarkSensitive(t *testing.T) {
+	ctx := cuexruntime.WithSensitiveValues(context.Background())
+	_, err := crypto.HMAC(ctx, &crypto.HashParams{Params: crypto.HashVars{Algorithm: "sha256", Key: "hmac-key", Data: "example"}})
+	require.NoError(t, err)
+	_, err = crypto.Bcrypt(ctx, &crypto.BcryptParams{Params: crypto.BcryptVars{Password: "bcrypt-password", Cost: 4}})
+	require.NoError(t, err)
+	_, err = crypto.BcryptCompare(ctx, &crypto.BcryptParams{Params: crypto.BcryptVars{Password: "compare-password"}})
+	require.NoError(t, err)
+	token, err := crypto.JWTSign(ctx, &crypto.JWTParams{Params: crypto.JWTVars{Algorithm: "HS256", Key: "jwt-sign-key"}})
+	require.NoError(t, err)
+	_, err = crypto.JWTVerify(ctx, &crypto.JWTParams{Params: crypto.JWTVars{Token: token.Returns, Key: "jwt-verify-key", Algorithms: []string{"HS256"}}})
+	require.NoError(t, err)
+	for _, v := range []string{"hmac-key", "bcrypt-password", "compare-password", "jwt-sign-key", "jwt-verify-key"} {
+		require.Equal(t, cuexruntime.RedactedPlaceholder, cuexruntime.Redact(ctx, v))
+	}
+}
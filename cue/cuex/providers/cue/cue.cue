@@ -8,8 +8,28 @@ package cue
 	$params: {
 		value: {...}
 		patch: {...}
+		// +usage=Whether to report how the fields are unified in $explanation, conflicts are reported instead of failing the action
+		explain: *false | bool
+		// +usage=Whether to find the patchKey of the lists without comment tags from the schemas of the kubernetes built-in kinds
+		patchMeta: *true | bool
 	}
 
 	// +usage=The result of this action
 	$returns: {...}
+
+	// +usage=The explanation of this action, only filled when explain is set
+	$explanation?: {
+		entries: [...{
+			path:      string
+			action:    "merged" | "replaced" | "retained" | "added" | "removed"
+			strategy:  string
+			basePos?:  string
+			patchPos?: string
+		}]
+		conflicts?: [...{
+			path:    string
+			message: string
+			positions?: [...string]
+		}]
+	}
 }
@@ -34,13 +34,33 @@ const ProviderName = "cue"
 //go:embed cue.cue
 var template string
 
-// StrategyUnify unifies values by using a strategic patching approach.
+// ExplanationKey the key to fill the explanation of StrategyUnify
+const ExplanationKey = "$explanation"
+
+// StrategyUnify unifies values by using a strategic patching approach. If
+// explain is set, the explanation is filled and conflicts are reported in it
+// instead of failing the call. Unless patchMeta is set to false, the patchKey
+// of the lists in the kubernetes built-in kinds are found from their schemas.
 func StrategyUnify(_ context.Context, in cue.Value) (cue.Value, error) {
 	params := in.LookupPath(cue.ParsePath(providers.ParamsKey))
 	base := params.LookupPath(cue.ParsePath("value"))
 	patcher := params.LookupPath(cue.ParsePath("patch"))
-	res, err := sets.StrategyUnify(base, patcher)
-	return in.FillPath(cue.ParsePath(providers.ReturnsKey), res), err
+	explain, _ := params.LookupPath(cue.ParsePath("explain")).Bool()
+	var options []sets.UnifyOption
+	if patchMeta, err := params.LookupPath(cue.ParsePath("patchMeta")).Bool(); err == nil && !patchMeta {
+		options = append(options, sets.UnifyWithPatchMetaResolver{PatchMetaResolver: nil})
+	}
+	if !explain {
+		res, err := sets.StrategyUnify(base, patcher, options...)
+		return in.FillPath(cue.ParsePath(providers.ReturnsKey), res), err
+	}
+	exp := &sets.Explanation{}
+	res, err := sets.StrategyUnify(base, patcher, append(options, sets.UnifyWithExplanation{Explanation: exp})...)
+	in = in.FillPath(cue.ParsePath(ExplanationKey), exp)
+	if err != nil {
+		return in, nil
+	}
+	return in.FillPath(cue.ParsePath(providers.ReturnsKey), res), nil
 }
 
 // Package .
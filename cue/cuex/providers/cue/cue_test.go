@@ -19,6 +19,7 @@ package cue_test
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"cuelang.org/go/cue"
@@ -122,3 +123,62 @@ func TestStrategyUnify(t *testing.T) {
 		})
 	}
 }
+
+func TestStrategyUnifyExplain(t *testing.T) {
+	ctx := context.Background()
+	value := cuecontext.New().CompileString(`{$params: {
+		value: {containers: [{name: "x1"}, {name: "x2"}, ...]}
+		patch: {
+			// +patchKey=name
+			containers: [{name: "x2", image: "nginx"}]
+		}
+		explain: true
+	}}`)
+	val, err := cueprovider.StrategyUnify(ctx, value)
+	require.NoError(t, err)
+	exp := &sets.Explanation{}
+	require.NoError(t, val.LookupPath(cue.ParsePath(cueprovider.ExplanationKey)).Decode(exp))
+	require.Equal(t, 2, len(exp.Entries))
+	require.Equal(t, "containers[name=x2]", exp.Entries[1].Path)
+	require.Equal(t, sets.ActionMerged, exp.Entries[1].Action)
+	require.True(t, val.LookupPath(cue.ParsePath(providers.ReturnsKey)).Exists())
+
+	value = cuecontext.New().CompileString(`{$params: {
+		value: {name: "x1"}
+		patch: {name: "x2"}
+		explain: true
+	}}`)
+	val, err = cueprovider.StrategyUnify(ctx, value)
+	require.NoError(t, err)
+	require.NoError(t, val.LookupPath(cue.ParsePath(cueprovider.ExplanationKey)).Decode(exp))
+	require.Equal(t, 1, len(exp.Conflicts))
+	require.Equal(t, "name", exp.Conflicts[0].Path)
+	require.False(t, val.LookupPath(cue.ParsePath(providers.ReturnsKey)).Exists())
+}
+
+func TestStrategyUnifyPatchMeta(t *testing.T) {
+	ctx := context.Background()
+	src := `{$params: {
+		value: {
+			apiVersion: "v1"
+			kind: "Pod"
+			spec: containers: [{name: "main", image: "nginx"}, {name: "sidecar", image: "envoy"}]
+		}
+		patch: spec: containers: [{name: "sidecar", args: ["-v"]}]
+		patchMeta: %t
+	}}`
+	val, err := cueprovider.StrategyUnify(ctx, cuecontext.New().CompileString(fmt.Sprintf(src, true)))
+	require.NoError(t, err)
+	arg, err := val.LookupPath(cue.ParsePath(providers.ReturnsKey + ".spec.containers[1].args[0]")).String()
+	require.NoError(t, err)
+	require.Equal(t, "-v", arg)
+
+	_, err = cueprovider.StrategyUnify(ctx, cuecontext.New().CompileString(fmt.Sprintf(src, false)))
+	require.Error(t, err)
+
+	val, err = cueprovider.StrategyUnify(ctx, cuecontext.New().CompileString(strings.Replace(src, "patchMeta: %t", "", 1)))
+	require.NoError(t, err)
+	arg, err = val.LookupPath(cue.ParsePath(providers.ReturnsKey + ".spec.containers[1].args[0]")).String()
+	require.NoError(t, err)
+	require.Equal(t, "-v", arg)
+}
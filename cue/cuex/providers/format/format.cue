@@ -0,0 +1,165 @@
+package format
+
+#ParseYAML: {
+	#do:       "parseYAML"
+	#provider: "format"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The yaml data to parse, multiple documents separated by --- are supported
+		data: string
+	}
+
+	// +usage=The parsed documents
+	$returns?: [..._]
+}
+
+#EmitYAML: {
+	#do:       "emitYAML"
+	#provider: "format"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The value to emit, must be a list if multiDocument is set
+		value: _
+		// +usage=Whether to emit each item of the value as a separated document
+		multiDocument: *false | bool
+	}
+
+	// +usage=The emitted yaml
+	$returns?: string
+}
+
+#ParseTOML: {
+	#do:       "parseTOML"
+	#provider: "format"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The toml data to parse
+		data: string
+	}
+
+	// +usage=The parsed value
+	$returns?: {...}
+}
+
+#EmitTOML: {
+	#do:       "emitTOML"
+	#provider: "format"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The value to emit
+		value: {...}
+	}
+
+	// +usage=The emitted toml
+	$returns?: string
+}
+
+#ParseINI: {
+	#do:       "parseINI"
+	#provider: "format"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The ini data to parse
+		data: string
+	}
+
+	// +usage=The parsed value, keys outside sections are placed at the top level and sections are nested
+	$returns?: {...}
+}
+
+#EmitINI: {
+	#do:       "emitINI"
+	#provider: "format"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The value to emit, nested structs are emitted as sections
+		value: {...}
+	}
+
+	// +usage=The emitted ini
+	$returns?: string
+}
+
+#ParseXML: {
+	#do:       "parseXML"
+	#provider: "format"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The xml data to parse
+		data: string
+	}
+
+	// +usage=The parsed value, attributes are prefixed with - and the text of elements with attributes or children is placed in #text
+	$returns?: {...}
+}
+
+#EmitXML: {
+	#do:       "emitXML"
+	#provider: "format"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The value to emit, it must have exactly one root element
+		value: {...}
+		// +usage=The indent to use
+		indent: *"  " | string
+	}
+
+	// +usage=The emitted xml
+	$returns?: string
+}
+
+#Lookup: {
+	#do:       "lookup"
+	#provider: "format"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The value to query
+		value: _
+		// +usage=The dotted path to lookup, such as spec.containers.0.name
+		path: string
+	}
+
+	// +usage=The value found at the path, null if not found
+	$returns?: _
+}
+
+#JSONPath: {
+	#do:       "jsonPath"
+	#provider: "format"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The value to query
+		value: _
+		// +usage=The JSONPath expression, such as {.items[*].metadata.name}
+		path: string
+	}
+
+	// +usage=All the matched results
+	$returns?: [..._]
+}
+
+#JMESPath: {
+	#do:       "jmesPath"
+	#provider: "format"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The value to query
+		value: _
+		// +usage=The JMESPath expression, such as items[?status=='ready'].name
+		path: string
+	}
+
+	// +usage=The result of the query
+	$returns?: _
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package format
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	_ "embed"
+
+	"github.com/jmespath/go-jmespath"
+	"github.com/pelletier/go-toml/v2"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubevela/pkg/cue/cuex/providers"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/util/jsonutil"
+	"github.com/kubevela/pkg/util/runtime"
+)
+
+// ParseVars is the vars for parsing data
+type ParseVars struct {
+	Data string `json:"data"`
+}
+
+// ParseParams is the params for parsing data
+type ParseParams providers.Params[ParseVars]
+
+// EmitVars is the vars for emitting value
+type EmitVars struct {
+	Value         any    `json:"value"`
+	MultiDocument bool   `json:"multiDocument,omitempty"`
+	Indent        string `json:"indent,omitempty"`
+}
+
+// EmitParams is the params for emitting value
+type EmitParams providers.Params[EmitVars]
+
+// QueryVars is the vars for querying value
+type QueryVars struct {
+	Value any    `json:"value"`
+	Path  string `json:"path"`
+}
+
+// QueryParams is the params for querying value
+type QueryParams providers.Params[QueryVars]
+
+// ValueReturns is the returns for parsed or queried value
+type ValueReturns providers.Returns[any]
+
+// StringReturns is the returns for emitted data
+type StringReturns providers.Returns[string]
+
+// ParseYAML parses all the documents in the yaml data
+func ParseYAML(_ context.Context, parseParams *ParseParams) (*ValueReturns, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(strings.NewReader(parseParams.Params.Data)))
+	docs := []any{}
+	for {
+		bs, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var doc any
+		if err = yaml.Unmarshal(bs, &doc); err != nil {
+			return nil, err
+		}
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+	return &ValueReturns{Returns: docs}, nil
+}
+
+// EmitYAML emits the value as yaml, list items are emitted as separated
+// documents if MultiDocument is set
+func EmitYAML(_ context.Context, emitParams *EmitParams) (*StringReturns, error) {
+	params := emitParams.Params
+	if !params.MultiDocument {
+		bs, err := yaml.Marshal(params.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &StringReturns{Returns: string(bs)}, nil
+	}
+	docs, ok := params.Value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("value must be a list to emit multiple documents")
+	}
+	var sb strings.Builder
+	for i, doc := range docs {
+		bs, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			sb.WriteString("---\n")
+		}
+		sb.Write(bs)
+	}
+	return &StringReturns{Returns: sb.String()}, nil
+}
+
+// ParseTOML parses the toml data
+func ParseTOML(_ context.Context, parseParams *ParseParams) (*ValueReturns, error) {
+	val := map[string]any{}
+	if err := toml.Unmarshal([]byte(parseParams.Params.Data), &val); err != nil {
+		return nil, err
+	}
+	return &ValueReturns{Returns: val}, nil
+}
+
+// EmitTOML emits the value as toml
+func EmitTOML(_ context.Context, emitParams *EmitParams) (*StringReturns, error) {
+	buf := &bytes.Buffer{}
+	if err := toml.NewEncoder(buf).Encode(emitParams.Params.Value); err != nil {
+		return nil, err
+	}
+	return &StringReturns{Returns: buf.String()}, nil
+}
+
+// ParseINI parses the ini data
+func ParseINI(_ context.Context, parseParams *ParseParams) (*ValueReturns, error) {
+	val, err := parseINI(parseParams.Params.Data)
+	if err != nil {
+		return nil, err
+	}
+	return &ValueReturns{Returns: val}, nil
+}
+
+// EmitINI emits the value as ini
+func EmitINI(_ context.Context, emitParams *EmitParams) (*StringReturns, error) {
+	s, err := emitINI(emitParams.Params.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &StringReturns{Returns: s}, nil
+}
+
+// ParseXML parses the xml data
+func ParseXML(_ context.Context, parseParams *ParseParams) (*ValueReturns, error) {
+	val, err := parseXML(parseParams.Params.Data)
+	if err != nil {
+		return nil, err
+	}
+	return &ValueReturns{Returns: val}, nil
+}
+
+// EmitXML emits the value as xml
+func EmitXML(_ context.Context, emitParams *EmitParams) (*StringReturns, error) {
+	s, err := emitXML(emitParams.Params.Value, emitParams.Params.Indent)
+	if err != nil {
+		return nil, err
+	}
+	return &StringReturns{Returns: s}, nil
+}
+
+// Lookup finds the value at the dotted path
+func Lookup(_ context.Context, queryParams *QueryParams) (*ValueReturns, error) {
+	val, err := jsonutil.LookupPath(queryParams.Params.Value, queryParams.Params.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &ValueReturns{Returns: val}, nil
+}
+
+// JSONPath finds all the results matched by the JSONPath expression
+func JSONPath(_ context.Context, queryParams *QueryParams) (*ValueReturns, error) {
+	val, err := jsonutil.JSONPath(queryParams.Params.Value, queryParams.Params.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &ValueReturns{Returns: val}, nil
+}
+
+// JMESPath evaluates the JMESPath expression over the value
+func JMESPath(_ context.Context, queryParams *QueryParams) (*ValueReturns, error) {
+	val, err := jmespath.Search(queryParams.Params.Path, queryParams.Params.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &ValueReturns{Returns: val}, nil
+}
+
+// ProviderName .
+const ProviderName = "format"
+
+//go:embed format.cue
+var template string
+
+// Package .
+var Package = runtime.Must(cuexruntime.NewInternalPackage(ProviderName, template, map[string]cuexruntime.ProviderFn{
+	"parseYAML": cuexruntime.GenericProviderFn[ParseParams, ValueReturns](ParseYAML),
+	"emitYAML":  cuexruntime.GenericProviderFn[EmitParams, StringReturns](EmitYAML),
+	"parseTOML": cuexruntime.GenericProviderFn[ParseParams, ValueReturns](ParseTOML),
+	"emitTOML":  cuexruntime.GenericProviderFn[EmitParams, StringReturns](EmitTOML),
+	"parseINI":  cuexruntime.GenericProviderFn[ParseParams, ValueReturns](ParseINI),
+	"emitINI":   cuexruntime.GenericProviderFn[EmitParams, StringReturns](EmitINI),
+	"parseXML":  cuexruntime.GenericProviderFn[ParseParams, ValueReturns](ParseXML),
+	"emitXML":   cuexruntime.GenericProviderFn[EmitParams, StringReturns](EmitXML),
+	"lookup":    cuexruntime.GenericProviderFn[QueryParams, ValueReturns](Lookup),
+	"jsonPath":  cuexruntime.GenericProviderFn[QueryParams, ValueReturns](JSONPath),
+	"jmesPath":  cuexruntime.GenericProviderFn[QueryParams, ValueReturns](JMESPath),
+}))
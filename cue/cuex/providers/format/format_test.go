@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package format_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/pkg/cue/cuex/providers/format"
+)
+
+func parse(t *testing.T, fn func(context.Context, *format.ParseParams) (*format.ValueReturns, error), data string) any {
+	params := &format.ParseParams{}
+	params.Params.Data = data
+	ret, err := fn(context.Background(), params)
+	require.NoError(t, err)
+	return ret.Returns
+}
+
+func emit(t *testing.T, fn func(context.Context, *format.EmitParams) (*format.StringReturns, error), vars format.EmitVars) string {
+	ret, err := fn(context.Background(), &format.EmitParams{Params: vars})
+	require.NoError(t, err)
+	return ret.Returns
+}
+
+func TestYAML(t *testing.T) {
+	docs := parse(t, format.ParseYAML, "a: 1\n---\n---\nb: [x, z]\n")
+	require.Equal(t, []any{map[string]any{"a": float64(1)}, map[string]any{"b": []any{"x", "z"}}}, docs)
+	require.Equal(t, "a: 1\n", emit(t, format.EmitYAML, format.EmitVars{Value: map[string]any{"a": 1}}))
+	require.Equal(t, "a: 1\n---\nb:\n- x\n- z\n", emit(t, format.EmitYAML, format.EmitVars{Value: docs, MultiDocument: true}))
+	_, err := format.EmitYAML(context.Background(), &format.EmitParams{Params: format.EmitVars{Value: "x", MultiDocument: true}})
+	require.Error(t, err)
+	_, err = format.ParseYAML(context.Background(), &format.ParseParams{Params: format.ParseVars{Data: "a: [\n"}})
+	require.Error(t, err)
+}
+
+func TestTOML(t *testing.T) {
+	val := parse(t, format.ParseTOML, "name = \"web\"\n[server]\nport = 80\n")
+	require.Equal(t, map[string]any{"name": "web", "server": map[string]any{"port": int64(80)}}, val)
+	out := emit(t, format.EmitTOML, format.EmitVars{Value: val})
+	require.Equal(t, val, parse(t, format.ParseTOML, out))
+}
+
+func TestINI(t *testing.T) {
+	val := parse(t, format.ParseINI, `
+; comment
+name = web
+[server]
+# comment
+port: 80
+motd = " hello; world "
+`)
+	require.Equal(t, map[string]any{
+		"name":   "web",
+		"server": map[string]any{"port": "80", "motd": " hello; world "},
+	}, val)
+	out := emit(t, format.EmitINI, format.EmitVars{Value: val})
+	require.Equal(t, "name = web\n\n[server]\nmotd = \" hello; world \"\nport = 80\n", out)
+	require.Equal(t, val, parse(t, format.ParseINI, out))
+
+	out = emit(t, format.EmitINI, format.EmitVars{Value: map[string]any{"size": float64(1000000), "ratio": 0.5}})
+	require.Equal(t, "ratio = 0.5\nsize = 1000000\n", out)
+
+	for _, data := range []string{"[server", "[]", "novalue", "server = a\n[server]\nport = 80"} {
+		_, err := format.ParseINI(context.Background(), &format.ParseParams{Params: format.ParseVars{Data: data}})
+		require.Error(t, err, data)
+	}
+	_, err := format.EmitINI(context.Background(), &format.EmitParams{Params: format.EmitVars{Value: map[string]any{"a": []any{1}}}})
+	require.Error(t, err)
+}
+
+func TestXML(t *testing.T) {
+	val := parse(t, format.ParseXML, `<?xml version="1.0"?>
+<server name="web">
+  <port>80</port>
+  <host>a</host>
+  <host>b</host>
+  <path secure="true">/api</path>
+</server>`)
+	require.Equal(t, map[string]any{"server": map[string]any{
+		"-name": "web",
+		"port":  "80",
+		"host":  []any{"a", "b"},
+		"path":  map[string]any{"-secure": "true", "#text": "/api"},
+	}}, val)
+	out := emit(t, format.EmitXML, format.EmitVars{Value: val, Indent: "  "})
+	require.Equal(t, `<server name="web">
+  <host>a</host>
+  <host>b</host>
+  <path secure="true">/api</path>
+  <port>80</port>
+</server>`, out)
+	require.Equal(t, val, parse(t, format.ParseXML, out))
+
+	for _, data := range []string{"", "<a></b>", "<a/><b/>"} {
+		_, err := format.ParseXML(context.Background(), &format.ParseParams{Params: format.ParseVars{Data: data}})
+		require.Error(t, err, data)
+	}
+	_, err := format.EmitXML(context.Background(), &format.EmitParams{Params: format.EmitVars{Value: map[string]any{"a": 1, "b": 2}}})
+	require.Error(t, err)
+}
+
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+	obj := map[string]any{"items": []any{
+		map[string]any{"name": "a", "ready": true},
+		map[string]any{"name": "b", "ready": false},
+	}}
+
+	ret, err := format.Lookup(ctx, &format.QueryParams{Params: format.QueryVars{Value: obj, Path: "items.1.name"}})
+	require.NoError(t, err)
+	require.Equal(t, "b", ret.Returns)
+
+	ret, err = format.JSONPath(ctx, &format.QueryParams{Params: format.QueryVars{Value: obj, Path: "{.items[*].name}"}})
+	require.NoError(t, err)
+	require.Equal(t, []any{"a", "b"}, ret.Returns)
+
+	ret, err = format.JMESPath(ctx, &format.QueryParams{Params: format.QueryVars{Value: obj, Path: "items[?ready].name"}})
+	require.NoError(t, err)
+	require.Equal(t, []any{"a"}, ret.Returns)
+
+	_, err = format.JMESPath(ctx, &format.QueryParams{Params: format.QueryVars{Value: obj, Path: "items[?"}})
+	require.Error(t, err)
+}
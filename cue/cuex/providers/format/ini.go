@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package format
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// parseINI parses the ini data, keys before any section are placed at the
+// top level and each section becomes a nested map
+func parseINI(data string) (map[string]any, error) {
+	root := map[string]any{}
+	current := root
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("invalid section at line %d: %s", lineNo, line)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if name == "" {
+				return nil, fmt.Errorf("empty section name at line %d", lineNo)
+			}
+			section, ok := root[name].(map[string]any)
+			if !ok {
+				if _, exists := root[name]; exists {
+					return nil, fmt.Errorf("section %s at line %d conflicts with the top level key", name, lineNo)
+				}
+				section = map[string]any{}
+				root[name] = section
+			}
+			current = section
+			continue
+		}
+		idx := strings.IndexAny(line, "=:")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid key value pair at line %d: %s", lineNo, line)
+		}
+		key := strings.TrimSpace(line[:idx])
+		value := strings.TrimSpace(line[idx+1:])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			if value[0] == '"' {
+				if unquoted, err := strconv.Unquote(value); err == nil {
+					value = unquoted
+				}
+			} else {
+				value = value[1 : len(value)-1]
+			}
+		}
+		current[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// emitINI emits the value as ini, nested maps are emitted as sections after
+// the top level keys. Keys are sorted for stable output.
+func emitINI(value any) (string, error) {
+	root, ok := value.(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("value must be a struct to emit ini")
+	}
+	var sb strings.Builder
+	var sections []string
+	for _, key := range sortedKeys(root) {
+		if _, isSection := root[key].(map[string]any); isSection {
+			sections = append(sections, key)
+			continue
+		}
+		if err := writeINIKey(&sb, key, root[key]); err != nil {
+			return "", err
+		}
+	}
+	for _, name := range sections {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("[" + name + "]\n")
+		section := root[name].(map[string]any)
+		for _, key := range sortedKeys(section) {
+			if err := writeINIKey(&sb, key, section[key]); err != nil {
+				return "", err
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+func writeINIKey(sb *strings.Builder, key string, value any) error {
+	var s string
+	switch v := value.(type) {
+	case nil:
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool, int, int64:
+		s = fmt.Sprint(v)
+	default:
+		return fmt.Errorf("unsupported ini value type %T for key %s", value, key)
+	}
+	if s != strings.TrimSpace(s) || strings.ContainsAny(s, "\n\r\"#;") {
+		s = strconv.Quote(s)
+	}
+	sb.WriteString(key + " = " + s + "\n")
+	return nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
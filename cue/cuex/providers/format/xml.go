@@ -0,0 +1,187 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package format
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	xmlAttrPrefix = "-"
+	xmlTextKey    = "#text"
+)
+
+type xmlNode struct {
+	attrs    map[string]any
+	children map[string][]any
+	order    []string
+	text     strings.Builder
+}
+
+func (in *xmlNode) value() any {
+	if len(in.attrs) == 0 && len(in.children) == 0 {
+		return strings.TrimSpace(in.text.String())
+	}
+	val := map[string]any{}
+	for k, v := range in.attrs {
+		val[k] = v
+	}
+	for _, name := range in.order {
+		if items := in.children[name]; len(items) == 1 {
+			val[name] = items[0]
+		} else {
+			val[name] = items
+		}
+	}
+	if text := strings.TrimSpace(in.text.String()); text != "" {
+		val[xmlTextKey] = text
+	}
+	return val
+}
+
+// parseXML parses the xml data into a generic map. Attributes are prefixed
+// with "-", repeated elements become lists and elements without attributes
+// or children become strings.
+func parseXML(data string) (map[string]any, error) {
+	decoder := xml.NewDecoder(strings.NewReader(data))
+	var stack []*xmlNode
+	var names []string
+	root := map[string]any{}
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{attrs: map[string]any{}, children: map[string][]any{}}
+			for _, attr := range t.Attr {
+				node.attrs[xmlAttrPrefix+attr.Name.Local] = attr.Value
+			}
+			stack = append(stack, node)
+			names = append(names, t.Name.Local)
+		case xml.EndElement:
+			node, name := stack[len(stack)-1], names[len(names)-1]
+			stack, names = stack[:len(stack)-1], names[:len(names)-1]
+			if len(stack) == 0 {
+				if len(root) > 0 {
+					return nil, fmt.Errorf("xml must have exactly one root element")
+				}
+				root[name] = node.value()
+				continue
+			}
+			parent := stack[len(stack)-1]
+			if _, found := parent.children[name]; !found {
+				parent.order = append(parent.order, name)
+			}
+			parent.children[name] = append(parent.children[name], node.value())
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+	if len(root) == 0 {
+		return nil, fmt.Errorf("no root element found in xml")
+	}
+	return root, nil
+}
+
+// emitXML emits the value as xml, the value must contain exactly one root
+// element. Keys are sorted for stable output.
+func emitXML(value any, indent string) (string, error) {
+	root, ok := value.(map[string]any)
+	if !ok || len(root) != 1 {
+		return "", fmt.Errorf("value must be a struct with exactly one root element to emit xml")
+	}
+	buf := &bytes.Buffer{}
+	encoder := xml.NewEncoder(buf)
+	encoder.Indent("", indent)
+	for name, val := range root {
+		if err := encodeXMLElement(encoder, name, val); err != nil {
+			return "", err
+		}
+	}
+	if err := encoder.Flush(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func encodeXMLElement(encoder *xml.Encoder, name string, value any) error {
+	if items, ok := value.([]any); ok {
+		for _, item := range items {
+			if err := encodeXMLElement(encoder, name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	var text string
+	var children []string
+	switch v := value.(type) {
+	case nil:
+	case map[string]any:
+		for _, key := range sortedKeys(v) {
+			switch {
+			case key == xmlTextKey:
+				text = fmt.Sprint(v[key])
+			case strings.HasPrefix(key, xmlAttrPrefix):
+				start.Attr = append(start.Attr, xml.Attr{
+					Name:  xml.Name{Local: strings.TrimPrefix(key, xmlAttrPrefix)},
+					Value: fmt.Sprint(v[key]),
+				})
+			default:
+				children = append(children, key)
+			}
+		}
+		if err := encoder.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, key := range children {
+			if err := encodeXMLElement(encoder, key, v[key]); err != nil {
+				return err
+			}
+		}
+		if text != "" {
+			if err := encoder.EncodeToken(xml.CharData(text)); err != nil {
+				return err
+			}
+		}
+		return encoder.EncodeToken(start.End())
+	default:
+		text = fmt.Sprint(v)
+	}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	if text != "" {
+		if err := encoder.EncodeToken(xml.CharData(text)); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
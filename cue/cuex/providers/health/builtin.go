@@ -0,0 +1,205 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+)
+
+type builtinRule func(obj *unstructured.Unstructured) (*Result, error)
+
+// typedRule converts the object into the typed struct before evaluation
+func typedRule[T any](fn func(*T) *Result) builtinRule {
+	return func(obj *unstructured.Unstructured) (*Result, error) {
+		typed := new(T)
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, typed); err != nil {
+			return nil, err
+		}
+		return fn(typed), nil
+	}
+}
+
+var builtinRules = map[schema.GroupKind]builtinRule{
+	{Group: "apps", Kind: "Deployment"}:        typedRule(deploymentHealth),
+	{Group: "apps", Kind: "StatefulSet"}:       typedRule(statefulSetHealth),
+	{Group: "apps", Kind: "DaemonSet"}:         typedRule(daemonSetHealth),
+	{Group: "apps", Kind: "ReplicaSet"}:        typedRule(replicaSetHealth),
+	{Group: "batch", Kind: "Job"}:              typedRule(jobHealth),
+	{Group: "", Kind: "Pod"}:                   typedRule(podHealth),
+	{Group: "", Kind: "PersistentVolumeClaim"}: typedRule(pvcHealth),
+	{Group: "", Kind: "Service"}:               typedRule(serviceHealth),
+}
+
+func healthy(format string, args ...any) *Result {
+	return &Result{Status: StatusHealthy, Message: fmt.Sprintf(format, args...)}
+}
+
+func progressing(format string, args ...any) *Result {
+	return &Result{Status: StatusProgressing, Message: fmt.Sprintf(format, args...)}
+}
+
+func degraded(format string, args ...any) *Result {
+	return &Result{Status: StatusDegraded, Message: fmt.Sprintf(format, args...)}
+}
+
+func deploymentHealth(deploy *appsv1.Deployment) *Result {
+	if deploy.Generation > deploy.Status.ObservedGeneration {
+		return progressing("waiting for rollout to be observed")
+	}
+	for _, cond := range deploy.Status.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded" {
+			return degraded("deployment %s exceeded its progress deadline", deploy.Name)
+		}
+	}
+	replicas := ptr.Deref(deploy.Spec.Replicas, 1)
+	switch {
+	case deploy.Status.UpdatedReplicas < replicas:
+		return progressing("%d out of %d new replicas have been updated", deploy.Status.UpdatedReplicas, replicas)
+	case deploy.Status.Replicas > deploy.Status.UpdatedReplicas:
+		return progressing("%d old replicas are pending termination", deploy.Status.Replicas-deploy.Status.UpdatedReplicas)
+	case deploy.Status.AvailableReplicas < deploy.Status.UpdatedReplicas:
+		return progressing("%d of %d updated replicas are available", deploy.Status.AvailableReplicas, deploy.Status.UpdatedReplicas)
+	}
+	return healthy("%d replicas are available", deploy.Status.AvailableReplicas)
+}
+
+func statefulSetHealth(sts *appsv1.StatefulSet) *Result {
+	if sts.Generation > sts.Status.ObservedGeneration {
+		return progressing("waiting for rollout to be observed")
+	}
+	replicas := ptr.Deref(sts.Spec.Replicas, 1)
+	if sts.Status.ReadyReplicas < replicas {
+		return progressing("%d of %d replicas are ready", sts.Status.ReadyReplicas, replicas)
+	}
+	if sts.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+		return healthy("%d replicas are ready", sts.Status.ReadyReplicas)
+	}
+	if ru := sts.Spec.UpdateStrategy.RollingUpdate; ru != nil && ru.Partition != nil && *ru.Partition > 0 {
+		if expected := replicas - *ru.Partition; sts.Status.UpdatedReplicas < expected {
+			return progressing("%d of %d replicas beyond the partition have been updated", sts.Status.UpdatedReplicas, expected)
+		}
+		return healthy("partitioned roll out complete")
+	}
+	if sts.Status.UpdateRevision != sts.Status.CurrentRevision {
+		return progressing("waiting for replicas to be updated to revision %s", sts.Status.UpdateRevision)
+	}
+	return healthy("%d replicas are ready", sts.Status.ReadyReplicas)
+}
+
+func daemonSetHealth(ds *appsv1.DaemonSet) *Result {
+	if ds.Generation > ds.Status.ObservedGeneration {
+		return progressing("waiting for rollout to be observed")
+	}
+	switch {
+	case ds.Status.UpdatedNumberScheduled < ds.Status.DesiredNumberScheduled:
+		return progressing("%d out of %d new pods have been updated", ds.Status.UpdatedNumberScheduled, ds.Status.DesiredNumberScheduled)
+	case ds.Status.NumberAvailable < ds.Status.DesiredNumberScheduled:
+		return progressing("%d of %d updated pods are available", ds.Status.NumberAvailable, ds.Status.DesiredNumberScheduled)
+	}
+	return healthy("%d pods are available", ds.Status.NumberAvailable)
+}
+
+func replicaSetHealth(rs *appsv1.ReplicaSet) *Result {
+	if rs.Generation > rs.Status.ObservedGeneration {
+		return progressing("waiting for rollout to be observed")
+	}
+	for _, cond := range rs.Status.Conditions {
+		if cond.Type == appsv1.ReplicaSetReplicaFailure && cond.Status == corev1.ConditionTrue {
+			return degraded("%s", cond.Message)
+		}
+	}
+	if replicas := ptr.Deref(rs.Spec.Replicas, 1); rs.Status.AvailableReplicas < replicas {
+		return progressing("%d of %d replicas are available", rs.Status.AvailableReplicas, replicas)
+	}
+	return healthy("%d replicas are available", rs.Status.AvailableReplicas)
+}
+
+func jobHealth(job *batchv1.Job) *Result {
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobFailed:
+			return degraded("job failed: %s", cond.Message)
+		case batchv1.JobComplete:
+			return healthy("job completed")
+		case batchv1.JobSuspended:
+			return healthy("job is suspended")
+		}
+	}
+	return progressing("job is running, %d active, %d succeeded", job.Status.Active, job.Status.Succeeded)
+}
+
+var podErrorReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+func podHealth(pod *corev1.Pod) *Result {
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return healthy("pod completed")
+	case corev1.PodFailed:
+		return degraded("pod failed: %s", pod.Status.Message)
+	}
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if w := status.State.Waiting; w != nil && podErrorReasons[w.Reason] {
+			return degraded("container %s is waiting: %s", status.Name, w.Reason)
+		}
+	}
+	if pod.Status.Phase == corev1.PodRunning {
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
+				return healthy("pod is ready")
+			}
+		}
+		return progressing("pod is running but not ready")
+	}
+	return progressing("pod is %s", pod.Status.Phase)
+}
+
+func pvcHealth(pvc *corev1.PersistentVolumeClaim) *Result {
+	switch pvc.Status.Phase {
+	case corev1.ClaimBound:
+		return healthy("claim is bound")
+	case corev1.ClaimLost:
+		return degraded("claim lost its underlying volume")
+	default:
+		return progressing("claim is pending")
+	}
+}
+
+func serviceHealth(svc *corev1.Service) *Result {
+	if svc.Spec.Type == corev1.ServiceTypeLoadBalancer && len(svc.Status.LoadBalancer.Ingress) == 0 {
+		return progressing("waiting for load balancer to be provisioned")
+	}
+	return healthy("service is ready")
+}
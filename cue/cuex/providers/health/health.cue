@@ -0,0 +1,24 @@
+package health
+
+#Evaluate: {
+	#do:       "evaluate"
+	#provider: "health"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The object to evaluate
+		object: {
+			apiVersion: string
+			kind:       string
+			...
+		}
+	}
+
+	// +usage=The health of the object
+	$returns?: {
+		// +usage=The health status of the object
+		status: "healthy" | "progressing" | "degraded" | "unknown"
+		// +usage=The message explaining the status
+		message: string
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"context"
+	"fmt"
+
+	_ "embed"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kubevela/pkg/cue/cuex/providers"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/util/runtime"
+)
+
+// Status the health status of object
+type Status string
+
+const (
+	// StatusHealthy the object is running as expected
+	StatusHealthy Status = "healthy"
+	// StatusProgressing the object is not healthy yet but still making progress
+	StatusProgressing Status = "progressing"
+	// StatusDegraded the object failed or is not able to become healthy
+	StatusDegraded Status = "degraded"
+	// StatusUnknown there is no rule to evaluate the health of the object
+	StatusUnknown Status = "unknown"
+)
+
+// Result the result of health evaluation
+type Result struct {
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+}
+
+// EvaluateObject evaluate the health of the object. Custom rules in the
+// DefaultRuleStore take precedence over the built-in rules. For objects
+// without rules, the Ready condition is used if exists.
+func EvaluateObject(obj *unstructured.Unstructured) (*Result, error) {
+	if obj == nil {
+		return nil, fmt.Errorf("object is required for evaluating health")
+	}
+	gvk := obj.GroupVersionKind()
+	if template, ok := DefaultRuleStore.Get().Get(obj.GetNamespace(), obj.GetAPIVersion(), obj.GetKind()); ok {
+		return evaluateTemplate(template, obj)
+	}
+	if rule, ok := builtinRules[gvk.GroupKind()]; ok {
+		return rule(obj)
+	}
+	return readyConditionHealth(obj), nil
+}
+
+// readyConditionHealth evaluate the health by the Ready condition which is
+// commonly used by custom resources
+func readyConditionHealth(obj *unstructured.Unstructured) *Result {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["type"] != "Ready" {
+			continue
+		}
+		message, _ := cond["message"].(string)
+		switch cond["status"] {
+		case "True":
+			return &Result{Status: StatusHealthy, Message: message}
+		case "False":
+			return &Result{Status: StatusDegraded, Message: message}
+		default:
+			return &Result{Status: StatusProgressing, Message: message}
+		}
+	}
+	return &Result{Status: StatusUnknown, Message: "no health rule for " + obj.GroupVersionKind().String()}
+}
+
+// EvaluateVars is the vars for evaluating health
+type EvaluateVars struct {
+	Object *unstructured.Unstructured `json:"object"`
+}
+
+// EvaluateParams is the params for evaluating health
+type EvaluateParams providers.Params[EvaluateVars]
+
+// EvaluateReturns is the returns for evaluating health
+type EvaluateReturns providers.Returns[*Result]
+
+// Evaluate evaluates the health of the object
+func Evaluate(_ context.Context, params *EvaluateParams) (*EvaluateReturns, error) {
+	result, err := EvaluateObject(params.Params.Object)
+	if err != nil {
+		return nil, err
+	}
+	return &EvaluateReturns{Returns: result}, nil
+}
+
+// ProviderName .
+const ProviderName = "health"
+
+//go:embed health.cue
+var template string
+
+// Package .
+var Package = runtime.Must(cuexruntime.NewInternalPackage(ProviderName, template, map[string]cuexruntime.ProviderFn{
+	"evaluate": cuexruntime.GenericProviderFn[EvaluateParams, EvaluateReturns](Evaluate),
+}))
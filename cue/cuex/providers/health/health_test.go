@@ -0,0 +1,227 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/kubevela/pkg/apis/cue/v1alpha1"
+	"github.com/kubevela/pkg/cue/cuex/providers/health"
+	"github.com/kubevela/pkg/util/singleton"
+)
+
+func newObject(apiVersion, kind string, spec, status map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": "test", "namespace": "default", "generation": int64(1)},
+	}}
+	if spec != nil {
+		obj.Object["spec"] = spec
+	}
+	if status != nil {
+		obj.Object["status"] = status
+	}
+	return obj
+}
+
+func TestBuiltinRules(t *testing.T) {
+	health.DefaultRuleStore.Set(health.NewRuleStore())
+	for name, tt := range map[string]struct {
+		Object *unstructured.Unstructured
+		Status health.Status
+	}{
+		"deployment-healthy": {
+			Object: newObject("apps/v1", "Deployment", map[string]interface{}{"replicas": int64(2)},
+				map[string]interface{}{"observedGeneration": int64(1), "replicas": int64(2), "updatedReplicas": int64(2), "availableReplicas": int64(2)}),
+			Status: health.StatusHealthy,
+		},
+		"deployment-not-observed": {
+			Object: newObject("apps/v1", "Deployment", nil, map[string]interface{}{}),
+			Status: health.StatusProgressing,
+		},
+		"deployment-rolling": {
+			Object: newObject("apps/v1", "Deployment", map[string]interface{}{"replicas": int64(2)},
+				map[string]interface{}{"observedGeneration": int64(1), "replicas": int64(3), "updatedReplicas": int64(2), "availableReplicas": int64(2)}),
+			Status: health.StatusProgressing,
+		},
+		"deployment-deadline-exceeded": {
+			Object: newObject("apps/v1", "Deployment", nil, map[string]interface{}{"observedGeneration": int64(1), "conditions": []interface{}{
+				map[string]interface{}{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded"},
+			}}),
+			Status: health.StatusDegraded,
+		},
+		"statefulset-healthy": {
+			Object: newObject("apps/v1", "StatefulSet", nil,
+				map[string]interface{}{"observedGeneration": int64(1), "readyReplicas": int64(1), "currentRevision": "r1", "updateRevision": "r1"}),
+			Status: health.StatusHealthy,
+		},
+		"statefulset-updating": {
+			Object: newObject("apps/v1", "StatefulSet", nil,
+				map[string]interface{}{"observedGeneration": int64(1), "readyReplicas": int64(1), "currentRevision": "r1", "updateRevision": "r2"}),
+			Status: health.StatusProgressing,
+		},
+		"daemonset-progressing": {
+			Object: newObject("apps/v1", "DaemonSet", nil,
+				map[string]interface{}{"observedGeneration": int64(1), "desiredNumberScheduled": int64(3), "updatedNumberScheduled": int64(3), "numberAvailable": int64(2)}),
+			Status: health.StatusProgressing,
+		},
+		"job-failed": {
+			Object: newObject("batch/v1", "Job", nil, map[string]interface{}{"conditions": []interface{}{
+				map[string]interface{}{"type": "Failed", "status": "True", "message": "backoff limit exceeded"},
+			}}),
+			Status: health.StatusDegraded,
+		},
+		"job-complete": {
+			Object: newObject("batch/v1", "Job", nil, map[string]interface{}{"conditions": []interface{}{
+				map[string]interface{}{"type": "Complete", "status": "True"},
+			}}),
+			Status: health.StatusHealthy,
+		},
+		"pod-crashloop": {
+			Object: newObject("v1", "Pod", nil, map[string]interface{}{"phase": "Running", "containerStatuses": []interface{}{
+				map[string]interface{}{"name": "main", "state": map[string]interface{}{"waiting": map[string]interface{}{"reason": "CrashLoopBackOff"}}},
+			}}),
+			Status: health.StatusDegraded,
+		},
+		"pod-ready": {
+			Object: newObject("v1", "Pod", nil, map[string]interface{}{"phase": "Running", "conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "True"},
+			}}),
+			Status: health.StatusHealthy,
+		},
+		"pvc-pending": {
+			Object: newObject("v1", "PersistentVolumeClaim", nil, map[string]interface{}{"phase": "Pending"}),
+			Status: health.StatusProgressing,
+		},
+		"service-lb-pending": {
+			Object: newObject("v1", "Service", map[string]interface{}{"type": "LoadBalancer"}, nil),
+			Status: health.StatusProgressing,
+		},
+		"service-cluster-ip": {
+			Object: newObject("v1", "Service", map[string]interface{}{"type": "ClusterIP"}, nil),
+			Status: health.StatusHealthy,
+		},
+		"custom-ready": {
+			Object: newObject("example.com/v1", "Database", nil, map[string]interface{}{"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "False", "message": "disk full"},
+			}}),
+			Status: health.StatusDegraded,
+		},
+		"custom-unknown": {
+			Object: newObject("example.com/v1", "Database", nil, nil),
+			Status: health.StatusUnknown,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ret, err := health.Evaluate(context.Background(), &health.EvaluateParams{Params: health.EvaluateVars{Object: tt.Object}})
+			require.NoError(t, err)
+			require.Equal(t, tt.Status, ret.Returns.Status, ret.Returns.Message)
+		})
+	}
+}
+
+func TestCustomRules(t *testing.T) {
+	rule := &v1alpha1.HealthRule{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.GroupVersion.String(), Kind: "HealthRule"},
+		ObjectMeta: metav1.ObjectMeta{Name: "database", Namespace: "vela-system"},
+		Spec: v1alpha1.HealthRuleSpec{
+			APIVersion: "example.com/v1",
+			Kind:       "Database",
+			Template: `
+				_phase: *"" | string
+				if context.data.status.phase != _|_ { _phase: context.data.status.phase }
+				status: [if _phase == "Online" {"healthy"}, "progressing"][0]
+				message: "database is \(_phase)"
+			`,
+		},
+	}
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(rule)
+	require.NoError(t, err)
+	singleton.DynamicClient.Set(dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		v1alpha1.HealthRuleGroupVersionResource: "HealthRuleList",
+	}, &unstructured.Unstructured{Object: u}))
+	store := health.NewRuleStore()
+	require.NoError(t, store.Load(context.Background()))
+	health.DefaultRuleStore.Set(store)
+
+	ret, err := health.EvaluateObject(newObject("example.com/v1", "Database", nil, map[string]interface{}{"phase": "Online"}))
+	require.NoError(t, err)
+	require.Equal(t, &health.Result{Status: health.StatusHealthy, Message: "database is Online"}, ret)
+	ret, err = health.EvaluateObject(newObject("example.com/v1", "Database", nil, nil))
+	require.NoError(t, err)
+	require.Equal(t, health.StatusProgressing, ret.Status)
+
+	rule.Spec.Template = `status: "unavailable"`
+	store.Set(rule)
+	_, err = health.EvaluateObject(newObject("example.com/v1", "Database", nil, nil))
+	require.Error(t, err)
+
+	rule.Spec.Template = `message: "missing status"`
+	store.Set(rule)
+	_, err = health.EvaluateObject(newObject("example.com/v1", "Database", nil, nil))
+	require.Error(t, err)
+
+	store.Del(rule)
+	ret, err = health.EvaluateObject(newObject("example.com/v1", "Database", nil, nil))
+	require.NoError(t, err)
+	require.Equal(t, health.StatusUnknown, ret.Status)
+
+	_, err = health.EvaluateObject(nil)
+	require.Error(t, err)
+	_, err = health.Evaluate(context.Background(), &health.EvaluateParams{})
+	require.Error(t, err)
+}
+
+func TestCustomRulesNamespace(t *testing.T) {
+	store := health.NewRuleStore()
+	store.Set(&v1alpha1.HealthRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "deployment", Namespace: "tenant"},
+		Spec:       v1alpha1.HealthRuleSpec{APIVersion: "apps/v1", Kind: "Deployment", Template: `status: "degraded"`},
+	})
+	health.DefaultRuleStore.Set(store)
+	deploy := newObject("apps/v1", "Deployment", map[string]interface{}{"replicas": int64(1)},
+		map[string]interface{}{"observedGeneration": int64(1), "replicas": int64(1), "updatedReplicas": int64(1), "availableReplicas": int64(1)})
+
+	ret, err := health.EvaluateObject(deploy)
+	require.NoError(t, err)
+	require.Equal(t, health.StatusHealthy, ret.Status)
+	deploy.SetNamespace("tenant")
+	ret, err = health.EvaluateObject(deploy)
+	require.NoError(t, err)
+	require.Equal(t, health.StatusDegraded, ret.Status)
+
+	store.Set(&v1alpha1.HealthRule{
+		ObjectMeta: metav1.ObjectMeta{Name: "deployment", Namespace: health.SystemNamespace},
+		Spec:       v1alpha1.HealthRuleSpec{APIVersion: "apps/v1", Kind: "Deployment", Template: `status: "progressing"`},
+	})
+	ret, err = health.EvaluateObject(deploy)
+	require.NoError(t, err)
+	require.Equal(t, health.StatusDegraded, ret.Status)
+	deploy.SetNamespace("default")
+	ret, err = health.EvaluateObject(deploy)
+	require.NoError(t, err)
+	require.Equal(t, health.StatusProgressing, ret.Status)
+}
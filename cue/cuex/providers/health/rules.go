@@ -0,0 +1,195 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/kubevela/pkg/apis/cue/v1alpha1"
+	"github.com/kubevela/pkg/meta"
+	"github.com/kubevela/pkg/util/k8s"
+	"github.com/kubevela/pkg/util/maps"
+	"github.com/kubevela/pkg/util/singleton"
+)
+
+const defaultResyncPeriod = 5 * time.Minute
+
+// RuleStore stores the custom health rules defined by HealthRule
+type RuleStore struct {
+	Rules        *maps.SyncMap[string, *v1alpha1.HealthRule]
+	ResyncPeriod time.Duration
+	StopCh       chan struct{}
+}
+
+// NewRuleStore create an empty RuleStore
+func NewRuleStore() *RuleStore {
+	return &RuleStore{
+		Rules:        maps.NewSyncMap[string, *v1alpha1.HealthRule](),
+		ResyncPeriod: defaultResyncPeriod,
+	}
+}
+
+func (in *RuleStore) getRuleID(rule *v1alpha1.HealthRule) string {
+	return rule.GetNamespace() + "/" + rule.GetName()
+}
+
+// Set add or update the rule
+func (in *RuleStore) Set(rule *v1alpha1.HealthRule) {
+	in.Rules.Set(in.getRuleID(rule), rule)
+}
+
+// Del remove the rule
+func (in *RuleStore) Del(rule *v1alpha1.HealthRule) {
+	in.Rules.Del(in.getRuleID(rule))
+}
+
+// Get return the template of the rule for the given apiVersion and kind of
+// the object in the namespace. Rules in the namespace of the object take
+// precedence over the ones in the SystemNamespace, which apply to all
+// namespaces and the cluster scoped objects. Rules in other namespaces are
+// never used. If multiple rules match, the one with the smallest name is used.
+func (in *RuleStore) Get(namespace, apiVersion, kind string) (string, bool) {
+	for _, ns := range []string{namespace, SystemNamespace} {
+		var ids []string
+		in.Rules.Range(func(id string, rule *v1alpha1.HealthRule) {
+			if ns != "" && rule.GetNamespace() == ns && rule.Spec.APIVersion == apiVersion && rule.Spec.Kind == kind {
+				ids = append(ids, id)
+			}
+		})
+		if len(ids) > 0 {
+			sort.Strings(ids)
+			rule, _ := in.Rules.Get(ids[0])
+			return rule.Spec.Template, true
+		}
+	}
+	return "", false
+}
+
+// Load load all HealthRules from the cluster
+func (in *RuleStore) Load(ctx context.Context) error {
+	rules, err := singleton.DynamicClient.Get().Resource(v1alpha1.HealthRuleGroupVersionResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, item := range rules.Items {
+		rule, err := k8s.AsStructured[v1alpha1.HealthRule](&item)
+		if err != nil {
+			return err
+		}
+		in.Set(rule)
+	}
+	return nil
+}
+
+// Listen start informer to listen HealthRule changes
+func (in *RuleStore) Listen(stopCh <-chan struct{}) {
+	if stopCh == nil && in.StopCh == nil {
+		in.StopCh = make(chan struct{})
+		stopCh = in.StopCh
+	}
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(
+		singleton.DynamicClient.Get(), in.ResyncPeriod)
+	informer := factory.ForResource(v1alpha1.HealthRuleGroupVersionResource).Informer()
+	defer runtime.HandleCrash()
+	_, _ = informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if o, err := k8s.AsStructured[v1alpha1.HealthRule](obj.(*unstructured.Unstructured)); err == nil {
+				in.Set(o)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if o, err := k8s.AsStructured[v1alpha1.HealthRule](newObj.(*unstructured.Unstructured)); err == nil {
+				in.Set(o)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if o, err := k8s.AsStructured[v1alpha1.HealthRule](obj.(*unstructured.Unstructured)); err == nil {
+				in.Del(o)
+			}
+		},
+	})
+	informer.Run(stopCh)
+}
+
+var (
+	// SystemNamespace the namespace of the HealthRules that apply to all
+	// namespaces
+	SystemNamespace = meta.NamespaceVelaSystem
+	// EnableCustomHealthRules .
+	EnableCustomHealthRules = true
+	// EnableCustomHealthRulesWatch .
+	EnableCustomHealthRulesWatch = false
+)
+
+// DefaultRuleStore the RuleStore used by the health provider
+var DefaultRuleStore = singleton.NewSingleton[*RuleStore](func() *RuleStore {
+	store := NewRuleStore()
+	if EnableCustomHealthRules {
+		if err := store.Load(context.Background()); err != nil && !kerrors.IsNotFound(err) {
+			klog.Errorf("failed to load custom health rules: %s", err.Error())
+		}
+	}
+	if EnableCustomHealthRulesWatch {
+		go store.Listen(nil)
+	}
+	return store
+})
+
+// evaluateTemplate evaluate the CUE health rule with the object filled in
+// context.data
+func evaluateTemplate(template string, obj *unstructured.Unstructured) (*Result, error) {
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+	src := strings.Join([]string{template, "context: data: " + string(data)}, "\n")
+	val := cuecontext.New().CompileString(src)
+	if val.Err() != nil {
+		return nil, fmt.Errorf("failed to compile health rule: %w", val.Err())
+	}
+	status, err := val.LookupPath(cue.ParsePath("status")).String()
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate status of health rule: %w", err)
+	}
+	result := &Result{Status: Status(status)}
+	if v := val.LookupPath(cue.ParsePath("message")); v.Exists() {
+		if result.Message, err = v.String(); err != nil {
+			return nil, fmt.Errorf("failed to evaluate message of health rule: %w", err)
+		}
+	}
+	switch result.Status {
+	case StatusHealthy, StatusProgressing, StatusDegraded, StatusUnknown:
+		return result, nil
+	default:
+		return nil, fmt.Errorf("invalid health status %q returned by health rule", result.Status)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kube
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kubevela/pkg/cue/cuex/providers"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/multicluster"
+	"github.com/kubevela/pkg/util/slices"
+)
+
+// ClusterSelector selects clusters for fan-out queries
+type ClusterSelector struct {
+	MatchLabels      map[string]string                 `json:"matchLabels,omitempty"`
+	MatchExpressions []metav1.LabelSelectorRequirement `json:"matchExpressions,omitempty"`
+}
+
+// FanOutVars is the common vars for running queries across clusters
+type FanOutVars struct {
+	Clusters        []string         `json:"clusters,omitempty"`
+	ClusterSelector *ClusterSelector `json:"clusterSelector,omitempty"`
+	Parallelism     int              `json:"parallelism,omitempty"`
+}
+
+// ClusterResult is the result of one cluster in fan-out queries, Error is
+// set instead of Result if the query fails in the cluster
+type ClusterResult[T any] struct {
+	Result T      `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// resolveClusters returns the deduplicated clusters given by names and the
+// cluster selector
+func (in FanOutVars) resolveClusters(ctx context.Context) ([]string, error) {
+	var clusters []string
+	visited := map[string]bool{}
+	add := func(names ...string) {
+		for _, name := range names {
+			if !visited[name] {
+				visited[name] = true
+				clusters = append(clusters, name)
+			}
+		}
+	}
+	add(in.Clusters...)
+	if in.ClusterSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
+			MatchLabels:      in.ClusterSelector.MatchLabels,
+			MatchExpressions: in.ClusterSelector.MatchExpressions,
+		})
+		if err != nil {
+			return nil, err
+		}
+		selected, err := multicluster.ListClusters(ctx, cuexruntime.GetKubeClient(ctx), selector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list clusters: %w", err)
+		}
+		add(selected...)
+	}
+	return clusters, nil
+}
+
+// fanOut runs fn against all the selected clusters with bounded parallelism
+func fanOut[T any](ctx context.Context, vars FanOutVars, fn func(cluster string) (T, error)) (map[string]ClusterResult[T], error) {
+	clusters, err := vars.resolveClusters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parallelism := vars.Parallelism
+	if parallelism <= 0 {
+		parallelism = slices.DefaultParallelism
+	}
+	results := slices.ParMap(clusters, func(cluster string) ClusterResult[T] {
+		if err := ctx.Err(); err != nil {
+			return ClusterResult[T]{Error: err.Error()}
+		}
+		result, err := fn(cluster)
+		if err != nil {
+			return ClusterResult[T]{Error: err.Error()}
+		}
+		return ClusterResult[T]{Result: result}
+	}, slices.Parallelism(parallelism))
+	returns := make(map[string]ClusterResult[T], len(clusters))
+	for i, cluster := range clusters {
+		returns[cluster] = results[i]
+	}
+	return returns, nil
+}
+
+// GetAcrossClustersVars is the vars for getting resource across clusters
+type GetAcrossClustersVars struct {
+	FanOutVars `json:",inline"`
+	Resource   *unstructured.Unstructured `json:"resource"`
+}
+
+// GetAcrossClustersParams is the params for getting resource across clusters
+type GetAcrossClustersParams providers.Params[GetAcrossClustersVars]
+
+// GetAcrossClustersReturns is the returns for getting resource across clusters
+type GetAcrossClustersReturns providers.Returns[map[string]ClusterResult[*unstructured.Unstructured]]
+
+// GetAcrossClusters gets the resource from each of the selected clusters
+func GetAcrossClusters(ctx context.Context, getParams *GetAcrossClustersParams) (*GetAcrossClustersReturns, error) {
+	params := getParams.Params
+	returns, err := fanOut(ctx, params.FanOutVars, func(cluster string) (*unstructured.Unstructured, error) {
+		ret, err := Get(ctx, &ResourceParams{Params: ResourceVars{Cluster: cluster, Resource: params.Resource.DeepCopy()}})
+		if err != nil {
+			return nil, err
+		}
+		return ret.Returns, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &GetAcrossClustersReturns{Returns: returns}, nil
+}
+
+// ListAcrossClustersVars is the vars for listing resources across clusters
+type ListAcrossClustersVars struct {
+	FanOutVars `json:",inline"`
+	Filter     *ListFilter                `json:"filter,omitempty"`
+	Resource   *unstructured.Unstructured `json:"resource"`
+}
+
+// ListAcrossClustersParams is the params for listing resources across clusters
+type ListAcrossClustersParams providers.Params[ListAcrossClustersVars]
+
+// ListAcrossClustersReturns is the returns for listing resources across clusters
+type ListAcrossClustersReturns providers.Returns[map[string]ClusterResult[*unstructured.UnstructuredList]]
+
+// ListAcrossClusters lists the resources from each of the selected clusters
+func ListAcrossClusters(ctx context.Context, listParams *ListAcrossClustersParams) (*ListAcrossClustersReturns, error) {
+	params := listParams.Params
+	returns, err := fanOut(ctx, params.FanOutVars, func(cluster string) (*unstructured.UnstructuredList, error) {
+		ret, err := List(ctx, &ListParams{Params: ListVars{Cluster: cluster, Filter: params.Filter, Resource: params.Resource.DeepCopy()}})
+		if err != nil {
+			return nil, err
+		}
+		return ret.Returns, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ListAcrossClustersReturns{Returns: returns}, nil
+}
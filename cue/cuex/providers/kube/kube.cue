@@ -130,3 +130,81 @@ package kube
 		...
 	}
 }
+
+#GetAcrossClusters: {
+	#do:       "getAcrossClusters"
+	#provider: "kube"
+
+	// +usage=The params of this action
+	$params: {
+		#FanOut
+		// +usage=The resource to get
+		resource: {
+			// +usage=The api version of the resource
+			apiVersion: string
+			// +usage=The kind of the resource
+			kind: string
+			// +usage=The metadata of the resource
+			metadata: {
+				// +usage=The name of the resource
+				name: string
+				// +usage=The namespace of the resource
+				namespace?: string
+			}
+		}
+	}
+
+	// +usage=The result of this action, the map from cluster name to the resource read from the cluster or the error occurred
+	$returns: [cluster=string]: {
+		result?: {...}
+		error?:  string
+	}
+}
+
+#ListAcrossClusters: {
+	#do:       "listAcrossClusters"
+	#provider: "kube"
+
+	// +usage=The params of this action
+	$params: {
+		#FanOut
+		// +usage=The resource to list
+		resource: {
+			// +usage=The api version of the resource
+			apiVersion: string
+			// +usage=The kind of the resource
+			kind: string
+		}
+		// +usage=The filter to list the resources
+		filter?: {
+			// +usage=The namespace to list the resources
+			namespace: *"" | string
+			// +usage=The label selector to filter the resources
+			matchingLabels?: {...}
+		}
+	}
+
+	// +usage=The result of this action, the map from cluster name to the resource list from the cluster or the error occurred
+	$returns: [cluster=string]: {
+		result?: {...}
+		error?:  string
+	}
+}
+
+#FanOut: {
+	// +usage=The clusters to run against
+	clusters: *[] | [...string]
+	// +usage=The selector of clusters to run against, selected clusters are added to clusters
+	clusterSelector?: {
+		// +usage=The labels the cluster must have
+		matchLabels?: [string]: string
+		// +usage=The label selector requirements of the cluster
+		matchExpressions?: [...{
+			key:      string
+			operator: "In" | "NotIn" | "Exists" | "DoesNotExist"
+			values?: [...string]
+		}]
+	}
+	// +usage=The max number of clusters to run against concurrently
+	parallelism: *5 | int & >0
+}
@@ -18,6 +18,7 @@ package kube
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 
@@ -34,7 +35,6 @@ import (
 	"github.com/kubevela/pkg/util/k8s"
 	"github.com/kubevela/pkg/util/k8s/patch"
 	"github.com/kubevela/pkg/util/runtime"
-	"github.com/kubevela/pkg/util/singleton"
 )
 
 const (
@@ -68,12 +68,35 @@ type ResourceParams providers.Params[ResourceVars]
 // ResourceReturns is the returns for resource
 type ResourceReturns providers.Returns[*unstructured.Unstructured]
 
+// markSecretDataSensitive marks the data of Secrets as sensitive, both the
+// encoded and the decoded values are recorded
+func markSecretDataSensitive(ctx context.Context, objs ...*unstructured.Unstructured) {
+	for _, obj := range objs {
+		if obj == nil || obj.GetAPIVersion() != "v1" || obj.GetKind() != "Secret" {
+			continue
+		}
+		data, _, _ := unstructured.NestedStringMap(obj.Object, "data")
+		for _, v := range data {
+			cuexruntime.MarkSensitive(ctx, v)
+			if bs, err := base64.StdEncoding.DecodeString(v); err == nil {
+				cuexruntime.MarkSensitive(ctx, string(bs))
+			}
+		}
+		stringData, _, _ := unstructured.NestedStringMap(obj.Object, "stringData")
+		for _, v := range stringData {
+			cuexruntime.MarkSensitive(ctx, v, base64.StdEncoding.EncodeToString([]byte(v)))
+		}
+	}
+}
+
 // Apply .
 func Apply(ctx context.Context, getParams *ResourceParams) (*ResourceReturns, error) {
 	params := getParams.Params
 	ctx = multicluster.WithCluster(ctx, params.Cluster)
 	workload := params.Resource
-	cli := singleton.KubeClient.Get()
+	markSecretDataSensitive(ctx, workload)
+	defer markSecretDataSensitive(ctx, workload)
+	cli := cuexruntime.GetKubeClient(ctx)
 	existing := &unstructured.Unstructured{}
 	existing.GetObjectKind().SetGroupVersionKind(workload.GetObjectKind().GroupVersionKind())
 
@@ -115,9 +138,10 @@ func Apply(ctx context.Context, getParams *ResourceParams) (*ResourceReturns, er
 func Get(ctx context.Context, getParams *ResourceParams) (*ResourceReturns, error) {
 	params := getParams.Params
 	ctx = multicluster.WithCluster(ctx, params.Cluster)
-	if err := singleton.KubeClient.Get().Get(ctx, client.ObjectKeyFromObject(params.Resource), params.Resource); err != nil {
+	if err := cuexruntime.GetKubeClient(ctx).Get(ctx, client.ObjectKeyFromObject(params.Resource), params.Resource); err != nil {
 		return nil, err
 	}
+	markSecretDataSensitive(ctx, params.Resource)
 	return &ResourceReturns{Returns: params.Resource}, nil
 }
 
@@ -154,9 +178,12 @@ func List(ctx context.Context, listParams *ListParams) (*ListReturns, error) {
 	returns := &ListReturns{
 		Returns: &unstructured.UnstructuredList{Object: params.Resource.Object},
 	}
-	if err := singleton.KubeClient.Get().List(ctx, returns.Returns, listOpts...); err != nil {
+	if err := cuexruntime.GetKubeClient(ctx).List(ctx, returns.Returns, listOpts...); err != nil {
 		return returns, err
 	}
+	for i := range returns.Returns.Items {
+		markSecretDataSensitive(ctx, &returns.Returns.Items[i])
+	}
 	return returns, nil
 }
 
@@ -180,10 +207,12 @@ type PatchParams providers.Params[PatchVars]
 func Patch(ctx context.Context, patchParams *PatchParams) (*ResourceReturns, error) {
 	params := patchParams.Params
 	ctx = multicluster.WithCluster(ctx, params.Cluster)
-	err := singleton.KubeClient.Get().Get(ctx, client.ObjectKeyFromObject(params.Resource), params.Resource)
+	defer markSecretDataSensitive(ctx, params.Resource)
+	err := cuexruntime.GetKubeClient(ctx).Get(ctx, client.ObjectKeyFromObject(params.Resource), params.Resource)
 	if err != nil {
 		return nil, err
 	}
+	markSecretDataSensitive(ctx, params.Resource)
 	patchData, err := json.Marshal(params.Patch.Data)
 	if err != nil {
 		return nil, err
@@ -197,7 +226,7 @@ func Patch(ctx context.Context, patchParams *PatchParams) (*ResourceReturns, err
 	default:
 		patchType = types.MergePatchType
 	}
-	if err := singleton.KubeClient.Get().Patch(ctx, params.Resource, client.RawPatch(patchType, patchData)); err != nil {
+	if err := cuexruntime.GetKubeClient(ctx).Patch(ctx, params.Resource, client.RawPatch(patchType, patchData)); err != nil {
 		return nil, err
 	}
 	return &ResourceReturns{Returns: params.Resource}, nil
@@ -215,4 +244,7 @@ var Package = runtime.Must(cuexruntime.NewInternalPackage(ProviderName, template
 	"get":   cuexruntime.GenericProviderFn[ResourceParams, ResourceReturns](Get),
 	"list":  cuexruntime.GenericProviderFn[ListParams, ListReturns](List),
 	"patch": cuexruntime.GenericProviderFn[PatchParams, ResourceReturns](Patch),
+
+	"getAcrossClusters":  cuexruntime.GenericProviderFn[GetAcrossClustersParams, GetAcrossClustersReturns](GetAcrossClusters),
+	"listAcrossClusters": cuexruntime.GenericProviderFn[ListAcrossClustersParams, ListAcrossClustersReturns](ListAcrossClusters),
 }))
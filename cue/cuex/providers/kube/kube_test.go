@@ -18,6 +18,7 @@ package kube_test
 
 import (
 	"context"
+	"fmt"
 	"math"
 	"testing"
 
@@ -25,9 +26,13 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
 	"github.com/kubevela/pkg/cue/cuex/providers/kube"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/multicluster"
 	"github.com/kubevela/pkg/util/singleton"
 	"github.com/kubevela/pkg/util/slices"
 )
@@ -244,3 +249,108 @@ func TestKube(t *testing.T) {
 	patchResult, err = kube.Patch(ctx, patchParams)
 	require.Error(t, err)
 }
+
+func TestKubeSecretSensitive(t *testing.T) {
+	cli := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "s", Namespace: "x"},
+		Data:       map[string][]byte{"password": []byte("p@ss")},
+	}).Build()
+	singleton.KubeClient.Set(cli)
+	ctx := cuexruntime.WithSensitiveValues(context.Background())
+	_, err := kube.Get(ctx, &kube.ResourceParams{
+		Params: kube.ResourceVars{
+			Resource: &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"metadata": map[string]interface{}{
+					"name":      "s",
+					"namespace": "x",
+				},
+			}},
+		}})
+	require.NoError(t, err)
+	require.Equal(t, "<redacted> <redacted>", cuexruntime.Redact(ctx, "p@ss cEBzcw=="))
+}
+
+func newClusterSecret(name string, region string) *corev1.Secret {
+	return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name:      name,
+		Namespace: multicluster.ClusterSecretNamespace,
+		Labels: map[string]string{
+			multicluster.LabelClusterCredentialType: "X509Certificate",
+			"region":                                region,
+		},
+	}}
+}
+
+func TestKubeAcrossClusters(t *testing.T) {
+	cli := fake.NewClientBuilder().WithObjects(
+		newClusterSecret("c1", "east"),
+		newClusterSecret("c2", "west"),
+		newClusterSecret("c3", "east"),
+		newConfigMap("a", "x", "1"),
+		newConfigMap("b", "x", "2"),
+	).WithInterceptorFuncs(interceptor.Funcs{
+		Get: func(ctx context.Context, cli client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if cluster, _ := multicluster.ClusterFrom(ctx); cluster == "c3" {
+				return fmt.Errorf("cluster c3 unreachable")
+			}
+			return cli.Get(ctx, key, obj, opts...)
+		},
+		List: func(ctx context.Context, cli client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if cluster, _ := multicluster.ClusterFrom(ctx); cluster == "c3" {
+				return fmt.Errorf("cluster c3 unreachable")
+			}
+			return cli.List(ctx, list, opts...)
+		},
+	}).Build()
+	singleton.KubeClient.Set(cli)
+	ctx := context.Background()
+
+	getRet, err := kube.GetAcrossClusters(ctx, &kube.GetAcrossClustersParams{Params: kube.GetAcrossClustersVars{
+		FanOutVars: kube.FanOutVars{
+			Clusters:        []string{"local", "c1"},
+			ClusterSelector: &kube.ClusterSelector{MatchLabels: map[string]string{"region": "east"}},
+			Parallelism:     2,
+		},
+		Resource: &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "a", "namespace": "x"},
+		}},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(getRet.Returns))
+	require.Equal(t, "1", getRet.Returns["local"].Result.GetLabels()["label"])
+	require.Equal(t, "a", getRet.Returns["c1"].Result.GetName())
+	require.Empty(t, getRet.Returns["c1"].Error)
+	require.Nil(t, getRet.Returns["c3"].Result)
+	require.Equal(t, "cluster c3 unreachable", getRet.Returns["c3"].Error)
+
+	listRet, err := kube.ListAcrossClusters(ctx, &kube.ListAcrossClustersParams{Params: kube.ListAcrossClustersVars{
+		FanOutVars: kube.FanOutVars{
+			ClusterSelector: &kube.ClusterSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key: "region", Operator: metav1.LabelSelectorOpIn, Values: []string{"west", "east"},
+			}}},
+		},
+		Filter: &kube.ListFilter{Namespace: "x", MatchingLabels: map[string]string{"label": "2"}},
+		Resource: &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+		}},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(listRet.Returns))
+	for _, cluster := range []string{"c1", "c2"} {
+		require.Equal(t, 1, len(listRet.Returns[cluster].Result.Items))
+		require.Equal(t, "b", listRet.Returns[cluster].Result.Items[0].GetName())
+	}
+	require.Equal(t, "cluster c3 unreachable", listRet.Returns["c3"].Error)
+
+	_, err = kube.ListAcrossClusters(ctx, &kube.ListAcrossClustersParams{Params: kube.ListAcrossClustersVars{
+		FanOutVars: kube.FanOutVars{ClusterSelector: &kube.ClusterSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key: "region", Operator: "Unknown",
+		}}}},
+	}})
+	require.Error(t, err)
+}
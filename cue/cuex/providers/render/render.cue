@@ -0,0 +1,55 @@
+package render
+
+#GoTemplate: {
+	#do:       "goTemplate"
+	#provider: "render"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The go template to render, sprig functions are available
+		template: string
+		// +usage=The data to render the template with
+		data: _
+		// +usage=The behavior when the template references a missing key in map
+		missingKey: *"default" | "zero" | "error"
+		// +usage=The custom action delimiters of the template
+		delims?: {
+			left:  string
+			right: string
+		}
+	}
+
+	// +usage=The rendered string
+	$returns?: string
+}
+
+#HelmTemplate: {
+	#do:       "helmTemplate"
+	#provider: "render"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The path of the chart relative to the chart directory of the server, either a directory or a packaged tarball
+		chart: string
+		// +usage=The values to render the chart with
+		values: *{} | {...}
+		// +usage=The release to render the chart for
+		release: {
+			// +usage=The name of the release
+			name: *"release" | string
+			// +usage=The namespace of the release
+			namespace: *"default" | string
+		}
+		// +usage=The kubernetes version to use for .Capabilities.KubeVersion
+		kubeVersion?: string
+		// +usage=The extra api versions to use for .Capabilities.APIVersions
+		apiVersions: *[] | [...string]
+		// +usage=Whether to include the crds of the chart in the manifests
+		includeCRDs: *true | bool
+		// +usage=Whether to fail on missing values in templates
+		strict: *false | bool
+	}
+
+	// +usage=The rendered manifests of the chart
+	$returns?: [...{...}]
+}
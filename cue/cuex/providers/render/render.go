@@ -0,0 +1,240 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	_ "embed"
+
+	"github.com/Masterminds/sprig/v3"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubevela/pkg/cue/cuex/providers"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/util/runtime"
+)
+
+// HelmChartDir the directory of the local charts that can be rendered by
+// HelmTemplate. Rendering charts is disabled if empty.
+var HelmChartDir = ""
+
+// goTemplateFuncs the sprig functions without the ones reading the
+// environment variables. Like helm, getHostByName is stubbed to return empty
+// without looking up the dns.
+var goTemplateFuncs = func() texttemplate.FuncMap {
+	funcs := sprig.TxtFuncMap()
+	delete(funcs, "env")
+	delete(funcs, "expandenv")
+	funcs["getHostByName"] = func(string) string { return "" }
+	return funcs
+}()
+
+// GoTemplateVars is the vars for rendering go template
+type GoTemplateVars struct {
+	Template   string `json:"template"`
+	Data       any    `json:"data"`
+	MissingKey string `json:"missingKey"`
+	Delims     *struct {
+		Left  string `json:"left"`
+		Right string `json:"right"`
+	} `json:"delims,omitempty"`
+}
+
+// GoTemplateParams is the params for rendering go template
+type GoTemplateParams providers.Params[GoTemplateVars]
+
+// GoTemplateReturns is the returns for rendering go template
+type GoTemplateReturns providers.Returns[string]
+
+// GoTemplate renders the go template with the given data
+func GoTemplate(_ context.Context, goTemplateParams *GoTemplateParams) (*GoTemplateReturns, error) {
+	params := goTemplateParams.Params
+	tmpl := texttemplate.New("template").Funcs(goTemplateFuncs)
+	if params.MissingKey != "" {
+		tmpl = tmpl.Option("missingkey=" + params.MissingKey)
+	}
+	if params.Delims != nil {
+		tmpl = tmpl.Delims(params.Delims.Left, params.Delims.Right)
+	}
+	tmpl, err := tmpl.Parse(params.Template)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err = tmpl.Execute(buf, params.Data); err != nil {
+		return nil, err
+	}
+	return &GoTemplateReturns{Returns: buf.String()}, nil
+}
+
+// HelmTemplateVars is the vars for rendering helm chart
+type HelmTemplateVars struct {
+	Chart   string         `json:"chart"`
+	Values  map[string]any `json:"values"`
+	Release struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"release"`
+	KubeVersion string   `json:"kubeVersion,omitempty"`
+	APIVersions []string `json:"apiVersions,omitempty"`
+	IncludeCRDs bool     `json:"includeCRDs"`
+	Strict      bool     `json:"strict"`
+}
+
+// HelmTemplateParams is the params for rendering helm chart
+type HelmTemplateParams providers.Params[HelmTemplateVars]
+
+// HelmTemplateReturns is the returns for rendering helm chart
+type HelmTemplateReturns providers.Returns[[]*unstructured.Unstructured]
+
+// resolveChartPath resolve the chart path relative to HelmChartDir and reject
+// the ones outside it
+func resolveChartPath(chart string) (string, error) {
+	if HelmChartDir == "" {
+		return "", fmt.Errorf("rendering helm chart is disabled as the chart directory is not set")
+	}
+	dir, err := filepath.EvalSymlinks(HelmChartDir)
+	if err != nil {
+		return "", fmt.Errorf("invalid chart directory %s: %w", HelmChartDir, err)
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return "", err
+	}
+	p := chart
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	if p, err = filepath.EvalSymlinks(p); err != nil {
+		return "", fmt.Errorf("failed to load chart %s: %w", chart, err)
+	}
+	if p, err = filepath.Abs(p); err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(dir, p); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("chart %s is not in the chart directory", chart)
+	}
+	return p, nil
+}
+
+// HelmTemplate renders the helm chart from local directory or tarball in
+// HelmChartDir into manifests. No cluster or repository access is made during
+// the rendering.
+func HelmTemplate(_ context.Context, helmTemplateParams *HelmTemplateParams) (*HelmTemplateReturns, error) {
+	params := helmTemplateParams.Params
+	chartPath, err := resolveChartPath(params.Chart)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart %s: %w", params.Chart, err)
+	}
+	if params.Values == nil {
+		params.Values = map[string]any{}
+	}
+	if err = chartutil.ProcessDependenciesWithMerge(ch, params.Values); err != nil {
+		return nil, err
+	}
+	caps := chartutil.DefaultCapabilities.Copy()
+	if params.KubeVersion != "" {
+		kubeVersion, err := chartutil.ParseKubeVersion(params.KubeVersion)
+		if err != nil {
+			return nil, err
+		}
+		caps.KubeVersion = *kubeVersion
+	}
+	caps.APIVersions = append(caps.APIVersions, params.APIVersions...)
+	values, err := chartutil.ToRenderValues(ch, params.Values, chartutil.ReleaseOptions{
+		Name:      params.Release.Name,
+		Namespace: params.Release.Namespace,
+		Revision:  1,
+		IsInstall: true,
+	}, caps)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := engine.Engine{Strict: params.Strict}.Render(ch, values)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []string
+	if params.IncludeCRDs {
+		for _, crd := range ch.CRDObjects() {
+			docs = append(docs, string(crd.File.Data))
+		}
+	}
+	filenames := make([]string, 0, len(rendered))
+	for filename := range rendered {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		if path.Base(filename) == "NOTES.txt" {
+			continue
+		}
+		docs = append(docs, rendered[filename])
+	}
+
+	returns := &HelmTemplateReturns{Returns: []*unstructured.Unstructured{}}
+	for _, doc := range docs {
+		manifests := releaseutil.SplitManifests(doc)
+		keys := make([]string, 0, len(manifests))
+		for k := range manifests {
+			keys = append(keys, k)
+		}
+		sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+		for _, k := range keys {
+			if strings.TrimSpace(manifests[k]) == "" {
+				continue
+			}
+			obj := &unstructured.Unstructured{Object: map[string]any{}}
+			if err = yaml.Unmarshal([]byte(manifests[k]), &obj.Object); err != nil {
+				return nil, fmt.Errorf("failed to parse rendered manifest: %w", err)
+			}
+			if len(obj.Object) > 0 {
+				returns.Returns = append(returns.Returns, obj)
+			}
+		}
+	}
+	return returns, nil
+}
+
+// ProviderName .
+const ProviderName = "render"
+
+//go:embed render.cue
+var template string
+
+// Package .
+var Package = runtime.Must(cuexruntime.NewInternalPackage(ProviderName, template, map[string]cuexruntime.ProviderFn{
+	"goTemplate":   cuexruntime.GenericProviderFn[GoTemplateParams, GoTemplateReturns](GoTemplate),
+	"helmTemplate": cuexruntime.GenericProviderFn[HelmTemplateParams, HelmTemplateReturns](HelmTemplate),
+}))
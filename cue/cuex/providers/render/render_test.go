@@ -0,0 +1,165 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/kubevela/pkg/cue/cuex/providers/render"
+)
+
+func TestGoTemplate(t *testing.T) {
+	ctx := context.Background()
+	params := &render.GoTemplateParams{}
+	params.Params.Template = `{{ .name | upper }}:{{ .port | default 80 }}`
+	params.Params.Data = map[string]any{"name": "web"}
+	ret, err := render.GoTemplate(ctx, params)
+	require.NoError(t, err)
+	require.Equal(t, "WEB:80", ret.Returns)
+
+	params.Params.Template = `<< .name >>`
+	params.Params.Delims = &struct {
+		Left  string `json:"left"`
+		Right string `json:"right"`
+	}{Left: "<<", Right: ">>"}
+	ret, err = render.GoTemplate(ctx, params)
+	require.NoError(t, err)
+	require.Equal(t, "web", ret.Returns)
+
+	params.Params.Template = `<< .missing >>`
+	params.Params.MissingKey = "error"
+	_, err = render.GoTemplate(ctx, params)
+	require.Error(t, err)
+
+	params.Params.Template = `<< .name `
+	_, err = render.GoTemplate(ctx, params)
+	require.Error(t, err)
+
+	t.Setenv("RENDER_TEST_SECRET", "s3cr3tpw")
+	for _, tmpl := range []string{`<< env "RENDER_TEST_SECRET" >>`, `<< expandenv "$RENDER_TEST_SECRET" >>`} {
+		params.Params.Template = tmpl
+		_, err = render.GoTemplate(ctx, params)
+		require.ErrorContains(t, err, "not defined")
+	}
+
+	params.Params.Template = `[<< getHostByName "localhost" >>]`
+	ret, err = render.GoTemplate(ctx, params)
+	require.NoError(t, err)
+	require.Equal(t, "[]", ret.Returns)
+}
+
+func writeChart(t *testing.T, dir string) {
+	files := map[string]string{
+		"Chart.yaml":  "apiVersion: v2\nname: web\nversion: 0.1.0\n",
+		"values.yaml": "replicas: 1\nimage: nginx\n",
+		"crds/crd.yaml": `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: webs.example.com
+`,
+		"templates/_helpers.tpl": `{{- define "web.name" -}}{{ .Release.Name }}-web{{- end -}}`,
+		"templates/NOTES.txt":    "installed {{ .Release.Name }}",
+		"templates/workload.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "web.name" . }}
+  namespace: {{ .Release.Namespace }}
+spec:
+  replicas: {{ .Values.replicas }}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ include "web.name" . }}
+{{- if .Capabilities.APIVersions.Has "example.com/v1" }}
+---
+apiVersion: example.com/v1
+kind: Web
+metadata:
+  name: {{ include "web.name" . }}
+{{- end }}
+`,
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+}
+
+func TestHelmTemplate(t *testing.T) {
+	ctx := context.Background()
+	chartDir := t.TempDir()
+	dir := filepath.Join(chartDir, "web")
+	writeChart(t, dir)
+	params := &render.HelmTemplateParams{}
+	params.Params.Chart = "web"
+	_, err := render.HelmTemplate(ctx, params)
+	require.ErrorContains(t, err, "disabled")
+
+	defer func() { render.HelmChartDir = "" }()
+	render.HelmChartDir = chartDir
+	params.Params.Values = map[string]any{"replicas": 3}
+	params.Params.Release.Name = "test"
+	params.Params.Release.Namespace = "prod"
+	params.Params.IncludeCRDs = true
+	ret, err := render.HelmTemplate(ctx, params)
+	require.NoError(t, err)
+	require.Len(t, ret.Returns, 3)
+	require.Equal(t, "CustomResourceDefinition", ret.Returns[0].GetKind())
+	require.Equal(t, "Deployment", ret.Returns[1].GetKind())
+	require.Equal(t, "test-web", ret.Returns[1].GetName())
+	require.Equal(t, "prod", ret.Returns[1].GetNamespace())
+	require.Equal(t, float64(3), ret.Returns[1].Object["spec"].(map[string]any)["replicas"])
+	require.Equal(t, "Service", ret.Returns[2].GetKind())
+
+	ch, err := loader.Load(dir)
+	require.NoError(t, err)
+	tarball, err := chartutil.Save(ch, chartDir)
+	require.NoError(t, err)
+	params.Params.Chart = tarball
+	params.Params.IncludeCRDs = false
+	params.Params.APIVersions = []string{"example.com/v1"}
+	params.Params.KubeVersion = "v1.30.0"
+	ret, err = render.HelmTemplate(ctx, params)
+	require.NoError(t, err)
+	require.Len(t, ret.Returns, 3)
+	require.Equal(t, "Web", ret.Returns[2].GetKind())
+
+	params.Params.KubeVersion = "bad"
+	_, err = render.HelmTemplate(ctx, params)
+	require.Error(t, err)
+	params.Params.Chart = filepath.Join(dir, "not-exist")
+	_, err = render.HelmTemplate(ctx, params)
+	require.Error(t, err)
+
+	outside := filepath.Join(t.TempDir(), "outside")
+	writeChart(t, outside)
+	require.NoError(t, os.Symlink(outside, filepath.Join(chartDir, "link")))
+	for _, chart := range []string{outside, "../" + filepath.Base(filepath.Dir(outside)) + "/outside", "link", "/etc/passwd"} {
+		params.Params.Chart = chart
+		_, err = render.HelmTemplate(ctx, params)
+		require.ErrorContains(t, err, "not in the chart directory", chart)
+	}
+}
@@ -0,0 +1,53 @@
+package secret
+
+#Get: {
+	#do:       "get"
+	#provider: "secret"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The namespace of the secret
+		namespace: *"default" | string
+		// +usage=The name of the secret
+		name: string
+		// +usage=The key to read from the secret, if set, the decoded value will be returned in value
+		key?: string
+	}
+
+	// +usage=The result of this action, all returned values are marked as sensitive and will be redacted in errors and outputs
+	$returns?: {
+		// +usage=The decoded data of the secret
+		data: [string]: string
+		// +usage=The decoded value of the given key
+		value?: string
+	}
+}
+
+#GetConfig: {
+	#do:       "getConfig"
+	#provider: "secret"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The cluster to use
+		cluster: *"" | string
+		// +usage=The namespace of the config map
+		namespace: *"default" | string
+		// +usage=The name of the config map
+		name: string
+		// +usage=The key to read from the config map, if set, the value will be returned in value
+		key?: string
+		// +usage=Whether to mark the returned values as sensitive
+		sensitive: *false | bool
+	}
+
+	// +usage=The result of this action, will be filled with the data and decoded binary data of the config map
+	$returns?: {
+		// +usage=The data of the config map
+		data: [string]: string
+		// +usage=The value of the given key
+		value?: string
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret
+
+import (
+	"context"
+	"fmt"
+
+	_ "embed"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/pkg/cue/cuex/providers"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/multicluster"
+	"github.com/kubevela/pkg/util/maps"
+	"github.com/kubevela/pkg/util/runtime"
+)
+
+// ReadVars is the vars for reading secret or config map
+type ReadVars struct {
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Key       string `json:"key,omitempty"`
+	Sensitive *bool  `json:"sensitive,omitempty"`
+}
+
+// ReadResult is the decoded data read from secret or config map
+type ReadResult struct {
+	Data  map[string]string `json:"data"`
+	Value *string           `json:"value,omitempty"`
+}
+
+// ReadParams is the params for reading secret or config map
+type ReadParams providers.Params[ReadVars]
+
+// ReadReturns is the returns for reading secret or config map
+type ReadReturns providers.Returns[ReadResult]
+
+func newReadReturns(ctx context.Context, params ReadVars, kind string, data map[string]string, sensitive bool) (*ReadReturns, error) {
+	if sensitive {
+		cuexruntime.MarkSensitive(ctx, maps.Values(data)...)
+	}
+	returns := &ReadReturns{Returns: ReadResult{Data: data}}
+	if params.Key != "" {
+		val, found := data[params.Key]
+		if !found {
+			return nil, fmt.Errorf("key %s not found in %s %s/%s", params.Key, kind, params.Namespace, params.Name)
+		}
+		returns.Returns.Value = &val
+	}
+	return returns, nil
+}
+
+// Get reads the secret and returns its decoded data, the values are marked
+// as sensitive
+func Get(ctx context.Context, readParams *ReadParams) (*ReadReturns, error) {
+	params := readParams.Params
+	ctx = multicluster.WithCluster(ctx, params.Cluster)
+	secret := &corev1.Secret{}
+	if err := cuexruntime.GetKubeClient(ctx).Get(ctx, client.ObjectKey{Namespace: params.Namespace, Name: params.Name}, secret); err != nil {
+		return nil, err
+	}
+	data := map[string]string{}
+	for k, v := range secret.Data {
+		data[k] = string(v)
+	}
+	return newReadReturns(ctx, params, "secret", data, true)
+}
+
+// GetConfig reads the config map and returns its data together with the
+// decoded binary data. The values are marked as sensitive only if enabled.
+func GetConfig(ctx context.Context, readParams *ReadParams) (*ReadReturns, error) {
+	params := readParams.Params
+	ctx = multicluster.WithCluster(ctx, params.Cluster)
+	cm := &corev1.ConfigMap{}
+	if err := cuexruntime.GetKubeClient(ctx).Get(ctx, client.ObjectKey{Namespace: params.Namespace, Name: params.Name}, cm); err != nil {
+		return nil, err
+	}
+	data := map[string]string{}
+	for k, v := range cm.BinaryData {
+		data[k] = string(v)
+	}
+	for k, v := range cm.Data {
+		data[k] = v
+	}
+	return newReadReturns(ctx, params, "configmap", data, params.Sensitive != nil && *params.Sensitive)
+}
+
+// ProviderName .
+const ProviderName = "secret"
+
+//go:embed secret.cue
+var template string
+
+// Package .
+var Package = runtime.Must(cuexruntime.NewInternalPackage(ProviderName, template, map[string]cuexruntime.ProviderFn{
+	"get":       cuexruntime.GenericProviderFn[ReadParams, ReadReturns](Get),
+	"getConfig": cuexruntime.GenericProviderFn[ReadParams, ReadReturns](GetConfig),
+}))
@@ -0,0 +1,71 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secret_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/cue/cuex/providers/secret"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/util/singleton"
+)
+
+func TestSecret(t *testing.T) {
+	cli := fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+			Data:       map[string][]byte{"password": []byte("p@ss")},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "conf", Namespace: "default"},
+			Data:       map[string]string{"host": "db.local"},
+			BinaryData: map[string][]byte{"cert": []byte("cert-data")},
+		},
+	).Build()
+	singleton.KubeClient.Set(cli)
+	ctx := cuexruntime.WithSensitiveValues(context.Background())
+
+	ret, err := secret.Get(ctx, &secret.ReadParams{Params: secret.ReadVars{Namespace: "default", Name: "db", Key: "password"}})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"password": "p@ss"}, ret.Returns.Data)
+	require.Equal(t, "p@ss", *ret.Returns.Value)
+	require.Equal(t, "password=<redacted>", cuexruntime.Redact(ctx, "password=p@ss"))
+
+	_, err = secret.Get(ctx, &secret.ReadParams{Params: secret.ReadVars{Namespace: "default", Name: "db", Key: "user"}})
+	require.Error(t, err)
+	_, err = secret.Get(ctx, &secret.ReadParams{Params: secret.ReadVars{Namespace: "default", Name: "none"}})
+	require.Error(t, err)
+
+	ret, err = secret.GetConfig(ctx, &secret.ReadParams{Params: secret.ReadVars{Namespace: "default", Name: "conf"}})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"host": "db.local", "cert": "cert-data"}, ret.Returns.Data)
+	require.Equal(t, "db.local", cuexruntime.Redact(ctx, "db.local"))
+
+	ret, err = secret.GetConfig(ctx, &secret.ReadParams{Params: secret.ReadVars{Namespace: "default", Name: "conf", Key: "host", Sensitive: ptr.To(true)}})
+	require.NoError(t, err)
+	require.Equal(t, "db.local", *ret.Returns.Value)
+	require.Equal(t, "<redacted>", cuexruntime.Redact(ctx, "db.local"))
+	_, err = secret.GetConfig(ctx, &secret.ReadParams{Params: secret.ReadVars{Namespace: "default", Name: "none"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,141 @@
+package time
+
+#Now: {
+	#do:       "now"
+	#provider: "time"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The layout to format the time, either a go layout or the name of a go layout constant such as RFC3339, DateTime, DateOnly
+		layout: *"RFC3339" | string
+		// +usage=The IANA location to format the time in, such as UTC or Asia/Shanghai, the local location is used if not set
+		location?: string
+	}
+
+	// +usage=The formatted current time
+	$returns?: string
+}
+
+#Format: {
+	#do:       "format"
+	#provider: "time"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The time to format, either a RFC3339 string or unix seconds
+		time: string | number
+		// +usage=The layout to format the time, either a go layout or the name of a go layout constant such as RFC3339, DateTime, DateOnly
+		layout: *"RFC3339" | string
+		// +usage=The IANA location to format the time in, the location of the input is kept if not set
+		location?: string
+	}
+
+	// +usage=The formatted time
+	$returns?: string
+}
+
+#Parse: {
+	#do:       "parse"
+	#provider: "time"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The string to parse
+		value: string
+		// +usage=The layout of the value, either a go layout or the name of a go layout constant such as RFC3339, DateTime, DateOnly
+		layout: *"RFC3339" | string
+		// +usage=The IANA location to use when the value has no time zone, UTC is used if not set
+		location?: string
+	}
+
+	// +usage=The parsed time
+	$returns?: #Time
+}
+
+#Add: {
+	#do:       "add"
+	#provider: "time"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The base time, either a RFC3339 string or unix seconds
+		time: string | number
+		// +usage=The duration to add, such as 1h30m, -15m or 90d
+		duration: string
+	}
+
+	// +usage=The result time in RFC3339
+	$returns?: string
+}
+
+#Sub: {
+	#do:       "sub"
+	#provider: "time"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The time to subtract from, either a RFC3339 string or unix seconds
+		time: string | number
+		// +usage=The time to subtract, either a RFC3339 string or unix seconds
+		other: string | number
+	}
+
+	// +usage=The duration of time - other
+	$returns?: #Duration
+}
+
+#ParseDuration: {
+	#do:       "parseDuration"
+	#provider: "time"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The duration to parse, such as 1h30m, -15m or 90d
+		duration: string
+	}
+
+	// +usage=The parsed duration
+	$returns?: #Duration
+}
+
+#CronNext: {
+	#do:       "cronNext"
+	#provider: "time"
+
+	// +usage=The params of this action
+	$params: {
+		// +usage=The standard 5-field cron expression or descriptor such as @daily
+		schedule: string
+		// +usage=The time to calculate from, either a RFC3339 string or unix seconds, the current time is used if not set
+		from?: string | number
+		// +usage=The number of next firings to calculate
+		count: *1 | int & >0 & <=1000
+		// +usage=The IANA location to evaluate the schedule in, the location of from is used if not set
+		location?: string
+	}
+
+	// +usage=The next firing times in RFC3339
+	$returns?: [...string]
+}
+
+#Time: {
+	rfc3339:   string
+	unix:      int
+	unixMilli: int
+	year:      int
+	month:     int
+	day:       int
+	hour:      int
+	minute:    int
+	second:    int
+	weekday:   string
+}
+
+#Duration: {
+	// +usage=The canonical go representation of the duration
+	duration: string
+	// +usage=The duration in seconds
+	seconds: number
+	// +usage=The duration in milliseconds
+	milliseconds: int
+}
@@ -0,0 +1,338 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package time
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	_ "embed"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/kubevela/pkg/cue/cuex/providers"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/util/runtime"
+)
+
+var layouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"UnixDate":    time.UnixDate,
+	"RubyDate":    time.RubyDate,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"Kitchen":     time.Kitchen,
+	"Stamp":       time.Stamp,
+	"DateTime":    time.DateTime,
+	"DateOnly":    time.DateOnly,
+	"TimeOnly":    time.TimeOnly,
+}
+
+func getLayout(layout string) string {
+	if l, ok := layouts[layout]; ok {
+		return l
+	}
+	if layout == "" {
+		return time.RFC3339
+	}
+	return layout
+}
+
+func inLocation(t time.Time, location string) (time.Time, error) {
+	if location == "" {
+		return t, nil
+	}
+	loc, err := time.LoadLocation(location)
+	if err != nil {
+		return t, err
+	}
+	return t.In(loc), nil
+}
+
+// toTime convert RFC3339 string or unix seconds into time
+func toTime(v any) (time.Time, error) {
+	switch val := v.(type) {
+	case string:
+		return time.Parse(time.RFC3339, val)
+	case float64:
+		sec, frac := int64(val), val-float64(int64(val))
+		return time.Unix(sec, int64(frac*float64(time.Second))).UTC(), nil
+	default:
+		return time.Time{}, fmt.Errorf("invalid time %v, must be RFC3339 string or unix seconds", v)
+	}
+}
+
+var daysPattern = regexp.MustCompile(`^([-+]?)(\d+)d(.*)$`)
+
+// parseDuration extends time.ParseDuration with the d unit for days
+func parseDuration(s string) (time.Duration, error) {
+	match := daysPattern.FindStringSubmatch(s)
+	if match == nil {
+		return time.ParseDuration(s)
+	}
+	days, err := strconv.ParseInt(match[2], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	d := time.Duration(days) * 24 * time.Hour
+	if match[3] != "" {
+		rest, err := time.ParseDuration(match[3])
+		if err != nil {
+			return 0, fmt.Errorf("time: invalid duration %q", s)
+		}
+		d += rest
+	}
+	if match[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// FormatVars is the vars for formatting time
+type FormatVars struct {
+	Time     any    `json:"time,omitempty"`
+	Layout   string `json:"layout,omitempty"`
+	Location string `json:"location,omitempty"`
+}
+
+// FormatParams is the params for formatting time
+type FormatParams providers.Params[FormatVars]
+
+// StringReturns is the returns for formatted time
+type StringReturns providers.Returns[string]
+
+// Now returns the current time of the clock in the context
+func Now(ctx context.Context, params *FormatParams) (*StringReturns, error) {
+	t, err := inLocation(cuexruntime.GetClock(ctx).Now(), params.Params.Location)
+	if err != nil {
+		return nil, err
+	}
+	return &StringReturns{Returns: t.Format(getLayout(params.Params.Layout))}, nil
+}
+
+// Format formats the time with the given layout
+func Format(_ context.Context, params *FormatParams) (*StringReturns, error) {
+	t, err := toTime(params.Params.Time)
+	if err != nil {
+		return nil, err
+	}
+	if t, err = inLocation(t, params.Params.Location); err != nil {
+		return nil, err
+	}
+	return &StringReturns{Returns: t.Format(getLayout(params.Params.Layout))}, nil
+}
+
+// ParseVars is the vars for parsing time
+type ParseVars struct {
+	Value    string `json:"value"`
+	Layout   string `json:"layout,omitempty"`
+	Location string `json:"location,omitempty"`
+}
+
+// ParseParams is the params for parsing time
+type ParseParams providers.Params[ParseVars]
+
+// Time is the structured representation of time
+type Time struct {
+	RFC3339   string `json:"rfc3339"`
+	Unix      int64  `json:"unix"`
+	UnixMilli int64  `json:"unixMilli"`
+	Year      int    `json:"year"`
+	Month     int    `json:"month"`
+	Day       int    `json:"day"`
+	Hour      int    `json:"hour"`
+	Minute    int    `json:"minute"`
+	Second    int    `json:"second"`
+	Weekday   string `json:"weekday"`
+}
+
+// TimeReturns is the returns for parsed time
+type TimeReturns providers.Returns[Time]
+
+// Parse parses the string with the given layout
+func Parse(_ context.Context, params *ParseParams) (*TimeReturns, error) {
+	loc := time.UTC
+	if params.Params.Location != "" {
+		var err error
+		if loc, err = time.LoadLocation(params.Params.Location); err != nil {
+			return nil, err
+		}
+	}
+	t, err := time.ParseInLocation(getLayout(params.Params.Layout), params.Params.Value, loc)
+	if err != nil {
+		return nil, err
+	}
+	return &TimeReturns{Returns: Time{
+		RFC3339:   t.Format(time.RFC3339),
+		Unix:      t.Unix(),
+		UnixMilli: t.UnixMilli(),
+		Year:      t.Year(),
+		Month:     int(t.Month()),
+		Day:       t.Day(),
+		Hour:      t.Hour(),
+		Minute:    t.Minute(),
+		Second:    t.Second(),
+		Weekday:   t.Weekday().String(),
+	}}, nil
+}
+
+// AddVars is the vars for adding duration to time
+type AddVars struct {
+	Time     any    `json:"time"`
+	Duration string `json:"duration"`
+}
+
+// AddParams is the params for adding duration to time
+type AddParams providers.Params[AddVars]
+
+// Add adds the duration to the time
+func Add(_ context.Context, params *AddParams) (*StringReturns, error) {
+	t, err := toTime(params.Params.Time)
+	if err != nil {
+		return nil, err
+	}
+	d, err := parseDuration(params.Params.Duration)
+	if err != nil {
+		return nil, err
+	}
+	return &StringReturns{Returns: t.Add(d).Format(time.RFC3339)}, nil
+}
+
+// Duration is the structured representation of duration
+type Duration struct {
+	Duration     string  `json:"duration"`
+	Seconds      float64 `json:"seconds"`
+	Milliseconds int64   `json:"milliseconds"`
+}
+
+// DurationReturns is the returns for duration
+type DurationReturns providers.Returns[Duration]
+
+func newDuration(d time.Duration) *DurationReturns {
+	return &DurationReturns{Returns: Duration{
+		Duration:     d.String(),
+		Seconds:      d.Seconds(),
+		Milliseconds: d.Milliseconds(),
+	}}
+}
+
+// SubVars is the vars for subtracting time
+type SubVars struct {
+	Time  any `json:"time"`
+	Other any `json:"other"`
+}
+
+// SubParams is the params for subtracting time
+type SubParams providers.Params[SubVars]
+
+// Sub returns the duration between two times
+func Sub(_ context.Context, params *SubParams) (*DurationReturns, error) {
+	t, err := toTime(params.Params.Time)
+	if err != nil {
+		return nil, err
+	}
+	other, err := toTime(params.Params.Other)
+	if err != nil {
+		return nil, err
+	}
+	return newDuration(t.Sub(other)), nil
+}
+
+// DurationVars is the vars for parsing duration
+type DurationVars struct {
+	Duration string `json:"duration"`
+}
+
+// DurationParams is the params for parsing duration
+type DurationParams providers.Params[DurationVars]
+
+// ParseDuration parses the duration string
+func ParseDuration(_ context.Context, params *DurationParams) (*DurationReturns, error) {
+	d, err := parseDuration(params.Params.Duration)
+	if err != nil {
+		return nil, err
+	}
+	return newDuration(d), nil
+}
+
+// CronVars is the vars for calculating cron firings
+type CronVars struct {
+	Schedule string `json:"schedule"`
+	From     any    `json:"from,omitempty"`
+	Count    int    `json:"count,omitempty"`
+	Location string `json:"location,omitempty"`
+}
+
+// CronParams is the params for calculating cron firings
+type CronParams providers.Params[CronVars]
+
+// CronReturns is the returns for cron firings
+type CronReturns providers.Returns[[]string]
+
+// CronNext calculates the next firings of the cron schedule
+func CronNext(ctx context.Context, params *CronParams) (*CronReturns, error) {
+	schedule, err := cron.ParseStandard(params.Params.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	from := cuexruntime.GetClock(ctx).Now()
+	if params.Params.From != nil {
+		if from, err = toTime(params.Params.From); err != nil {
+			return nil, err
+		}
+	}
+	if from, err = inLocation(from, params.Params.Location); err != nil {
+		return nil, err
+	}
+	count := params.Params.Count
+	if count <= 0 {
+		count = 1
+	}
+	firings := make([]string, 0, count)
+	for t := from; len(firings) < count; {
+		if t = schedule.Next(t); t.IsZero() {
+			break
+		}
+		firings = append(firings, t.Format(time.RFC3339))
+	}
+	return &CronReturns{Returns: firings}, nil
+}
+
+// ProviderName .
+const ProviderName = "time"
+
+//go:embed time.cue
+var template string
+
+// Package .
+var Package = runtime.Must(cuexruntime.NewInternalPackage(ProviderName, template, map[string]cuexruntime.ProviderFn{
+	"now":           cuexruntime.GenericProviderFn[FormatParams, StringReturns](Now),
+	"format":        cuexruntime.GenericProviderFn[FormatParams, StringReturns](Format),
+	"parse":         cuexruntime.GenericProviderFn[ParseParams, TimeReturns](Parse),
+	"add":           cuexruntime.GenericProviderFn[AddParams, StringReturns](Add),
+	"sub":           cuexruntime.GenericProviderFn[SubParams, DurationReturns](Sub),
+	"parseDuration": cuexruntime.GenericProviderFn[DurationParams, DurationReturns](ParseDuration),
+	"cronNext":      cuexruntime.GenericProviderFn[CronParams, CronReturns](CronNext),
+}))
@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package time_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	velatime "github.com/kubevela/pkg/cue/cuex/providers/time"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+)
+
+func TestNowAndFormat(t *testing.T) {
+	now := time.Date(2023, 5, 1, 10, 30, 0, 0, time.UTC)
+	ctx := cuexruntime.WithClock(context.Background(), clocktesting.NewFakePassiveClock(now))
+	ret, err := velatime.Now(ctx, &velatime.FormatParams{Params: velatime.FormatVars{Layout: "DateTime"}})
+	require.NoError(t, err)
+	require.Equal(t, "2023-05-01 10:30:00", ret.Returns)
+
+	ret, err = velatime.Now(ctx, &velatime.FormatParams{Params: velatime.FormatVars{Location: "Asia/Shanghai"}})
+	require.NoError(t, err)
+	require.Equal(t, "2023-05-01T18:30:00+08:00", ret.Returns)
+
+	_, err = velatime.Now(ctx, &velatime.FormatParams{Params: velatime.FormatVars{Location: "Unknown/Place"}})
+	require.Error(t, err)
+
+	ret, err = velatime.Format(ctx, &velatime.FormatParams{Params: velatime.FormatVars{Time: float64(now.Unix()), Layout: "2006/01/02"}})
+	require.NoError(t, err)
+	require.Equal(t, "2023/05/01", ret.Returns)
+
+	ret, err = velatime.Format(ctx, &velatime.FormatParams{Params: velatime.FormatVars{Time: "2023-05-01T10:30:00Z", Layout: "Kitchen"}})
+	require.NoError(t, err)
+	require.Equal(t, "10:30AM", ret.Returns)
+
+	_, err = velatime.Format(ctx, &velatime.FormatParams{Params: velatime.FormatVars{Time: true}})
+	require.Error(t, err)
+}
+
+func TestParse(t *testing.T) {
+	ret, err := velatime.Parse(context.Background(), &velatime.ParseParams{Params: velatime.ParseVars{
+		Value: "2023-05-01 10:30:05", Layout: "DateTime", Location: "Asia/Shanghai",
+	}})
+	require.NoError(t, err)
+	require.Equal(t, velatime.Time{
+		RFC3339:   "2023-05-01T10:30:05+08:00",
+		Unix:      1682908205,
+		UnixMilli: 1682908205000,
+		Year:      2023,
+		Month:     5,
+		Day:       1,
+		Hour:      10,
+		Minute:    30,
+		Second:    5,
+		Weekday:   "Monday",
+	}, ret.Returns)
+
+	_, err = velatime.Parse(context.Background(), &velatime.ParseParams{Params: velatime.ParseVars{Value: "yesterday"}})
+	require.Error(t, err)
+}
+
+func TestDuration(t *testing.T) {
+	ctx := context.Background()
+	ret, err := velatime.Add(ctx, &velatime.AddParams{Params: velatime.AddVars{Time: "2023-05-01T10:30:00Z", Duration: "90d12h"}})
+	require.NoError(t, err)
+	require.Equal(t, "2023-07-30T22:30:00Z", ret.Returns)
+
+	ret, err = velatime.Add(ctx, &velatime.AddParams{Params: velatime.AddVars{Time: "2023-05-01T10:30:00Z", Duration: "-1d"}})
+	require.NoError(t, err)
+	require.Equal(t, "2023-04-30T10:30:00Z", ret.Returns)
+
+	_, err = velatime.Add(ctx, &velatime.AddParams{Params: velatime.AddVars{Time: "2023-05-01T10:30:00Z", Duration: "1dx"}})
+	require.Error(t, err)
+
+	d, err := velatime.Sub(ctx, &velatime.SubParams{Params: velatime.SubVars{Time: "2023-05-01T12:00:00Z", Other: "2023-05-01T10:30:00Z"}})
+	require.NoError(t, err)
+	require.Equal(t, velatime.Duration{Duration: "1h30m0s", Seconds: 5400, Milliseconds: 5400000}, d.Returns)
+
+	d, err = velatime.ParseDuration(ctx, &velatime.DurationParams{Params: velatime.DurationVars{Duration: "1500ms"}})
+	require.NoError(t, err)
+	require.Equal(t, velatime.Duration{Duration: "1.5s", Seconds: 1.5, Milliseconds: 1500}, d.Returns)
+
+	_, err = velatime.ParseDuration(ctx, &velatime.DurationParams{Params: velatime.DurationVars{Duration: "soon"}})
+	require.Error(t, err)
+}
+
+func TestCronNext(t *testing.T) {
+	ctx := context.Background()
+	ret, err := velatime.CronNext(ctx, &velatime.CronParams{Params: velatime.CronVars{
+		Schedule: "*/15 * * * *", From: "2023-05-01T10:20:00Z", Count: 3,
+	}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"2023-05-01T10:30:00Z", "2023-05-01T10:45:00Z", "2023-05-01T11:00:00Z"}, ret.Returns)
+
+	ret, err = velatime.CronNext(ctx, &velatime.CronParams{Params: velatime.CronVars{
+		Schedule: "@daily", From: "2023-05-01T10:20:00Z", Location: "Asia/Shanghai",
+	}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"2023-05-02T00:00:00+08:00"}, ret.Returns)
+
+	_, err = velatime.CronNext(ctx, &velatime.CronParams{Params: velatime.CronVars{Schedule: "every day"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,52 @@
+package topology
+
+#SubResources: {
+	#do:       "subResources"
+	#provider: "topology"
+
+	// +usage=The params of this action
+	$params: #TopologyParams
+
+	// +usage=The sub resources of the resource, each one contains its own children
+	$returns?: [...#SubResource]
+}
+
+#PeerResources: {
+	#do:       "peerResources"
+	#provider: "topology"
+
+	// +usage=The params of this action
+	$params: #TopologyParams
+
+	// +usage=The peer resources of the resource
+	$returns?: [...#ResourceIdentifier]
+}
+
+#TopologyParams: {
+	// +usage=The cluster to use
+	cluster: *"" | string
+	// +usage=The resource to query
+	resource: #ResourceIdentifier
+	// +usage=The topology rules in CUE, the queried resource is available as context.data
+	rules: string
+}
+
+#ResourceIdentifier: {
+	// +usage=The api version of the resource, either apiVersion & kind or group & resource is required
+	apiVersion?: string
+	// +usage=The kind of the resource
+	kind?: string
+	// +usage=The group of the resource
+	group?: string
+	// +usage=The plural resource name, such as deployments
+	resource?: string
+	// +usage=The name of the resource
+	name: string
+	// +usage=The namespace of the resource
+	namespace?: string
+}
+
+#SubResource: {
+	#ResourceIdentifier
+	children: [...#SubResource]
+}
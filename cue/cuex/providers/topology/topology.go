@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topology
+
+import (
+	"context"
+
+	_ "embed"
+
+	"github.com/kubevela/pkg/cue/cuex/providers"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/multicluster"
+	"github.com/kubevela/pkg/util/k8s"
+	"github.com/kubevela/pkg/util/resourcetopology/engine"
+	"github.com/kubevela/pkg/util/runtime"
+)
+
+// ResourceVars is the vars for querying resource topology
+type ResourceVars struct {
+	Cluster  string                 `json:"cluster"`
+	Resource k8s.ResourceIdentifier `json:"resource"`
+	Rules    string                 `json:"rules"`
+}
+
+// ResourceParams is the params for querying resource topology
+type ResourceParams providers.Params[ResourceVars]
+
+// SubResourcesReturns is the returns for sub resources
+type SubResourcesReturns providers.Returns[[]engine.SubResource]
+
+// PeerResourcesReturns is the returns for peer resources
+type PeerResourcesReturns providers.Returns[[]k8s.ResourceIdentifier]
+
+// Provider query the resource topology with the rules compiled by Compile
+type Provider struct {
+	Compile engine.CompileFn
+}
+
+// SubResources get the sub resources of the given resource with the rules
+func (in *Provider) SubResources(ctx context.Context, params *ResourceParams) (*SubResourcesReturns, error) {
+	ctx = multicluster.WithCluster(ctx, params.Params.Cluster)
+	subs, err := engine.New(params.Params.Rules, in.Compile).GetSubResources(ctx, params.Params.Resource)
+	if err != nil {
+		return nil, err
+	}
+	return &SubResourcesReturns{Returns: subs}, nil
+}
+
+// PeerResources get the peer resources of the given resource with the rules
+func (in *Provider) PeerResources(ctx context.Context, params *ResourceParams) (*PeerResourcesReturns, error) {
+	ctx = multicluster.WithCluster(ctx, params.Params.Cluster)
+	peers, err := engine.New(params.Params.Rules, in.Compile).GetPeerResources(ctx, params.Params.Resource)
+	if err != nil {
+		return nil, err
+	}
+	return &PeerResourcesReturns{Returns: peers}, nil
+}
+
+// ProviderName .
+const ProviderName = "topology"
+
+//go:embed topology.cue
+var template string
+
+// NewPackage create the topology package whose rules are compiled by the
+// given function, which is usually the compiler that the package is
+// registered to
+func NewPackage(compile engine.CompileFn) cuexruntime.Package {
+	prd := &Provider{Compile: compile}
+	return runtime.Must(cuexruntime.NewInternalPackage(ProviderName, template, map[string]cuexruntime.ProviderFn{
+		"subResources":  cuexruntime.GenericProviderFn[ResourceParams, SubResourcesReturns](prd.SubResources),
+		"peerResources": cuexruntime.GenericProviderFn[ResourceParams, PeerResourcesReturns](prd.PeerResources),
+	}))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topology_test
+
+import (
+	"context"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/cue/cuex"
+	"github.com/kubevela/pkg/cue/cuex/providers/topology"
+	"github.com/kubevela/pkg/util/k8s"
+	"github.com/kubevela/pkg/util/resourcetopology"
+	"github.com/kubevela/pkg/util/singleton"
+)
+
+const rules = `
+rules: [{
+	apiVersion: "apps/v1"
+	kind:       "Deployment"
+	subResources: [{
+		apiVersion: "v1"
+		kind:       "Pod"
+		selectors: {
+			namespace:      context.data.metadata.namespace
+			ownerReference: true
+		}
+	}]
+	peerResources: [{
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		selectors: {
+			namespace: context.data.metadata.namespace
+			name:      context.data.metadata.name + "-config"
+		}
+	}]
+}]
+`
+
+func TestTopology(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{})
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "", Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	cli := fake.NewClientBuilder().WithObjects(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:            "web-pod",
+			Namespace:       "default",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}},
+		}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "web-config", Namespace: "default"}},
+	).WithRESTMapper(mapper).Build()
+	singleton.KubeClient.Set(cli)
+	singleton.RESTMapper.Set(mapper)
+	cuex.EnableExternalPackageForDefaultCompiler = false
+	ctx := context.Background()
+
+	prd := &topology.Provider{Compile: resourcetopology.Compile}
+	params := &topology.ResourceParams{}
+	params.Params.Rules = rules
+	params.Params.Resource = k8s.ResourceIdentifier{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Namespace: "default"}
+	subs, err := prd.SubResources(ctx, params)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(subs.Returns))
+	require.Equal(t, "web-pod", subs.Returns[0].Name)
+
+	peers, err := prd.PeerResources(ctx, params)
+	require.NoError(t, err)
+	require.Equal(t, []k8s.ResourceIdentifier{{APIVersion: "v1", Kind: "ConfigMap", Name: "web-config", Namespace: "default"}}, peers.Returns)
+
+	params.Params.Resource.Name = "not-found"
+	_, err = prd.SubResources(ctx, params)
+	require.Error(t, err)
+
+	compiler := cuex.NewCompilerWithDefaultInternalPackages()
+	val, err := compiler.CompileStringWithOptions(ctx, `
+		import "vela/topology"
+		parameter: rules: string
+		pods: topology.#SubResources & {
+			$params: {
+				resource: {apiVersion: "apps/v1", kind: "Deployment", name: "web", namespace: "default"}
+				rules: parameter.rules
+			}
+		}
+	`, cuex.WithExtraData("parameter", map[string]any{"rules": rules}))
+	require.NoError(t, err)
+	name, err := val.LookupPath(cue.ParsePath("pods.$returns[0].name")).String()
+	require.NoError(t, err)
+	require.Equal(t, "web-pod", name)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AppContextVersion the version of the AppContext schema
+const AppContextVersion = "v1"
+
+var (
+	// ContextSigningKey the HMAC key for signing the propagated context when
+	// sending and verifying it when receiving. Signing is disabled if empty.
+	ContextSigningKey []byte
+	// MaxPropagatedContextSize the max size in bytes of the base64 encoded
+	// propagated context header, no limit if not positive
+	MaxPropagatedContextSize = 4096
+)
+
+var (
+	// ErrPropagatedContextTooLarge the propagated context exceeds MaxPropagatedContextSize
+	ErrPropagatedContextTooLarge = errors.New("propagated context too large")
+	// ErrPropagatedContextSignature the signature of the propagated context is missing or invalid
+	ErrPropagatedContextSignature = errors.New("invalid propagated context signature")
+)
+
+// AppContext the application context propagated from cuex to the external
+// providers
+type AppContext struct {
+	Version      string `json:"version,omitempty"`
+	AppName      string `json:"appName,omitempty"`
+	Namespace    string `json:"namespace,omitempty"`
+	WorkflowStep string `json:"workflowStep,omitempty"`
+	User         string `json:"user,omitempty"`
+}
+
+// MarshalJSON marshal the AppContext with the current version if not set
+func (in AppContext) MarshalJSON() ([]byte, error) {
+	type alias AppContext
+	if in.Version == "" {
+		in.Version = AppContextVersion
+	}
+	return json.Marshal(alias(in))
+}
+
+// StartSpanWithAppContext creates a new OpenTelemetry span and attaches the
+// AppContext as baggage.
+func StartSpanWithAppContext(ctx context.Context, name string, appCtx AppContext) (context.Context, trace.Span, error) {
+	return StartSpanWithBaggage(ctx, name, appCtx)
+}
+
+// AppContext unmarshal the propagated context as AppContext. The context
+// without version is treated as the current version.
+func (p *PropagatedCtx) AppContext() (*AppContext, error) {
+	appCtx := &AppContext{}
+	if err := p.UnmarshalContext(appCtx); err != nil {
+		return nil, err
+	}
+	switch appCtx.Version {
+	case "":
+		appCtx.Version = AppContextVersion
+	case AppContextVersion:
+	default:
+		return nil, fmt.Errorf("unsupported propagated context version %q", appCtx.Version)
+	}
+	return appCtx, nil
+}
+
+func signContext(data []byte) string {
+	mac := hmac.New(sha256.New, ContextSigningKey)
+	_, _ = mac.Write(data)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func verifyContext(data []byte, signature string) bool {
+	expected, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+	mac := hmac.New(sha256.New, ContextSigningKey)
+	_, _ = mac.Write(data)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+type signingKeyFile struct {
+	path string
+}
+
+var _ pflag.Value = &signingKeyFile{}
+
+func (in *signingKeyFile) String() string { return in.path }
+
+func (in *signingKeyFile) Type() string { return "string" }
+
+func (in *signingKeyFile) Set(path string) error {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read propagated context signing key: %w", err)
+	}
+	in.path, ContextSigningKey = path, []byte(strings.TrimSpace(string(bs)))
+	return nil
+}
+
+// AddContextFlags add flags for the propagated context
+func AddContextFlags(set *pflag.FlagSet) {
+	if set.Lookup("propagated-context-max-size") == nil {
+		set.IntVarP(&MaxPropagatedContextSize, "propagated-context-max-size", "", MaxPropagatedContextSize, "max size in bytes of the encoded context header propagated to the external providers, no limit if not positive")
+	}
+	if set.Lookup("propagated-context-signing-key-file") == nil {
+		set.VarP(&signingKeyFile{}, "propagated-context-signing-key-file", "", "file of the HMAC key for signing and verifying the context propagated to the external providers")
+	}
+}
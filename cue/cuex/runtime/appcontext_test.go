@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func propagateAppContext(t *testing.T, appCtx AppContext, mutate func(http.Header)) *PropagatedCtx {
+	ctx, span, err := StartSpanWithAppContext(context.Background(), "test", appCtx)
+	require.NoError(t, err)
+	defer span.End()
+	header := http.Header{}
+	TraceHeaderPropagator{}.Inject(ctx, propagation.HeaderCarrier(header))
+	if mutate != nil {
+		mutate(header)
+	}
+	r, err := http.NewRequest(http.MethodPost, "/", nil)
+	require.NoError(t, err)
+	r.Header = header
+	pCtx, ok := GetPropagatedContext(ContextFromHeaders(r))
+	require.True(t, ok)
+	return pCtx
+}
+
+func TestAppContext(t *testing.T) {
+	appCtx := AppContext{AppName: "app", Namespace: "default", WorkflowStep: "deploy", User: "alice"}
+
+	t.Run("unsigned", func(t *testing.T) {
+		got, err := propagateAppContext(t, appCtx, nil).AppContext()
+		require.NoError(t, err)
+		appCtx.Version = AppContextVersion
+		require.Equal(t, appCtx, *got)
+		appCtx.Version = ""
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		_, err := propagateAppContext(t, AppContext{Version: "v2"}, nil).AppContext()
+		require.ErrorContains(t, err, "unsupported")
+	})
+
+	t.Run("signed", func(t *testing.T) {
+		ContextSigningKey = []byte("secret")
+		defer func() { ContextSigningKey = nil }()
+		pCtx := propagateAppContext(t, appCtx, nil)
+		require.NoError(t, pCtx.ValidationErr())
+		got, err := pCtx.AppContext()
+		require.NoError(t, err)
+		require.Equal(t, "app", got.AppName)
+
+		pCtx = propagateAppContext(t, appCtx, func(header http.Header) {
+			header.Set("X-Vela-Encoded-Context", "eyJhcHBOYW1lIjoib3RoZXIifQ==")
+		})
+		require.ErrorIs(t, pCtx.ValidationErr(), ErrPropagatedContextSignature)
+		_, err = pCtx.AppContext()
+		require.ErrorIs(t, err, ErrPropagatedContextSignature)
+
+		pCtx = propagateAppContext(t, appCtx, func(header http.Header) {
+			header.Del("X-Vela-Context-Signature")
+		})
+		require.ErrorIs(t, pCtx.ValidationErr(), ErrPropagatedContextSignature)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		large := AppContext{AppName: strings.Repeat("a", MaxPropagatedContextSize)}
+		_, span, err := StartSpanWithAppContext(context.Background(), "test", large)
+		span.End()
+		require.ErrorIs(t, err, ErrPropagatedContextTooLarge)
+
+		pCtx := propagateAppContext(t, appCtx, func(header http.Header) {
+			MaxPropagatedContextSize = 8
+		})
+		defer func() { MaxPropagatedContextSize = 4096 }()
+		require.ErrorIs(t, pCtx.ValidationErr(), ErrPropagatedContextTooLarge)
+	})
+
+	t.Run("too large after encoding", func(t *testing.T) {
+		large := AppContext{AppName: strings.Repeat("a", MaxPropagatedContextSize*3/4)}
+		_, span, err := StartSpanWithAppContext(context.Background(), "test", large)
+		span.End()
+		require.ErrorIs(t, err, ErrPropagatedContextTooLarge)
+	})
+
+	t.Run("context error", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		r, err := http.NewRequestWithContext(ctx, http.MethodPost, "/", nil)
+		require.NoError(t, err)
+		pCtx, ok := GetPropagatedContext(ContextFromHeaders(r))
+		require.True(t, ok)
+		require.NoError(t, pCtx.ValidationErr())
+		require.ErrorIs(t, pCtx.Err(), context.Canceled)
+	})
+}
+
+func TestAddContextFlags(t *testing.T) {
+	defer func() { ContextSigningKey = nil }()
+	file := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(file, []byte("secret\n"), 0600))
+	set := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddContextFlags(set)
+	AddContextFlags(set)
+	require.NoError(t, set.Parse([]string{"--propagated-context-signing-key-file=" + file}))
+	require.Equal(t, []byte("secret"), ContextSigningKey)
+	require.Error(t, set.Parse([]string{"--propagated-context-signing-key-file=" + file + ".missing"}))
+}
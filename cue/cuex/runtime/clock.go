@@ -0,0 +1,39 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"context"
+
+	"k8s.io/utils/clock"
+)
+
+const clockKey ctxKey = "Clock"
+
+// WithClock attach the time source used by providers to the context
+func WithClock(ctx context.Context, c clock.PassiveClock) context.Context {
+	return context.WithValue(ctx, clockKey, c)
+}
+
+// GetClock retrieve the time source from the context, the real clock is
+// returned if no one is attached
+func GetClock(ctx context.Context) clock.PassiveClock {
+	if c, ok := ctx.Value(clockKey).(clock.PassiveClock); ok && c != nil {
+		return c
+	}
+	return clock.RealClock{}
+}
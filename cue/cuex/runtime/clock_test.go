@@ -0,0 +1,37 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/kubevela/pkg/cue/cuex/runtime"
+)
+
+func TestClock(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, clock.RealClock{}, runtime.GetClock(ctx))
+	now := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	ctx = runtime.WithClock(ctx, clocktesting.NewFakePassiveClock(now))
+	require.Equal(t, now, runtime.GetClock(ctx).Now())
+}
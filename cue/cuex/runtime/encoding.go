@@ -0,0 +1,43 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/format"
+)
+
+// ContentTypeCUE the content type for the CUE source
+const ContentTypeCUE = "application/cue"
+
+// MarshalCUE encode the value into CUE source, the definitions, defaults and
+// incomplete values are kept
+func MarshalCUE(value cue.Value) ([]byte, error) {
+	if err := value.Err(); err != nil {
+		return nil, err
+	}
+	// references to the definitions outside the value are not always inlined
+	// without ResolveReferences, which makes the source invalid
+	// nolint:staticcheck
+	return format.Node(value.Syntax(cue.Docs(true), cue.Definitions(true), cue.Optional(true), cue.Attributes(true), cue.ResolveReferences(true)))
+}
+
+// UnmarshalCUE compile the CUE source into value within the given context
+func UnmarshalCUE(ctx *cue.Context, bs []byte) (cue.Value, error) {
+	value := ctx.CompileBytes(bs)
+	return value, value.Err()
+}
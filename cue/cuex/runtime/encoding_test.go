@@ -0,0 +1,51 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime_test
+
+import (
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/pkg/cue/cuex/runtime"
+)
+
+func TestMarshalCUE(t *testing.T) {
+	ctx := cuecontext.New()
+	v := ctx.CompileString(`
+#T: {a: int, b: *"x" | string}
+x: t: #T & {a: 1}
+x: n: int
+x: o?: string
+`)
+	bs, err := runtime.MarshalCUE(v.LookupPath(cue.ParsePath("x")))
+	require.NoError(t, err)
+	out, err := runtime.UnmarshalCUE(ctx, bs)
+	require.NoError(t, err)
+	b, err := out.LookupPath(cue.ParsePath("t.b")).String()
+	require.NoError(t, err)
+	require.Equal(t, "x", b)
+	require.Equal(t, cue.IntKind, out.LookupPath(cue.ParsePath("n")).IncompleteKind())
+	require.True(t, out.LookupPath(cue.MakePath(cue.Str("o").Optional())).Exists())
+
+	_, err = runtime.MarshalCUE(ctx.CompileString(`a: 1, a: 2`))
+	require.Error(t, err)
+	_, err = runtime.UnmarshalCUE(ctx, []byte(`a: `))
+	require.Error(t, err)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubevela/pkg/util/singleton"
+)
+
+const (
+	kubeClientKey ctxKey = "KubeClient"
+	authorizerKey ctxKey = "Authorizer"
+)
+
+// Authorizer decide if the provider function can be invoked with the context
+type Authorizer interface {
+	Authorize(ctx context.Context, provider string, fn string) error
+}
+
+// AuthorizerFunc function that implements Authorizer
+type AuthorizerFunc func(ctx context.Context, provider string, fn string) error
+
+// Authorize .
+func (f AuthorizerFunc) Authorize(ctx context.Context, provider string, fn string) error {
+	return f(ctx, provider, fn)
+}
+
+// WithAuthorizer attach the authorizer for provider function calls to the context
+func WithAuthorizer(ctx context.Context, authorizer Authorizer) context.Context {
+	return context.WithValue(ctx, authorizerKey, authorizer)
+}
+
+// GetAuthorizer retrieve the authorizer from the context, nil is returned if
+// no one is attached and all the calls are allowed
+func GetAuthorizer(ctx context.Context) Authorizer {
+	authorizer, _ := ctx.Value(authorizerKey).(Authorizer)
+	return authorizer
+}
+
+// WithKubeClient attach the kubernetes client used by providers to the
+// context, such as the client impersonating the requesting user
+func WithKubeClient(ctx context.Context, cli client.Client) context.Context {
+	return context.WithValue(ctx, kubeClientKey, cli)
+}
+
+// GetKubeClient retrieve the kubernetes client from the context, the default
+// client is returned if no one is attached
+func GetKubeClient(ctx context.Context) client.Client {
+	if cli, ok := ctx.Value(kubeClientKey).(client.Client); ok && cli != nil {
+		return cli
+	}
+	return singleton.KubeClient.Get()
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/util/singleton"
+)
+
+func TestIdentity(t *testing.T) {
+	ctx := context.Background()
+	require.Nil(t, runtime.GetAuthorizer(ctx))
+	defaultCli := fake.NewClientBuilder().Build()
+	singleton.KubeClient.Set(defaultCli)
+	require.Equal(t, defaultCli, runtime.GetKubeClient(ctx))
+
+	cli := fake.NewClientBuilder().Build()
+	ctx = runtime.WithKubeClient(ctx, cli)
+	require.Equal(t, cli, runtime.GetKubeClient(ctx))
+
+	ctx = runtime.WithAuthorizer(ctx, runtime.AuthorizerFunc(func(ctx context.Context, provider string, fn string) error {
+		if provider == "kube" {
+			return nil
+		}
+		return fmt.Errorf("forbidden")
+	}))
+	require.NoError(t, runtime.GetAuthorizer(ctx).Authorize(ctx, "kube", "get"))
+	require.Error(t, runtime.GetAuthorizer(ctx).Authorize(ctx, "http", "do"))
+}
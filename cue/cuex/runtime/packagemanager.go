@@ -21,6 +21,8 @@ import (
 	"time"
 
 	"cuelang.org/go/cue/build"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	apiruntime "k8s.io/apimachinery/pkg/runtime"
@@ -87,10 +89,18 @@ func (in *PackageManager) getExternalPackageID(pkg *v1alpha1.Package) string {
 	return "external://" + pkg.GetNamespace() + "/" + pkg.GetName()
 }
 
-func (in *PackageManager) setExternalPackage(pkg *v1alpha1.Package) {
+func (in *PackageManager) setExternalPackage(ctx context.Context, pkg *v1alpha1.Package) {
+	_, span := StartSpan(ctx, "cuex.LoadExternalPackage")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("cuex.package.namespace", pkg.Namespace),
+		attribute.String("cuex.package.name", pkg.Name),
+		attribute.String("cuex.package.path", pkg.Spec.Path),
+	)
 	_id := in.getExternalPackageID(pkg)
 	_pkg, err := NewExternalPackage(pkg)
 	if err != nil {
+		span.SetStatus(codes.Error, Redact(ctx, err.Error()))
 		klog.Errorf("parse external package %s/%s failed: %s", pkg.Namespace, pkg.Name, err.Error())
 		return
 	}
@@ -103,17 +113,25 @@ func (in *PackageManager) delExternalPackage(pkg *v1alpha1.Package) {
 }
 
 // LoadExternalPackages load all external packages
-func (in *PackageManager) LoadExternalPackages(ctx context.Context) error {
+func (in *PackageManager) LoadExternalPackages(ctx context.Context) (err error) {
+	ctx, span := StartSpan(ctx, "cuex.LoadExternalPackages")
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, Redact(ctx, err.Error()))
+		}
+		span.End()
+	}()
 	pkgs, err := singleton.DynamicClient.Get().Resource(v1alpha1.PackageGroupVersionResource).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return err
 	}
+	span.SetAttributes(attribute.Int("cuex.packages", len(pkgs.Items)))
 	for _, pkg := range pkgs.Items {
 		_pkg := &v1alpha1.Package{}
 		if err = apiruntime.DefaultUnstructuredConverter.FromUnstructured(pkg.Object, _pkg); err != nil {
 			return err
 		}
-		in.setExternalPackage(_pkg)
+		in.setExternalPackage(ctx, _pkg)
 	}
 	return nil
 }
@@ -131,12 +149,12 @@ func (in *PackageManager) ListenExternalPackages(stopCh <-chan struct{}) {
 	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			if o, err := k8s.AsStructured[v1alpha1.Package](obj.(*unstructured.Unstructured)); err == nil {
-				in.setExternalPackage(o)
+				in.setExternalPackage(context.Background(), o)
 			}
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
 			if o, err := k8s.AsStructured[v1alpha1.Package](newObj.(*unstructured.Unstructured)); err == nil {
-				in.setExternalPackage(o)
+				in.setExternalPackage(context.Background(), o)
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
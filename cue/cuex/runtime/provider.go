@@ -20,15 +20,18 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"go.opentelemetry.io/otel/propagation"
 	"io"
 	"net/http"
+	"os"
 	"strings"
 
 	"cuelang.org/go/cue"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/transport"
 
 	"github.com/kubevela/pkg/apis/cue/v1alpha1"
 	"github.com/kubevela/pkg/cue/cuex/providers"
@@ -66,24 +69,73 @@ type ExternalProviderFn struct {
 	Fn string
 }
 
-// DefaultClientInsecureSkipVerify set if the default external provider client
-// use insecure-skip-verify
-var DefaultClientInsecureSkipVerify = true
+var (
+	// DefaultClientInsecureSkipVerify set if the default external provider client
+	// use insecure-skip-verify, ignored if DefaultClientCAFile is set
+	DefaultClientInsecureSkipVerify = true
+	// DefaultClientCAFile ca file for verifying the external provider servers
+	DefaultClientCAFile = ""
+	// DefaultClientCertFile client certificate file sent to the external
+	// provider servers, reloaded on each tls handshake
+	DefaultClientCertFile = ""
+	// DefaultClientKeyFile key file of the client certificate
+	DefaultClientKeyFile = ""
+	// DefaultClientTokenFile file of the bearer token sent to the external
+	// provider servers, reloaded when changed
+	DefaultClientTokenFile = ""
+)
 
-// DefaultClient client for dealing requests
-var DefaultClient = singleton.NewSingleton(func() *http.Client {
-	return &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: DefaultClientInsecureSkipVerify},
-		},
+// NewDefaultClient create the client for dealing requests with the default
+// client options
+func NewDefaultClient() (*http.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: DefaultClientInsecureSkipVerify}
+	if DefaultClientCAFile != "" {
+		bs, err := os.ReadFile(DefaultClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bs) {
+			return nil, fmt.Errorf("no valid certificate found in ca file %s", DefaultClientCAFile)
+		}
+		tlsConfig.RootCAs, tlsConfig.InsecureSkipVerify = pool, false
+	}
+	if DefaultClientCertFile != "" || DefaultClientKeyFile != "" {
+		certFile, keyFile := DefaultClientCertFile, DefaultClientKeyFile
+		if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+			return nil, err
+		}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		}
 	}
-})
+	var rt http.RoundTripper = &http.Transport{TLSClientConfig: tlsConfig}
+	if DefaultClientTokenFile != "" {
+		var err error
+		if rt, err = transport.NewBearerAuthWithRefreshRoundTripper("", DefaultClientTokenFile, rt); err != nil {
+			return nil, err
+		}
+	}
+	return &http.Client{Transport: rt}, nil
+}
+
+// DefaultClient client for dealing requests
+var DefaultClient = singleton.NewSingletonE(NewDefaultClient)
 
 // Call dial external endpoints by passing the json data of the input parameter,
 // then fill back returned values
 func (in *ExternalProviderFn) Call(ctx context.Context, value cue.Value) (cue.Value, error) {
 	params := value.LookupPath(cue.ParsePath(providers.ParamsKey))
-	bs, err := params.MarshalJSON()
+	contentType := runtime.ContentTypeJSON
+	marshal := func(v cue.Value) ([]byte, error) { return v.MarshalJSON() }
+	if in.Encoding == v1alpha1.EncodingCUE {
+		contentType, marshal = ContentTypeCUE, MarshalCUE
+	}
+	bs, err := marshal(params)
 	if err != nil {
 		return value, err
 	}
@@ -95,7 +147,8 @@ func (in *ExternalProviderFn) Call(ctx context.Context, value cue.Value) (cue.Va
 		if err != nil {
 			return value, err
 		}
-		req.Header.Set("Content-Type", runtime.ContentTypeJSON)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Accept", contentType)
 		for k, v := range in.Header {
 			req.Header.Set(k, v)
 		}
@@ -109,6 +162,13 @@ func (in *ExternalProviderFn) Call(ctx context.Context, value cue.Value) (cue.Va
 		if bs, err = io.ReadAll(resp.Body); err != nil {
 			return value, err
 		}
+		if strings.HasPrefix(resp.Header.Get("Content-Type"), ContentTypeCUE) {
+			ret, err := UnmarshalCUE(value.Context(), bs)
+			if err != nil {
+				return value, err
+			}
+			return value.FillPath(cue.ParsePath(providers.ReturnsKey), ret), nil
+		}
 	default:
 		return value, fmt.Errorf("protocol %s not supported yet", in.Protocol)
 	}
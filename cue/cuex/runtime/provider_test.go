@@ -18,11 +18,16 @@ package runtime_test
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -33,6 +38,7 @@ import (
 	"github.com/kubevela/pkg/apis/cue/v1alpha1"
 	"github.com/kubevela/pkg/cue/cuex/providers"
 	"github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/util/cert"
 )
 
 type value struct {
@@ -219,3 +225,57 @@ func TestProviderCustomHeader(t *testing.T) {
 	_, err := prd.Call(context.Background(), v)
 	require.NoError(t, err, "call to ExternalProviderFn failed")
 }
+
+func TestNewDefaultClient(t *testing.T) {
+	dir := t.TempDir()
+	caCert, caKey, err := cert.NewTemplateBuilder("test-ca").AsCA().SelfSigned()
+	require.NoError(t, err)
+	serverCert, serverKey, err := cert.NewTemplateBuilder("server").WithIPs(net.ParseIP("127.0.0.1")).SignedBy(caCert, caKey)
+	require.NoError(t, err)
+	clientCert, clientKey, err := cert.NewTemplateBuilder("carol").SignedBy(caCert, caKey)
+	require.NoError(t, err)
+	for name, data := range map[string][]byte{"ca.crt": caCert, "tls.crt": clientCert, "tls.key": clientKey, "token": []byte("carol-token")} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0600))
+	}
+
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(caCert))
+	pair, err := tls.X509KeyPair(serverCert, serverKey)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte(request.TLS.PeerCertificates[0].Subject.CommonName + " " + request.Header.Get("Authorization")))
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{pair}, ClientCAs: pool, ClientAuth: tls.RequireAndVerifyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	defer func(insecure bool, ca, certFile, keyFile, token string) {
+		runtime.DefaultClientInsecureSkipVerify = insecure
+		runtime.DefaultClientCAFile, runtime.DefaultClientCertFile, runtime.DefaultClientKeyFile, runtime.DefaultClientTokenFile = ca, certFile, keyFile, token
+	}(runtime.DefaultClientInsecureSkipVerify, runtime.DefaultClientCAFile, runtime.DefaultClientCertFile, runtime.DefaultClientKeyFile, runtime.DefaultClientTokenFile)
+
+	runtime.DefaultClientInsecureSkipVerify = true
+	runtime.DefaultClientCAFile = filepath.Join(dir, "ca.crt")
+	cli, err := runtime.NewDefaultClient()
+	require.NoError(t, err)
+	_, err = cli.Get(server.URL)
+	require.Error(t, err)
+
+	runtime.DefaultClientCertFile, runtime.DefaultClientKeyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	runtime.DefaultClientTokenFile = filepath.Join(dir, "token")
+	cli, err = runtime.NewDefaultClient()
+	require.NoError(t, err)
+	resp, err := cli.Get(server.URL)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	bs, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "carol Bearer carol-token", string(bs))
+
+	runtime.DefaultClientCAFile = filepath.Join(dir, "tls.key")
+	_, err = runtime.NewDefaultClient()
+	require.ErrorContains(t, err, "no valid certificate found")
+	runtime.DefaultClientCAFile, runtime.DefaultClientKeyFile = "", filepath.Join(dir, "ca.crt")
+	_, err = runtime.NewDefaultClient()
+	require.Error(t, err)
+}
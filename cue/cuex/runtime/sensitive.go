@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RedactedPlaceholder the replacement for sensitive values in outputs
+const RedactedPlaceholder = "<redacted>"
+
+const sensitiveValuesKey ctxKey = "SensitiveValues"
+
+// SensitiveValues records values that must not be exposed in errors, traces
+// or compile outputs. It is shared by all provider calls in one compile.
+type SensitiveValues struct {
+	mu     sync.RWMutex
+	values map[string]struct{}
+}
+
+// NewSensitiveValues create an empty SensitiveValues
+func NewSensitiveValues() *SensitiveValues {
+	return &SensitiveValues{values: map[string]struct{}{}}
+}
+
+// Add mark the given values as sensitive, empty values are ignored
+func (in *SensitiveValues) Add(values ...string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	for _, v := range values {
+		if v != "" {
+			in.values[v] = struct{}{}
+		}
+	}
+}
+
+// Len return the number of recorded sensitive values
+func (in *SensitiveValues) Len() int {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	return len(in.values)
+}
+
+// MinSensitiveSubstringLength the min length of the sensitive values to be
+// redacted wherever contained in other strings. The shorter values are
+// redacted only where they are not adjacent to letters or digits, so that
+// short values like "1" do not break the unrelated outputs like "v1", while
+// they are still hidden in urls or messages like "admin:p@ss@host".
+var MinSensitiveSubstringLength = 8
+
+// Redact replace the sensitive values in the given string with
+// RedactedPlaceholder. Longer values are replaced first so that values
+// containing other sensitive values are fully hidden.
+func (in *SensitiveValues) Redact(s string) string {
+	if in == nil || s == "" {
+		return s
+	}
+	in.mu.RLock()
+	values := make([]string, 0, len(in.values))
+	for v := range in.values {
+		values = append(values, v)
+	}
+	in.mu.RUnlock()
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, v := range values {
+		if len(v) >= MinSensitiveSubstringLength {
+			s = strings.ReplaceAll(s, v, RedactedPlaceholder)
+		} else {
+			s = replaceOnBoundaries(s, v, RedactedPlaceholder)
+		}
+	}
+	return s
+}
+
+// replaceOnBoundaries replace the occurrences of old in s which are not
+// adjacent to letters or digits
+func replaceOnBoundaries(s string, old string, replacement string) string {
+	sb, i := strings.Builder{}, 0
+	for {
+		j := strings.Index(s[i:], old)
+		if j < 0 {
+			break
+		}
+		start, end := i+j, i+j+len(old)
+		prev, _ := utf8.DecodeLastRuneInString(s[:start])
+		next, _ := utf8.DecodeRuneInString(s[end:])
+		if isWordRune(prev) || isWordRune(next) {
+			sb.WriteString(s[i : start+1])
+			i = start + 1
+			continue
+		}
+		sb.WriteString(s[i:start])
+		sb.WriteString(replacement)
+		i = end
+	}
+	sb.WriteString(s[i:])
+	return sb.String()
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// WithSensitiveValues attach a SensitiveValues to the context if there is no
+// one attached yet
+func WithSensitiveValues(ctx context.Context) context.Context {
+	if _, ok := GetSensitiveValues(ctx); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, sensitiveValuesKey, NewSensitiveValues())
+}
+
+// GetSensitiveValues retrieve the SensitiveValues from the context if present
+func GetSensitiveValues(ctx context.Context) (*SensitiveValues, bool) {
+	sv, ok := ctx.Value(sensitiveValuesKey).(*SensitiveValues)
+	return sv, ok
+}
+
+// MarkSensitive mark the given values as sensitive in the context. It does
+// nothing if no SensitiveValues is attached to the context.
+func MarkSensitive(ctx context.Context, values ...string) {
+	if sv, ok := GetSensitiveValues(ctx); ok {
+		sv.Add(values...)
+	}
+}
+
+// Redact replace the sensitive values recorded in the context from the given
+// string
+func Redact(ctx context.Context, s string) string {
+	sv, _ := GetSensitiveValues(ctx)
+	return sv.Redact(s)
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/pkg/cue/cuex/runtime"
+)
+
+func TestSensitiveValues(t *testing.T) {
+	ctx := context.Background()
+	runtime.MarkSensitive(ctx, "ignored")
+	require.Equal(t, "ignored", runtime.Redact(ctx, "ignored"))
+
+	ctx = runtime.WithSensitiveValues(ctx)
+	sv, ok := runtime.GetSensitiveValues(ctx)
+	require.True(t, ok)
+	require.Equal(t, ctx, runtime.WithSensitiveValues(ctx))
+
+	runtime.MarkSensitive(ctx, "", "s3cr3t", "s3cr3t-token")
+	require.Equal(t, 2, sv.Len())
+	require.Equal(t, "user: admin, token: <redacted>", runtime.Redact(ctx, "user: admin, token: s3cr3t-token"))
+	require.Equal(t, "<redacted>-key", runtime.Redact(ctx, "s3cr3t-key"))
+	require.Equal(t, "", runtime.Redact(ctx, ""))
+
+	runtime.MarkSensitive(ctx, "1", "default")
+	require.Equal(t, "<redacted>", runtime.Redact(ctx, "1"))
+	require.Equal(t, "<redacted>", runtime.Redact(ctx, "default"))
+	require.Equal(t, "v1", runtime.Redact(ctx, "v1"))
+	require.Equal(t, "defaults", runtime.Redact(ctx, "defaults"))
+	require.Equal(t, "svc-<redacted>", runtime.Redact(ctx, "svc-default"))
+	require.Equal(t, "replicas <redacted> of 10", runtime.Redact(ctx, "replicas 1 of 10"))
+
+	runtime.MarkSensitive(ctx, "p@ss")
+	require.Equal(t, "dial https://admin:<redacted>@example.com: refused", runtime.Redact(ctx, "dial https://admin:p@ss@example.com: refused"))
+	require.Equal(t, "p@ssword", runtime.Redact(ctx, "p@ssword"))
+	require.Equal(t, `conflicting values "<redacted>" and "v1"`, runtime.Redact(ctx, `conflicting values "default" and "v1"`))
+}
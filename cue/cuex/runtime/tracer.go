@@ -35,16 +35,19 @@ type ctxKey string
 
 // PropagatedCtx .
 type PropagatedCtx struct {
-	pCtx []byte
+	pCtx      []byte
+	signature string
+	err       error
 	context.Context
 }
 
 const (
-	key          ctxKey = "PropagatedCtx"
-	headerPrefix        = "X-Vela"
-	traceParent         = "traceparent"
-	traceState          = "tracestate"
-	encodedCtx          = "Encoded-Context"
+	key              ctxKey = "PropagatedCtx"
+	headerPrefix            = "X-Vela"
+	traceParent             = "traceparent"
+	traceState              = "tracestate"
+	encodedCtx              = "Encoded-Context"
+	contextSignature        = "Context-Signature"
 )
 
 // StartSpan creates a new OpenTelemetry span and returns the updated context and span.
@@ -57,17 +60,28 @@ func StartSpan(ctx context.Context, name string) (context.Context, trace.Span) {
 // StartSpanWithBaggage creates a new OpenTelemetry span and attaches encoded JSON context as baggage.
 func StartSpanWithBaggage(ctx context.Context, name string, hCtx json.Marshaler) (context.Context, trace.Span, error) {
 	jsonCtx, err := json.Marshal(hCtx)
+	if size := base64.StdEncoding.EncodedLen(len(jsonCtx)); err == nil && MaxPropagatedContextSize > 0 && size > MaxPropagatedContextSize {
+		err = fmt.Errorf("%w: %d bytes exceeds %d bytes", ErrPropagatedContextTooLarge, size, MaxPropagatedContextSize)
+	}
 	if err != nil {
 		klog.Errorf("failed to marshal json: %v", err)
 		ctx, span := StartSpan(ctx, name)
 		return ctx, span, err
 	}
 
+	members := make([]baggage.Member, 0, 2)
 	member, err := newBaggageMember(strings.ToLower(encodedCtx), base64.StdEncoding.EncodeToString(jsonCtx))
+	if err == nil {
+		members = append(members, member)
+		if len(ContextSigningKey) > 0 {
+			member, err = newBaggageMember(strings.ToLower(contextSignature), signContext(jsonCtx))
+			members = append(members, member)
+		}
+	}
 	if err != nil {
 		klog.Warningf("failed to encode context baggage header: \n%v", err)
 	} else {
-		bag, err := newBaggage(member)
+		bag, err := newBaggage(members...)
 		if err != nil {
 			klog.Errorf("failed to create context bag: \n%v", err)
 			ctx, span := StartSpan(ctx, name)
@@ -135,6 +149,10 @@ func (cp TraceHeaderPropagator) Extract(ctx context.Context, carrier propagation
 			switch strippedKey {
 
 			case encodedCtx:
+				if MaxPropagatedContextSize > 0 && len(value) > MaxPropagatedContextSize {
+					appContext.err = fmt.Errorf("%w: %d bytes exceeds %d bytes", ErrPropagatedContextTooLarge, len(value), MaxPropagatedContextSize)
+					continue
+				}
 				dataBytes, err := base64.StdEncoding.DecodeString(value)
 				if err != nil {
 					klog.Errorf("error decoding base64 context: %v\n", err)
@@ -142,6 +160,9 @@ func (cp TraceHeaderPropagator) Extract(ctx context.Context, carrier propagation
 				}
 				appContext.pCtx = dataBytes
 
+			case contextSignature:
+				appContext.signature = value
+
 			case traceParent, traceState: // do nothing
 
 			default:
@@ -150,6 +171,11 @@ func (cp TraceHeaderPropagator) Extract(ctx context.Context, carrier propagation
 		}
 	}
 
+	if len(ContextSigningKey) > 0 && appContext.pCtx != nil && !verifyContext(appContext.pCtx, appContext.signature) {
+		klog.Errorf("rejected the propagated context: %v", ErrPropagatedContextSignature)
+		appContext.pCtx, appContext.err = nil, ErrPropagatedContextSignature
+	}
+
 	return context.WithValue(ctx, key, appContext)
 }
 
@@ -158,13 +184,25 @@ func (p *PropagatedCtx) RawJSON() json.RawMessage {
 	return json.RawMessage(p.pCtx)
 }
 
+// ValidationErr returns the error if the propagated context is rejected, such
+// as being too large or having an invalid signature.
+func (p *PropagatedCtx) ValidationErr() error {
+	return p.err
+}
+
 // UnmarshalContext unmarshals the embedded JSON context into the provided struct.
 func (p *PropagatedCtx) UnmarshalContext(out interface{}) error {
+	if p.err != nil {
+		return p.err
+	}
 	return json.Unmarshal(p.pCtx, out)
 }
 
 // GetCueContext returns the embedded JSON context as a CUE value.
 func (p *PropagatedCtx) GetCueContext() (*cue.Value, error) {
+	if p.err != nil {
+		return &cue.Value{}, p.err
+	}
 	cueCtx := cuecontext.New()
 	cueVal := cueCtx.CompileString(string(p.pCtx))
 	if cueVal.Err() != nil {
@@ -175,7 +213,7 @@ func (p *PropagatedCtx) GetCueContext() (*cue.Value, error) {
 
 // ContextFromHeaders extracts the span context and baggage from an HTTP request and returns the reconstructed ctx.
 func ContextFromHeaders(r *http.Request) context.Context {
-	return TraceHeaderPropagator{}.Extract(context.Background(), propagation.HeaderCarrier(r.Header))
+	return TraceHeaderPropagator{}.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
 }
 
 // GetPropagatedContext retrieves the PropagatedCtx from the given context if present.
@@ -196,5 +234,6 @@ func (cp TraceHeaderPropagator) Fields() []string {
 		traceParent,
 		traceState,
 		fmt.Sprintf("%s-%s", headerPrefix, encodedCtx),
+		fmt.Sprintf("%s-%s", headerPrefix, contextSignature),
 	}
 }
@@ -0,0 +1,207 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// TracingExporter the type of the exporter for the spans
+type TracingExporter string
+
+const (
+	// TracingExporterNone do not export the spans
+	TracingExporterNone TracingExporter = "none"
+	// TracingExporterStdout write the spans to stdout
+	TracingExporterStdout TracingExporter = "stdout"
+	// TracingExporterFile write the spans to TracingOptions.File
+	TracingExporterFile TracingExporter = "file"
+	// TracingExporterOTLPGRPC export the spans to the OTLP collector by grpc
+	TracingExporterOTLPGRPC TracingExporter = "otlp-grpc"
+	// TracingExporterOTLPHTTP export the spans to the OTLP collector by http
+	TracingExporterOTLPHTTP TracingExporter = "otlp-http"
+)
+
+// TracingOptions options for the OpenTelemetry tracer provider
+type TracingOptions struct {
+	// Exporter the type of the exporter
+	Exporter TracingExporter
+	// Endpoint the host:port or url of the OTLP collector, the OTLP
+	// environment variables are used if empty
+	Endpoint string
+	// Insecure disable the tls to the OTLP collector
+	Insecure bool
+	// File the file to write spans when the exporter is file
+	File string
+	// SampleRatio the ratio of the root spans to be sampled, the child spans
+	// follow the decision of the parent
+	SampleRatio float64
+	// ServiceName the service.name of the resource
+	ServiceName string
+	// ResourceAttributes the extra attributes of the resource
+	ResourceAttributes map[string]string
+}
+
+// DefaultTracingOptions the tracing options set by flags
+var DefaultTracingOptions = TracingOptions{
+	Exporter:    TracingExporterNone,
+	SampleRatio: 1,
+	ServiceName: "kubevela",
+}
+
+// NewTracerProvider create the tracer provider with the exporter, sampler
+// and resource in the options. The returned shutdown function flushes the
+// pending spans and releases the exporter.
+func NewTracerProvider(ctx context.Context, opts TracingOptions) (*tracesdk.TracerProvider, func(context.Context) error, error) {
+	if opts.SampleRatio < 0 || opts.SampleRatio > 1 {
+		return nil, nil, fmt.Errorf("tracing sample ratio %v is not in [0, 1]", opts.SampleRatio)
+	}
+	res, err := newTracingResource(ctx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	exporter, closer, err := newTracingExporter(ctx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	providerOpts := []tracesdk.TracerProviderOption{
+		tracesdk.WithResource(res),
+		tracesdk.WithSampler(tracesdk.ParentBased(tracesdk.TraceIDRatioBased(opts.SampleRatio))),
+	}
+	if exporter != nil {
+		providerOpts = append(providerOpts, tracesdk.WithBatcher(exporter))
+	}
+	tp := tracesdk.NewTracerProvider(providerOpts...)
+	shutdown := func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+		return err
+	}
+	return tp, shutdown, nil
+}
+
+// SetupTracing create the tracer provider with the options and set it as the
+// global tracer provider, which is used by the spans of cuex.
+func SetupTracing(ctx context.Context, opts TracingOptions) (func(context.Context) error, error) {
+	tp, shutdown, err := NewTracerProvider(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(tp)
+	return shutdown, nil
+}
+
+func newTracingResource(ctx context.Context, opts TracingOptions) (*resource.Resource, error) {
+	attrs := make([]attribute.KeyValue, 0, len(opts.ResourceAttributes)+1)
+	if opts.ServiceName != "" {
+		attrs = append(attrs, semconv.ServiceName(opts.ServiceName))
+	}
+	keys := make([]string, 0, len(opts.ResourceAttributes))
+	for k := range opts.ResourceAttributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attrs = append(attrs, attribute.String(k, opts.ResourceAttributes[k]))
+	}
+	return resource.New(ctx, resource.WithTelemetrySDK(), resource.WithFromEnv(), resource.WithAttributes(attrs...))
+}
+
+func newTracingExporter(ctx context.Context, opts TracingOptions) (tracesdk.SpanExporter, io.Closer, error) {
+	switch opts.Exporter {
+	case "", TracingExporterNone:
+		return nil, nil, nil
+	case TracingExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		return exporter, nil, err
+	case TracingExporterFile:
+		if opts.File == "" {
+			return nil, nil, fmt.Errorf("tracing file is required for the file exporter")
+		}
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			_ = f.Close()
+			return nil, nil, err
+		}
+		return exporter, f, nil
+	case TracingExporterOTLPGRPC:
+		var options []otlptracegrpc.Option
+		switch {
+		case strings.Contains(opts.Endpoint, "://"):
+			options = append(options, otlptracegrpc.WithEndpointURL(opts.Endpoint))
+		case opts.Endpoint != "":
+			options = append(options, otlptracegrpc.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(ctx, options...)
+		return exporter, nil, err
+	case TracingExporterOTLPHTTP:
+		var options []otlptracehttp.Option
+		switch {
+		case strings.Contains(opts.Endpoint, "://"):
+			options = append(options, otlptracehttp.WithEndpointURL(opts.Endpoint))
+		case opts.Endpoint != "":
+			options = append(options, otlptracehttp.WithEndpoint(opts.Endpoint))
+		}
+		if opts.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(ctx, options...)
+		return exporter, nil, err
+	default:
+		return nil, nil, fmt.Errorf("unsupported tracing exporter %q", opts.Exporter)
+	}
+}
+
+// AddTracingFlags add flags for configuring DefaultTracingOptions
+func AddTracingFlags(set *pflag.FlagSet) {
+	if set.Lookup("cuex-tracing-exporter") != nil {
+		return
+	}
+	opts := &DefaultTracingOptions
+	set.StringVarP((*string)(&opts.Exporter), "cuex-tracing-exporter", "", string(opts.Exporter), "exporter of the cuex spans, one of none, stdout, file, otlp-grpc and otlp-http")
+	set.StringVarP(&opts.Endpoint, "cuex-tracing-endpoint", "", opts.Endpoint, "host:port or url of the OTLP collector, use the OTEL_EXPORTER_OTLP_* environment variables if empty")
+	set.BoolVarP(&opts.Insecure, "cuex-tracing-insecure", "", opts.Insecure, "disable tls to the OTLP collector")
+	set.StringVarP(&opts.File, "cuex-tracing-file", "", opts.File, "file to write the cuex spans for the file exporter")
+	set.Float64VarP(&opts.SampleRatio, "cuex-tracing-sample-ratio", "", opts.SampleRatio, "ratio in [0, 1] of the root spans to be sampled")
+	set.StringVarP(&opts.ServiceName, "cuex-tracing-service-name", "", opts.ServiceName, "service.name of the tracing resource")
+	set.StringToStringVarP(&opts.ResourceAttributes, "cuex-tracing-resource-attributes", "", opts.ResourceAttributes, "extra attributes of the tracing resource, e.g. cluster=local,env=dev")
+}
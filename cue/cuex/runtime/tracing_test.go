@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestNewTracerProvider(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "spans.json")
+	tp, shutdown, err := NewTracerProvider(ctx, TracingOptions{
+		Exporter:           TracingExporterFile,
+		File:               file,
+		SampleRatio:        1,
+		ServiceName:        "test-service",
+		ResourceAttributes: map[string]string{"cluster": "local"},
+	})
+	require.NoError(t, err)
+	_, span := tp.Tracer("test").Start(ctx, "test-span")
+	span.End()
+	require.NoError(t, shutdown(ctx))
+	bs, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.Contains(t, string(bs), "test-span")
+	require.Contains(t, string(bs), "test-service")
+	require.Contains(t, string(bs), "cluster")
+
+	tp, shutdown, err = NewTracerProvider(ctx, TracingOptions{SampleRatio: 0})
+	require.NoError(t, err)
+	_, span = tp.Tracer("test").Start(ctx, "test-span")
+	require.False(t, span.SpanContext().IsSampled())
+	span.End()
+	require.NoError(t, shutdown(ctx))
+
+	for name, opts := range map[string]TracingOptions{
+		"invalid ratio":     {SampleRatio: 2},
+		"unknown exporter":  {Exporter: "unknown", SampleRatio: 1},
+		"file not provided": {Exporter: TracingExporterFile, SampleRatio: 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := NewTracerProvider(ctx, opts)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestSetupTracing(t *testing.T) {
+	ctx := context.Background()
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	for _, exporter := range []TracingExporter{TracingExporterNone, TracingExporterStdout, TracingExporterOTLPGRPC, TracingExporterOTLPHTTP} {
+		t.Run(string(exporter), func(t *testing.T) {
+			shutdown, err := SetupTracing(ctx, TracingOptions{Exporter: exporter, Endpoint: "127.0.0.1:4317", Insecure: true, SampleRatio: 1})
+			require.NoError(t, err)
+			_, span := StartSpan(ctx, "test")
+			require.True(t, span.SpanContext().IsSampled())
+			span.End()
+			cancelCtx, cancel := context.WithCancel(ctx)
+			cancel()
+			_ = shutdown(cancelCtx)
+		})
+	}
+}
+
+func TestAddTracingFlags(t *testing.T) {
+	defer func(opts TracingOptions) { DefaultTracingOptions = opts }(DefaultTracingOptions)
+	set := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddTracingFlags(set)
+	AddTracingFlags(set)
+	require.NoError(t, set.Parse([]string{
+		"--cuex-tracing-exporter=otlp-grpc",
+		"--cuex-tracing-endpoint=collector:4317",
+		"--cuex-tracing-sample-ratio=0.5",
+		"--cuex-tracing-resource-attributes=cluster=local,env=dev",
+	}))
+	require.Equal(t, TracingExporterOTLPGRPC, DefaultTracingOptions.Exporter)
+	require.Equal(t, "collector:4317", DefaultTracingOptions.Endpoint)
+	require.Equal(t, 0.5, DefaultTracingOptions.SampleRatio)
+	require.Equal(t, map[string]string{"cluster": "local", "env": "dev"}, DefaultTracingOptions.ResourceAttributes)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/emicklei/go-restful/v3"
+
+	"github.com/kubevela/pkg/cue/cuex"
+)
+
+// AssistFn the function for assisting the source at the cursor
+type AssistFn func(context.Context, string, cuex.Cursor) (*cuex.AssistResult, error)
+
+// AssistServer the server for the completion, the docs and the diagnostics
+// of the cuex source
+type AssistServer struct {
+	fn AssistFn
+}
+
+// NewAssistServer create AssistServer with the assist function
+func NewAssistServer(fn AssistFn) *AssistServer {
+	return &AssistServer{fn: fn}
+}
+
+// AssistRequest the request for assisting the source at the cursor, the line
+// and column are 1-based
+type AssistRequest struct {
+	Source string `json:"source"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// ServeHTTP .
+func (in *AssistServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "cue.server.Assist")
+	defer span.End()
+	req := AssistRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(r.Context(), w, newCompileError(ErrorTypeBadRequest, http.StatusBadRequest, fmt.Errorf("decode assist request error: %w", err)))
+		return
+	}
+	res, err := in.fn(r.Context(), req.Source, cuex.Cursor{Line: req.Line, Column: req.Column})
+	if err != nil {
+		writeError(r.Context(), w, newCompileError(ErrorTypeInternal, http.StatusInternalServerError, err))
+		return
+	}
+	bs, err := json.Marshal(res)
+	if err != nil {
+		writeError(r.Context(), w, fmt.Errorf("unexpected error when encoding response: %w", err))
+		return
+	}
+	w.Header().Set(restful.HEADER_ContentType, restful.MIME_JSON)
+	if _, err = w.Write(bs); err != nil {
+		writeError(r.Context(), w, fmt.Errorf("unexpected error when writing response: %w", err))
+	}
+}
+
+// Handle rest request
+func (in *AssistServer) Handle(request *restful.Request, response *restful.Response) {
+	in.ServeHTTP(response, request.Request)
+}
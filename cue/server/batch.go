@@ -0,0 +1,126 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/emicklei/go-restful/v3"
+	"go.opentelemetry.io/otel/attribute"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/cue/util"
+	"github.com/kubevela/pkg/util/slices"
+)
+
+var (
+	// MaxBatchParallelism the max parallelism for compiling the batch request
+	MaxBatchParallelism = 20
+	// MaxBatchItems the max number of items in the batch request, no limit if
+	// not positive
+	MaxBatchItems = 100
+)
+
+// BatchCompileRequest the request for compiling multiple documents
+type BatchCompileRequest struct {
+	Items       []CompileRequest `json:"items"`
+	Parallelism int              `json:"parallelism,omitempty"`
+}
+
+// BatchCompileResult the result of one document in the batch. The Result is
+// set for the json format, otherwise the Output is set.
+type BatchCompileResult struct {
+	Name   string          `json:"name,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Output string          `json:"output,omitempty"`
+	Error  *ErrorResponse  `json:"error,omitempty"`
+}
+
+// BatchCompileResponse the response of the batch request, the results are
+// in the same order as the request items
+type BatchCompileResponse struct {
+	Items []BatchCompileResult `json:"items"`
+}
+
+// ServeBatchHTTP compile the documents of the batch request in parallel
+func (in *CompileServer) ServeBatchHTTP(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "cue.server.BatchCompile")
+	defer span.End()
+	bs, err := readBody(w, r)
+	if err != nil {
+		writeError(r.Context(), w, err)
+		return
+	}
+	req := &BatchCompileRequest{}
+	if err = json.Unmarshal(bs, req); err != nil {
+		writeError(r.Context(), w, newCompileError(ErrorTypeBadRequest, http.StatusBadRequest, fmt.Errorf("decode batch request error: %w", err)))
+		return
+	}
+	span.SetAttributes(attribute.Int("cue.server.batch.items", len(req.Items)))
+	if MaxBatchItems > 0 && len(req.Items) > MaxBatchItems {
+		writeError(r.Context(), w, newCompileError(ErrorTypeBadRequest, http.StatusBadRequest, fmt.Errorf("too many items in the batch request: %d exceeds %d", len(req.Items), MaxBatchItems)))
+		return
+	}
+	parallelism := req.Parallelism
+	if parallelism <= 0 {
+		parallelism = slices.DefaultParallelism
+	}
+	if parallelism > MaxBatchParallelism {
+		parallelism = MaxBatchParallelism
+	}
+	ctx := r.Context()
+	results := slices.ParMap(req.Items, func(item CompileRequest) BatchCompileResult {
+		result := BatchCompileResult{Name: item.Name}
+		ctx := cuexruntime.WithSensitiveValues(ctx)
+		if err := ctx.Err(); err != nil {
+			_, result.Error = newErrorResponse(ctx, newCompileError(ErrorTypeTimeout, http.StatusGatewayTimeout, err))
+			return result
+		}
+		if item.Format == "" {
+			item.Format = util.PrintFormatJson
+		}
+		out, err := in.compile(ctx, item)
+		switch {
+		case err != nil:
+			_, result.Error = newErrorResponse(ctx, err)
+		case item.Format == util.PrintFormatJson:
+			result.Result = out
+		default:
+			result.Output = string(out)
+		}
+		return result
+	}, slices.Parallelism(parallelism))
+	if results == nil {
+		results = []BatchCompileResult{}
+	}
+	if bs, err = json.Marshal(BatchCompileResponse{Items: results}); err != nil {
+		writeError(r.Context(), w, fmt.Errorf("content encode error: %w", err))
+		return
+	}
+	w.Header().Set(restful.HEADER_ContentType, restful.MIME_JSON)
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(bs); err != nil {
+		writeError(r.Context(), w, fmt.Errorf("unexpected error when writing response: %w", err))
+	}
+}
+
+// HandleBatch rest request for batch compile
+func (in *CompileServer) HandleBatch(request *restful.Request, response *restful.Response) {
+	in.ServeBatchHTTP(response, request.Request)
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	cueerrors "cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+	"github.com/emicklei/go-restful/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/kubevela/pkg/cue/cuex"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+)
+
+// ErrorType the type of the error returned by the compile server
+type ErrorType string
+
+const (
+	// ErrorTypeBadRequest the request cannot be read or decoded
+	ErrorTypeBadRequest ErrorType = "bad-request"
+	// ErrorTypeParse the CUE source cannot be parsed
+	ErrorTypeParse ErrorType = "parse"
+	// ErrorTypeUnification the CUE value is invalid or incomplete
+	ErrorTypeUnification ErrorType = "unification"
+	// ErrorTypeProviderNotFound the provider or the function is not found
+	ErrorTypeProviderNotFound ErrorType = "provider-not-found"
+	// ErrorTypeFunctionCall the provider function call failed
+	ErrorTypeFunctionCall ErrorType = "function-call"
+	// ErrorTypeTimeout the compilation timeout
+	ErrorTypeTimeout ErrorType = "timeout"
+	// ErrorTypeUnauthorized the request is not authenticated
+	ErrorTypeUnauthorized ErrorType = "unauthorized"
+	// ErrorTypeForbidden the request is not allowed
+	ErrorTypeForbidden ErrorType = "forbidden"
+	// ErrorTypeInternal the unexpected server side error
+	ErrorTypeInternal ErrorType = "internal"
+)
+
+// Position the position in the CUE source
+type Position struct {
+	Filename string `json:"filename,omitempty"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// ErrorDetail the detail of one CUE error
+type ErrorDetail struct {
+	Message   string     `json:"message"`
+	Path      string     `json:"path,omitempty"`
+	Positions []Position `json:"positions,omitempty"`
+}
+
+// ErrorResponse the structured error returned by the compile server
+type ErrorResponse struct {
+	Type      ErrorType     `json:"type"`
+	Message   string        `json:"message"`
+	Path      string        `json:"path,omitempty"`
+	Provider  string        `json:"provider,omitempty"`
+	Function  string        `json:"function,omitempty"`
+	Positions []Position    `json:"positions,omitempty"`
+	Details   []ErrorDetail `json:"details,omitempty"`
+}
+
+// compileError the error with its type and status code, the message is
+// displayed instead of the wrapped error if set
+type compileError struct {
+	error
+	typ    ErrorType
+	status int
+	msg    string
+}
+
+// Error .
+func (e compileError) Error() string {
+	if e.msg != "" {
+		return e.msg
+	}
+	return e.error.Error()
+}
+
+// Unwrap .
+func (e compileError) Unwrap() error {
+	return e.error
+}
+
+func newCompileError(typ ErrorType, status int, err error) compileError {
+	return compileError{error: err, typ: typ, status: status}
+}
+
+// classifyError find the type and the status code of the error returned by
+// the compile function
+func classifyError(ctx context.Context, err error) compileError {
+	e := compileError{error: err, typ: ErrorTypeUnification, status: http.StatusBadRequest,
+		msg: "compile cue error: " + cuexruntime.Redact(ctx, err.Error())}
+	var callErr cuex.FunctionCallError
+	var fnNotFoundErr cuex.ProviderFnNotFoundErr
+	var prdNotFoundErr cuex.ProviderNotFoundErr
+	switch {
+	case apierrors.IsForbidden(err):
+		e.typ, e.status = ErrorTypeForbidden, http.StatusForbidden
+	case errors.As(err, &cuex.ResolveTimeoutErr{}), errors.Is(err, context.DeadlineExceeded):
+		e.typ, e.status = ErrorTypeTimeout, http.StatusGatewayTimeout
+	case errors.As(err, &callErr):
+		e.typ, e.status = ErrorTypeFunctionCall, http.StatusInternalServerError
+	case errors.As(err, &fnNotFoundErr), errors.As(err, &prdNotFoundErr):
+		e.typ = ErrorTypeProviderNotFound
+	}
+	return e
+}
+
+// newErrorResponse build the ErrorResponse and the status code from the error
+func newErrorResponse(ctx context.Context, err error) (int, *ErrorResponse) {
+	resp := &ErrorResponse{Type: ErrorTypeInternal, Message: cuexruntime.Redact(ctx, err.Error())}
+	status := http.StatusInternalServerError
+	var e compileError
+	if errors.As(err, &e) {
+		resp.Type, status = e.typ, e.status
+	}
+	var callErr cuex.FunctionCallError
+	var fnNotFoundErr cuex.ProviderFnNotFoundErr
+	var prdNotFoundErr cuex.ProviderNotFoundErr
+	switch {
+	case errors.As(err, &callErr):
+		resp.Path, resp.Provider, resp.Function = callErr.Path, callErr.Provider, callErr.Function
+	case errors.As(err, &fnNotFoundErr):
+		resp.Provider, resp.Function = fnNotFoundErr.Provider, fnNotFoundErr.Fn
+	case errors.As(err, &prdNotFoundErr):
+		resp.Provider = string(prdNotFoundErr)
+	}
+	var cueErr cueerrors.Error
+	if errors.As(err, &cueErr) {
+		for _, item := range cueerrors.Errors(cueErr) {
+			detail := ErrorDetail{
+				Message:   cuexruntime.Redact(ctx, item.Error()),
+				Path:      strings.Join(item.Path(), "."),
+				Positions: toPositions(append([]token.Pos{item.Position()}, item.InputPositions()...)),
+			}
+			resp.Details = append(resp.Details, detail)
+		}
+		if len(resp.Details) > 0 {
+			if resp.Path == "" {
+				resp.Path = resp.Details[0].Path
+			}
+			resp.Positions = resp.Details[0].Positions
+		}
+	}
+	return status, resp
+}
+
+func toPositions(pos []token.Pos) []Position {
+	var positions []Position
+	seen := map[token.Pos]bool{}
+	for _, p := range pos {
+		if !p.IsValid() || seen[p] {
+			continue
+		}
+		seen[p] = true
+		positions = append(positions, Position{Filename: p.Filename(), Line: p.Line(), Column: p.Column()})
+	}
+	return positions
+}
+
+// writeError write the structured error into the response
+func writeError(ctx context.Context, w http.ResponseWriter, err error) {
+	status, resp := newErrorResponse(ctx, err)
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("cue.server.error.type", string(resp.Type)), attribute.Int("http.response.status_code", status))
+	span.SetStatus(codes.Error, resp.Message)
+	bs, e := json.Marshal(resp)
+	if e != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set(restful.HEADER_ContentType, restful.MIME_JSON)
+	w.WriteHeader(status)
+	_, _ = w.Write(bs)
+}
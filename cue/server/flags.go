@@ -0,0 +1,37 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"github.com/spf13/pflag"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+)
+
+// AddFlags add flags for the cue and cuex compile server
+func AddFlags(set *pflag.FlagSet) {
+	set.BoolVarP(&CuexServerRequestIdentity,
+		"cuex-server-request-identity", "", CuexServerRequestIdentity,
+		"Run the provider functions of the cuex compile server with the identity of the requesting user, which requires the call permission on providers.cue.oam.dev for each function. Set to false to run them with the identity of the server.")
+	set.IntVarP(&MaxBatchItems, "cuex-server-max-batch-items", "", MaxBatchItems,
+		"The max number of items in one batch compile request, no limit if not positive.")
+	set.IntVarP(&MaxBatchParallelism, "cuex-server-max-batch-parallelism", "", MaxBatchParallelism,
+		"The max parallelism for compiling the items of one batch compile request.")
+	set.Int64VarP(&MaxRequestBodySize, "cuex-server-max-request-body-size", "", MaxRequestBodySize,
+		"The max size in bytes of the request body for the cue and cuex compile server, no limit if not positive.")
+	cuexruntime.AddTracingFlags(set)
+}
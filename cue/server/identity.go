@@ -0,0 +1,191 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/util/singleton"
+)
+
+// ProviderResource the virtual resource used for authorizing the provider
+// function calls. The provider name is used as the resource name and the
+// function name is used as the subresource, e.g. the rule for kube.#Apply is
+// {apiGroups: ["cue.oam.dev"], resources: ["providers/apply"], resourceNames: ["kube"], verbs: ["call"]}
+var ProviderResource = schema.GroupResource{Group: "cue.oam.dev", Resource: "providers"}
+
+// VerbCall the verb used for authorizing the provider function calls
+const VerbCall = "call"
+
+// WithRequestIdentity compile with the identity of the requesting user, which
+// is set by the authentication of the apiserver. The kubernetes client used by
+// providers impersonates the user and the provider function calls are
+// authorized by the Authorizer if set.
+type WithRequestIdentity struct {
+	Authorizer authorizer.Authorizer
+	NewClient  func(user.Info) (client.Client, error)
+}
+
+// ApplyTo .
+func (in WithRequestIdentity) ApplyTo(s *CompileServer) {
+	identity := in
+	if identity.NewClient == nil {
+		identity.NewClient = NewImpersonatedClient
+	}
+	s.identity = &identity
+}
+
+// withUser attach the impersonated client and the authorizer of the
+// requesting user to the context
+func (in *WithRequestIdentity) withUser(ctx context.Context) (context.Context, error) {
+	u, ok := request.UserFrom(ctx)
+	if !ok || u == nil {
+		return ctx, newCompileError(ErrorTypeUnauthorized, http.StatusUnauthorized, fmt.Errorf("unauthenticated request"))
+	}
+	cli, err := in.NewClient(u)
+	if err != nil {
+		return ctx, newCompileError(ErrorTypeInternal, http.StatusInternalServerError, fmt.Errorf("failed to create client for user %s: %w", u.GetName(), err))
+	}
+	ctx = cuexruntime.WithKubeClient(ctx, cli)
+	if in.Authorizer != nil {
+		ctx = cuexruntime.WithAuthorizer(ctx, newProviderAuthorizer(in.Authorizer, u))
+	}
+	return ctx, nil
+}
+
+func newProviderAuthorizer(a authorizer.Authorizer, u user.Info) cuexruntime.Authorizer {
+	return cuexruntime.AuthorizerFunc(func(ctx context.Context, provider string, fn string) error {
+		decision, reason, err := a.Authorize(ctx, authorizer.AttributesRecord{
+			User:            u,
+			Verb:            VerbCall,
+			APIGroup:        ProviderResource.Group,
+			Resource:        ProviderResource.Resource,
+			Subresource:     fn,
+			Name:            provider,
+			ResourceRequest: true,
+		})
+		if err != nil {
+			return apierrors.NewForbidden(ProviderResource, provider, fmt.Errorf("failed to authorize function %s: %w", fn, err))
+		}
+		if decision != authorizer.DecisionAllow {
+			if reason == "" {
+				reason = fmt.Sprintf("user %s cannot call function %s", u.GetName(), fn)
+			}
+			return apierrors.NewForbidden(ProviderResource, provider, fmt.Errorf("%s", reason))
+		}
+		return nil
+	})
+}
+
+// ImpersonatedClientCacheTimeout the timeout of the cached clients which
+// impersonate the requesting users
+var ImpersonatedClientCacheTimeout = 10 * time.Minute
+
+var impersonatedClients = ttlcache.New[string, client.Client](
+	ttlcache.WithDisableTouchOnHit[string, client.Client](),
+)
+
+// NewImpersonatedClient create the kubernetes client impersonating the user.
+// The clients are cached by the user identity for ImpersonatedClientCacheTimeout.
+func NewImpersonatedClient(u user.Info) (client.Client, error) {
+	impersonate := rest.ImpersonationConfig{
+		UserName: u.GetName(),
+		UID:      u.GetUID(),
+		Groups:   u.GetGroups(),
+		Extra:    u.GetExtra(),
+	}
+	bs, err := json.Marshal(impersonate)
+	if err != nil {
+		return nil, err
+	}
+	key := string(bs)
+	if item := impersonatedClients.Get(key); item != nil {
+		return item.Value(), nil
+	}
+	cfg := rest.CopyConfig(singleton.KubeConfig.Get())
+	cfg.Impersonate = impersonate
+	cli, err := client.New(cfg, client.Options{Scheme: scheme.Scheme, Mapper: singleton.RESTMapper.Get()})
+	if err != nil {
+		return nil, err
+	}
+	impersonatedClients.DeleteExpired()
+	impersonatedClients.Set(key, cli, ImpersonatedClientCacheTimeout)
+	return cli, nil
+}
+
+type subjectAccessReviewAuthorizer struct {
+	cli func() kubernetes.Interface
+}
+
+// NewSubjectAccessReviewAuthorizer create the authorizer which creates
+// SubjectAccessReview with the default kubernetes client
+func NewSubjectAccessReviewAuthorizer() authorizer.Authorizer {
+	return &subjectAccessReviewAuthorizer{cli: singleton.StaticClient.Get}
+}
+
+// Authorize .
+func (in *subjectAccessReviewAuthorizer) Authorize(ctx context.Context, attrs authorizer.Attributes) (authorizer.Decision, string, error) {
+	sar := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   attrs.GetNamespace(),
+				Verb:        attrs.GetVerb(),
+				Group:       attrs.GetAPIGroup(),
+				Resource:    attrs.GetResource(),
+				Subresource: attrs.GetSubresource(),
+				Name:        attrs.GetName(),
+			},
+		},
+	}
+	if u := attrs.GetUser(); u != nil {
+		sar.Spec.User, sar.Spec.UID, sar.Spec.Groups = u.GetName(), u.GetUID(), u.GetGroups()
+		sar.Spec.Extra = map[string]authorizationv1.ExtraValue{}
+		for k, v := range u.GetExtra() {
+			sar.Spec.Extra[k] = v
+		}
+	}
+	ret, err := in.cli().AuthorizationV1().SubjectAccessReviews().Create(ctx, sar, metav1.CreateOptions{})
+	if err != nil {
+		return authorizer.DecisionNoOpinion, "", err
+	}
+	switch {
+	case ret.Status.Allowed:
+		return authorizer.DecisionAllow, ret.Status.Reason, nil
+	case ret.Status.Denied:
+		return authorizer.DecisionDeny, ret.Status.Reason, nil
+	default:
+		return authorizer.DecisionNoOpinion, ret.Status.Reason, nil
+	}
+}
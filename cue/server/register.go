@@ -28,11 +28,18 @@ import (
 )
 
 const (
-	cuePath     = "/cue"
-	cuexPath    = "/cuex"
-	compilePath = "/compile"
+	cuePath          = "/cue"
+	cuexPath         = "/cuex"
+	compilePath      = "/compile"
+	batchCompilePath = "/batch-compile"
+	assistPath       = "/assist"
 )
 
+// CuexServerRequestIdentity if set, the cuex compile server runs the provider
+// functions with the identity of the requesting user instead of the server's.
+// The user needs the call permission on the ProviderResource for each function.
+var CuexServerRequestIdentity = true
+
 // RegisterGenericAPIServer register cue & cuex compile path to apiserver
 func RegisterGenericAPIServer(server *server.GenericAPIServer) *server.GenericAPIServer {
 	server = RegisterCueServerToGenericAPIServer(server)
@@ -40,14 +47,16 @@ func RegisterGenericAPIServer(server *server.GenericAPIServer) *server.GenericAP
 	return server
 }
 
-// RegisterCueServerToGenericAPIServer register cue compile path to apiserver
+// RegisterCueServerToGenericAPIServer register cue compile path to apiserver,
+// the plain cue server does not load the templates
 func RegisterCueServerToGenericAPIServer(server *server.GenericAPIServer) *server.GenericAPIServer {
 	ws := &restful.WebService{}
 	ws.Path(cuePath)
-	ws.Route(ws.POST(compilePath).To(
-		NewCompileServer(func(ctx context.Context, s string) (cue.Value, error) {
-			return cuecontext.New().CompileString(s), nil
-		}).Handle))
+	compileServer := NewCompileServer(func(ctx context.Context, s string) (cue.Value, error) {
+		return cuecontext.New().CompileString(s), nil
+	}, WithTemplateLoader(nil))
+	ws.Route(ws.POST(compilePath).To(compileServer.Handle))
+	ws.Route(ws.POST(batchCompilePath).To(compileServer.HandleBatch))
 	server.Handler.GoRestfulContainer.Add(ws)
 	return server
 }
@@ -56,7 +65,14 @@ func RegisterCueServerToGenericAPIServer(server *server.GenericAPIServer) *serve
 func RegisterCuexServerToGenericAPIServer(server *server.GenericAPIServer) *server.GenericAPIServer {
 	ws := &restful.WebService{}
 	ws.Path(cuexPath)
-	ws.Route(ws.POST(compilePath).To(NewCompileServer(cuex.CompileString).Handle))
+	var opts []CompileServerOption
+	if CuexServerRequestIdentity {
+		opts = append(opts, WithRequestIdentity{Authorizer: NewSubjectAccessReviewAuthorizer()})
+	}
+	compileServer := NewCompileServer(cuex.CompileString, opts...)
+	ws.Route(ws.POST(compilePath).To(compileServer.Handle))
+	ws.Route(ws.POST(batchCompilePath).To(compileServer.HandleBatch))
+	ws.Route(ws.POST(assistPath).To(NewAssistServer(cuex.Assist).Handle))
 	server.Handler.GoRestfulContainer.Add(ws)
 	return server
 }
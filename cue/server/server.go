@@ -18,73 +18,227 @@ package server
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/parser"
 	"github.com/emicklei/go-restful/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
 	"github.com/kubevela/pkg/cue/util"
+	"github.com/kubevela/pkg/util/template/definition"
 )
 
+// MaxRequestBodySize the max size in bytes of the request body, no limit if
+// not positive
+var MaxRequestBodySize int64 = 4 << 20
+
 // CompileFn function for compile
 type CompileFn func(context.Context, string) (cue.Value, error)
 
 const (
-	paramKeyPath = "path"
-	mimeYaml     = "application/yaml"
-	mimeCue      = "application/cue"
+	paramKeyPath      = "path"
+	paramKeyTemplate  = "template"
+	paramKeyType      = "type"
+	paramKeyNamespace = "namespace"
+	mimeYaml          = "application/yaml"
+	mimeCue           = "application/cue"
+	mimeToml          = "application/toml"
+	mimeOpenAPI       = "application/openapi+json"
+	mimeK8sManifests  = "application/k8s-manifests+yaml"
+	mimeK8sList       = "application/k8s-list+json"
 )
 
+// mimeFormats the print formats for the Accept header
+var mimeFormats = map[string]util.PrintFormat{
+	restful.MIME_JSON: util.PrintFormatJson,
+	mimeYaml:          util.PrintFormatYaml,
+	mimeCue:           util.PrintFormatCue,
+	mimeToml:          util.PrintFormatToml,
+	mimeOpenAPI:       util.PrintFormatOpenAPI,
+	mimeK8sManifests:  util.PrintFormatK8sManifests,
+	mimeK8sList:       util.PrintFormatK8sList,
+}
+
 // CompileServer server for compile cue value
 type CompileServer struct {
-	fn CompileFn
+	fn           CompileFn
+	loadTemplate TemplateLoadFn
+	identity     *WithRequestIdentity
+}
+
+// CompileServerOption options for CompileServer
+type CompileServerOption interface {
+	ApplyTo(*CompileServer)
 }
 
 // NewCompileServer create CompileServer
-func NewCompileServer(fn CompileFn) *CompileServer {
-	return &CompileServer{fn: fn}
+func NewCompileServer(fn CompileFn, opts ...CompileServerOption) *CompileServer {
+	in := &CompileServer{fn: fn, loadTemplate: DefaultTemplateLoadFn}
+	for _, opt := range opts {
+		opt.ApplyTo(in)
+	}
+	return in
 }
 
-// ServeHTTP .
-func (in *CompileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	bs, err := io.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("read request body error: %s", err.Error()), http.StatusBadRequest)
-		return
+// CompileRequest the request for compiling one document. Either the raw CUE
+// source or the name of the template with parameters should be set.
+type CompileRequest struct {
+	Name       string           `json:"name,omitempty"`
+	Source     string           `json:"source,omitempty"`
+	Template   string           `json:"template,omitempty"`
+	Type       string           `json:"type,omitempty"`
+	Namespace  string           `json:"namespace,omitempty"`
+	Parameters json.RawMessage  `json:"parameters,omitempty"`
+	Paths      []string         `json:"paths,omitempty"`
+	Format     util.PrintFormat `json:"format,omitempty"`
+}
+
+// compile the request and print the value in the requested format
+func (in *CompileServer) compile(ctx context.Context, req CompileRequest) ([]byte, error) {
+	if in.identity != nil {
+		var err error
+		if ctx, err = in.identity.withUser(ctx); err != nil {
+			return nil, err
+		}
+	}
+	src := req.Source
+	if req.Template != "" {
+		if in.loadTemplate == nil {
+			return nil, newCompileError(ErrorTypeBadRequest, http.StatusBadRequest, fmt.Errorf("template loader is not configured"))
+		}
+		if req.Namespace != "" {
+			ctx = definition.WithNamespace(ctx, req.Namespace)
+		}
+		tmpl, err := in.loadTemplate(ctx, req.Template, req.Type)
+		if err != nil {
+			return nil, newCompileError(ErrorTypeBadRequest, http.StatusBadRequest, fmt.Errorf("load template error: %w", err))
+		}
+		src = tmpl
+		if len(req.Parameters) > 0 {
+			if !json.Valid(req.Parameters) {
+				return nil, newCompileError(ErrorTypeBadRequest, http.StatusBadRequest, fmt.Errorf("parameters of the template must be valid json"))
+			}
+			src += "\n" + templateParameterKey + ": " + string(req.Parameters)
+		}
+	}
+	if _, err := parser.ParseFile("-", src); err != nil {
+		return nil, compileError{error: err, typ: ErrorTypeParse, status: http.StatusBadRequest, msg: "parse cue error: " + err.Error()}
 	}
-	val, err := in.fn(r.Context(), string(bs))
+	ctx = cuexruntime.WithSensitiveValues(ctx)
+	val, err := in.fn(ctx, src)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("compile cue error: %s", err.Error()), http.StatusBadRequest)
-		return
+		return nil, classifyError(ctx, err)
 	}
 	var options []util.PrintOption
-	if path := r.URL.Query().Get(paramKeyPath); len(path) > 0 {
-		options = append(options, util.WithPath(path))
-	}
-	switch r.Header.Get(restful.HEADER_Accept) {
-	case mimeCue:
-		w.Header().Set(restful.HEADER_ContentEncoding, mimeCue)
-		options = append(options, util.WithFormat(util.PrintFormatCue))
-	case mimeYaml:
-		w.Header().Set(restful.HEADER_ContentEncoding, mimeYaml)
-		options = append(options, util.WithFormat(util.PrintFormatYaml))
+	if sv, _ := cuexruntime.GetSensitiveValues(ctx); sv.Len() > 0 {
+		options = append(options, util.WithRedactor(sv.Redact))
+	}
+	switch paths := nonEmpty(req.Paths); len(paths) {
+	case 0:
+	case 1:
+		options = append(options, util.WithPath(paths[0]))
 	default:
-		w.Header().Set(restful.HEADER_ContentEncoding, restful.MIME_JSON)
-		options = append(options, util.WithFormat(util.PrintFormatJson))
+		val = selectPaths(val, paths)
+	}
+	options = append(options, util.WithFormat(req.Format))
+	bs, err := util.Print(val, options...)
+	if err != nil {
+		return nil, compileError{error: err, typ: ErrorTypeUnification, status: http.StatusBadRequest, msg: "content encode error: " + err.Error()}
+	}
+	return bs, nil
+}
+
+// selectPaths build the value with the selected paths as the keys
+func selectPaths(val cue.Value, paths []string) cue.Value {
+	ret := val.Context().CompileString("{}")
+	for _, p := range paths {
+		ret = ret.FillPath(cue.MakePath(cue.Str(p)), val.LookupPath(cue.ParsePath(p)))
 	}
-	bs, err = util.Print(val, options...)
+	return ret
+}
+
+func nonEmpty(arr []string) []string {
+	var ret []string
+	for _, s := range arr {
+		if s = strings.TrimSpace(s); s != "" {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
+
+// startSpan continue the trace propagated in the request headers and start
+// the span for handling the request
+func startSpan(r *http.Request, name string) (*http.Request, trace.Span) {
+	ctx, span := cuexruntime.StartSpan(cuexruntime.ContextFromHeaders(r), name)
+	span.SetAttributes(attribute.String("http.route", r.URL.Path))
+	return r.WithContext(ctx), span
+}
+
+// readBody read the request body bounded by MaxRequestBodySize
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	body := r.Body
+	if MaxRequestBodySize > 0 {
+		body = http.MaxBytesReader(w, r.Body, MaxRequestBodySize)
+	}
+	bs, err := io.ReadAll(body)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("content encode error: %s", err.Error()), http.StatusBadRequest)
+		if maxBytesErr := (*http.MaxBytesError)(nil); errors.As(err, &maxBytesErr) {
+			return nil, newCompileError(ErrorTypeBadRequest, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", maxBytesErr.Limit))
+		}
+		return nil, newCompileError(ErrorTypeBadRequest, http.StatusBadRequest, fmt.Errorf("read request body error: %w", err))
+	}
+	return bs, nil
+}
+
+// ServeHTTP .
+func (in *CompileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "cue.server.Compile")
+	defer span.End()
+	// attach the sensitive values before compiling so that the error
+	// responses are redacted with the values marked during the compile
+	r = r.WithContext(cuexruntime.WithSensitiveValues(r.Context()))
+	bs, err := readBody(w, r)
+	if err != nil {
+		writeError(r.Context(), w, err)
 		return
 	}
-	if _, err = w.Write(bs); err != nil {
-		http.Error(w, fmt.Sprintf("unexpected error when writing response: %s", err.Error()), http.StatusInternalServerError)
+	query := r.URL.Query()
+	req := CompileRequest{
+		Source:    string(bs),
+		Template:  query.Get(paramKeyTemplate),
+		Type:      query.Get(paramKeyType),
+		Namespace: query.Get(paramKeyNamespace),
+		Paths:     query[paramKeyPath],
+	}
+	if req.Template != "" {
+		req.Source, req.Parameters = "", bs
+		span.SetAttributes(attribute.String("cue.server.template", req.Template), attribute.String("cue.server.type", req.Type))
+	}
+	mime := r.Header.Get(restful.HEADER_Accept)
+	format, ok := mimeFormats[mime]
+	if !ok {
+		mime, format = restful.MIME_JSON, util.PrintFormatJson
+	}
+	w.Header().Set(restful.HEADER_ContentEncoding, mime)
+	req.Format = format
+	bs, err = in.compile(r.Context(), req)
+	if err != nil {
+		writeError(r.Context(), w, err)
 		return
 	}
 	w.WriteHeader(http.StatusOK)
-	return
+	if _, err = w.Write(bs); err != nil {
+		writeError(r.Context(), w, fmt.Errorf("unexpected error when writing response: %w", err))
+	}
 }
 
 // Handle rest request
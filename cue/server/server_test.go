@@ -19,6 +19,8 @@ package server_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"testing"
@@ -26,11 +28,32 @@ import (
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/cuecontext"
 	"github.com/emicklei/go-restful/v3"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
 	"github.com/kubevela/pkg/cue/cuex"
+	"github.com/kubevela/pkg/cue/cuex/providers"
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
 	cueserver "github.com/kubevela/pkg/cue/server"
+	utilruntime "github.com/kubevela/pkg/util/runtime"
+	"github.com/kubevela/pkg/util/singleton"
 )
 
 func TestRegisterGenericAPIServer(t *testing.T) {
@@ -38,6 +61,31 @@ func TestRegisterGenericAPIServer(t *testing.T) {
 		GoRestfulContainer: restful.NewContainer(),
 	}}
 	cueserver.RegisterGenericAPIServer(s)
+	raw, err := http.NewRequest(http.MethodPost, "/cue/compile?template=webservice", bytes.NewReader([]byte(`{}`)))
+	require.NoError(t, err)
+	writer := &FakeResponseWriter{}
+	s.Handler.GoRestfulContainer.ServeHTTP(writer, raw)
+	require.Equal(t, http.StatusBadRequest, writer.StatusCode)
+	require.Contains(t, writer.String(), "template loader is not configured")
+}
+
+func TestAddFlags(t *testing.T) {
+	defer func() { cueserver.CuexServerRequestIdentity = true }()
+	set := pflag.NewFlagSet("-", pflag.ContinueOnError)
+	cueserver.AddFlags(set)
+	require.True(t, cueserver.CuexServerRequestIdentity)
+	require.NoError(t, set.Parse([]string{"--cuex-server-request-identity=false"}))
+	require.False(t, cueserver.CuexServerRequestIdentity)
+	defer func(items, parallelism int) {
+		cueserver.MaxBatchItems, cueserver.MaxBatchParallelism = items, parallelism
+	}(cueserver.MaxBatchItems, cueserver.MaxBatchParallelism)
+	require.NoError(t, set.Parse([]string{"--cuex-server-max-batch-items=10", "--cuex-server-max-batch-parallelism=4"}))
+	require.Equal(t, 10, cueserver.MaxBatchItems)
+	require.Equal(t, 4, cueserver.MaxBatchParallelism)
+	defer func(size int64) { cueserver.MaxRequestBodySize = size }(cueserver.MaxRequestBodySize)
+	require.NoError(t, set.Parse([]string{"--cuex-server-max-request-body-size=1024"}))
+	require.Equal(t, int64(1024), cueserver.MaxRequestBodySize)
+	require.NotNil(t, set.Lookup("cuex-tracing-exporter"))
 }
 
 type FakeResponseWriter struct {
@@ -124,6 +172,33 @@ func TestHandleRequest(t *testing.T) {
 			StatusCode: http.StatusOK,
 			Output:     []byte("z: 5"),
 		},
+		"toml-format": {
+			Body:       []byte(`x: y: z: 5`),
+			Path:       "x",
+			Format:     "application/toml",
+			StatusCode: http.StatusOK,
+			Output:     []byte("[y]\nz = 5\n"),
+		},
+		"k8s-manifests-format": {
+			Body:       []byte(`x: [{apiVersion: "v1", kind: "ConfigMap"}, {apiVersion: "v1", kind: "Secret"}]`),
+			Path:       "x",
+			Format:     "application/k8s-manifests+yaml",
+			StatusCode: http.StatusOK,
+			Output:     []byte("apiVersion: v1\nkind: ConfigMap\n---\napiVersion: v1\nkind: Secret\n"),
+		},
+		"k8s-list-format": {
+			Body:       []byte(`x: [{apiVersion: "v1", kind: "ConfigMap"}]`),
+			Path:       "x",
+			Format:     "application/k8s-list+json",
+			StatusCode: http.StatusOK,
+			Output:     []byte(`{"apiVersion":"v1","items":[{"apiVersion":"v1","kind":"ConfigMap"}],"kind":"List"}`),
+		},
+		"openapi-format": {
+			Body:       []byte(`#X: {a: string}`),
+			Format:     "application/openapi+json",
+			StatusCode: http.StatusOK,
+			Output:     []byte(`{"openapi":"3.0.0","info":{"title":"Generated by cue.","version":"no version"},"paths":{},"components":{"schemas":{"X":{"type":"object","required":["a"],"properties":{"a":{"type":"string"}}}}}}`),
+		},
 	}
 	cueServer := cueserver.NewCompileServer(func(ctx context.Context, s string) (cue.Value, error) {
 		return cuecontext.New().CompileString(s), nil
@@ -153,3 +228,420 @@ func TestHandleRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleRequestWithSensitiveValues(t *testing.T) {
+	cueServer := cueserver.NewCompileServer(func(ctx context.Context, s string) (cue.Value, error) {
+		cuexruntime.MarkSensitive(ctx, "p@ss")
+		return cuecontext.New().CompileString(s), nil
+	})
+	raw, err := http.NewRequest("", "?path=x", bytes.NewReader([]byte(`x: url: "https://admin:p@ss@example.com"`)))
+	require.NoError(t, err)
+	writer := &FakeResponseWriter{}
+	cueServer.Handle(restful.NewRequest(raw), restful.NewResponse(writer))
+	require.Equal(t, http.StatusOK, writer.StatusCode)
+	require.Equal(t, `{"url":"https://admin:<redacted>@example.com"}`, writer.String())
+}
+
+func TestHandleRequestSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	defer func(tp oteltrace.TracerProvider) { otel.SetTracerProvider(tp) }(otel.GetTracerProvider())
+	otel.SetTracerProvider(tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder)))
+	cuexServer := cueserver.NewCompileServer(cuex.NewCompilerWithDefaultInternalPackages().CompileString)
+
+	const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	raw, err := http.NewRequest("", "?path=x", bytes.NewReader([]byte(`x: y: 1`)))
+	require.NoError(t, err)
+	raw.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	writer := &FakeResponseWriter{}
+	cuexServer.Handle(restful.NewRequest(raw), restful.NewResponse(writer))
+	require.Equal(t, http.StatusOK, writer.StatusCode)
+
+	spans := map[string]tracesdk.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	require.Contains(t, spans, "cue.server.Compile")
+	require.Contains(t, spans, "cuex.Compile")
+	require.Equal(t, traceID, spans["cue.server.Compile"].SpanContext().TraceID().String())
+	require.Equal(t, parentID, spans["cue.server.Compile"].Parent().SpanID().String())
+	require.Equal(t, spans["cue.server.Compile"].SpanContext().SpanID(), spans["cuex.Compile"].Parent().SpanID())
+
+	raw, err = http.NewRequest("", "", bytes.NewReader([]byte(`x: 1 & 2`)))
+	require.NoError(t, err)
+	writer = &FakeResponseWriter{}
+	cuexServer.Handle(restful.NewRequest(raw), restful.NewResponse(writer))
+	require.Equal(t, http.StatusBadRequest, writer.StatusCode)
+	ended := recorder.Ended()
+	require.Equal(t, codes.Error, ended[len(ended)-1].Status().Code)
+}
+
+func TestHandleRequestRedactErrorDetails(t *testing.T) {
+	compiler := cuex.NewCompilerWithInternalPackages(
+		utilruntime.Must(cuexruntime.NewInternalPackage("test", "", map[string]cuexruntime.ProviderFn{
+			"read": cuexruntime.GenericProviderFn[providers.Params[string], providers.Returns[string]](func(ctx context.Context, t *providers.Params[string]) (*providers.Returns[string], error) {
+				cuexruntime.MarkSensitive(ctx, "s3cr3t")
+				return &providers.Returns[string]{Returns: "s3cr3t"}, nil
+			}),
+		})),
+	)
+	cuexServer := cueserver.NewCompileServer(compiler.CompileString)
+	src := `
+		read: {
+			#do: "read"
+			#provider: "test"
+			$params: ""
+		}
+		use: {
+			#do: "read"
+			#provider: "test"
+			$params: "other" & read.$returns
+		}`
+
+	raw, err := http.NewRequest("", "", bytes.NewReader([]byte(src)))
+	require.NoError(t, err)
+	writer := &FakeResponseWriter{}
+	cuexServer.Handle(restful.NewRequest(raw), restful.NewResponse(writer))
+	require.Equal(t, http.StatusInternalServerError, writer.StatusCode)
+	resp := &cueserver.ErrorResponse{}
+	require.NoError(t, json.Unmarshal(writer.Bytes(), resp))
+	require.NotEmpty(t, resp.Details)
+	require.Contains(t, resp.Details[0].Message, cuexruntime.RedactedPlaceholder)
+	require.NotContains(t, writer.String(), "s3cr3t")
+
+	bs, err := json.Marshal(cueserver.BatchCompileRequest{Items: []cueserver.CompileRequest{{Name: "a", Source: src}}})
+	require.NoError(t, err)
+	raw, err = http.NewRequest("", "", bytes.NewReader(bs))
+	require.NoError(t, err)
+	writer = &FakeResponseWriter{}
+	cuexServer.HandleBatch(restful.NewRequest(raw), restful.NewResponse(writer))
+	require.Equal(t, http.StatusOK, writer.StatusCode)
+	batch := &cueserver.BatchCompileResponse{}
+	require.NoError(t, json.Unmarshal(writer.Bytes(), batch))
+	require.Len(t, batch.Items, 1)
+	require.NotNil(t, batch.Items[0].Error)
+	require.NotEmpty(t, batch.Items[0].Error.Details)
+	require.Contains(t, batch.Items[0].Error.Details[0].Message, cuexruntime.RedactedPlaceholder)
+	require.NotContains(t, writer.String(), "s3cr3t")
+}
+
+func TestHandleRequestWithMultiplePaths(t *testing.T) {
+	cueServer := cueserver.NewCompileServer(func(ctx context.Context, s string) (cue.Value, error) {
+		return cuecontext.New().CompileString(s), nil
+	})
+	raw, err := http.NewRequest("", "?path=x.y&path=z", bytes.NewReader([]byte(`x: y: 1, z: [2], w: 3`)))
+	require.NoError(t, err)
+	writer := &FakeResponseWriter{}
+	cueServer.Handle(restful.NewRequest(raw), restful.NewResponse(writer))
+	require.Equal(t, http.StatusOK, writer.StatusCode)
+	require.JSONEq(t, `{"x.y":1,"z":[2]}`, writer.String())
+}
+
+func TestHandleRequestWithTemplate(t *testing.T) {
+	loader := cueserver.WithTemplateLoader(func(ctx context.Context, name string, typ string) (string, error) {
+		if name != "webservice" || typ != "component" {
+			return "", fmt.Errorf("template %s not found", name)
+		}
+		return `parameter: image: string
+output: spec: image: parameter.image`, nil
+	})
+	cueServer := cueserver.NewCompileServer(func(ctx context.Context, s string) (cue.Value, error) {
+		return cuecontext.New().CompileString(s), nil
+	}, loader)
+	for name, tt := range map[string]struct {
+		Query      string
+		Body       string
+		StatusCode int
+		Output     string
+	}{
+		"good":      {Query: "?template=webservice&type=component&path=output", StatusCode: http.StatusOK, Output: `{"spec":{"image":"nginx"}}`},
+		"not-found": {Query: "?template=worker&type=component", StatusCode: http.StatusBadRequest},
+		"invalid-parameters": {
+			Query:      "?template=webservice&type=component&path=output",
+			Body:       `{"image":"nginx"}, output: spec: image: "busybox"`,
+			StatusCode: http.StatusBadRequest,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			body := `{"image":"nginx"}`
+			if tt.Body != "" {
+				body = tt.Body
+			}
+			raw, err := http.NewRequest("", tt.Query, bytes.NewReader([]byte(body)))
+			require.NoError(t, err)
+			writer := &FakeResponseWriter{}
+			cueServer.Handle(restful.NewRequest(raw), restful.NewResponse(writer))
+			require.Equal(t, tt.StatusCode, writer.StatusCode)
+			if tt.StatusCode == http.StatusOK {
+				require.JSONEq(t, tt.Output, writer.String())
+			}
+		})
+	}
+}
+
+func TestHandleBatchRequest(t *testing.T) {
+	loader := cueserver.WithTemplateLoader(func(ctx context.Context, name string, typ string) (string, error) {
+		return `parameter: replicas: int
+output: replicas: parameter.replicas`, nil
+	})
+	cueServer := cueserver.NewCompileServer(func(ctx context.Context, s string) (cue.Value, error) {
+		return cuecontext.New().CompileString(s), nil
+	}, loader)
+
+	raw, err := http.NewRequest("", "", bytes.NewReader([]byte(`{"items": [
+		{"name": "a", "source": "x: y: 1", "paths": ["x"]},
+		{"name": "b", "template": "worker", "type": "component", "parameters": {"replicas": 3}, "paths": ["output"]},
+		{"name": "c", "source": "x: 1", "format": "yaml"},
+		{"name": "d", "source": "bad-key: bad value"}
+	], "parallelism": 2}`)))
+	require.NoError(t, err)
+	writer := &FakeResponseWriter{}
+	cueServer.HandleBatch(restful.NewRequest(raw), restful.NewResponse(writer))
+	require.Equal(t, http.StatusOK, writer.StatusCode)
+	resp := &cueserver.BatchCompileResponse{}
+	require.NoError(t, json.Unmarshal(writer.Bytes(), resp))
+	require.Len(t, resp.Items, 4)
+	require.JSONEq(t, `{"y":1}`, string(resp.Items[0].Result))
+	require.JSONEq(t, `{"replicas":3}`, string(resp.Items[1].Result))
+	require.Equal(t, "x: 1\n", resp.Items[2].Output)
+	require.Equal(t, "d", resp.Items[3].Name)
+	require.Equal(t, cueserver.ErrorTypeParse, resp.Items[3].Error.Type)
+
+	raw, err = http.NewRequest("", "", bytes.NewReader([]byte(`bad`)))
+	require.NoError(t, err)
+	writer = &FakeResponseWriter{}
+	cueServer.HandleBatch(restful.NewRequest(raw), restful.NewResponse(writer))
+	require.Equal(t, http.StatusBadRequest, writer.StatusCode)
+
+	defer func(n int) { cueserver.MaxBatchItems = n }(cueserver.MaxBatchItems)
+	cueserver.MaxBatchItems = 1
+	raw, err = http.NewRequest("", "", bytes.NewReader([]byte(`{"items": [{"source": "x: 1"}, {"source": "y: 1"}]}`)))
+	require.NoError(t, err)
+	writer = &FakeResponseWriter{}
+	cueServer.HandleBatch(restful.NewRequest(raw), restful.NewResponse(writer))
+	require.Equal(t, http.StatusBadRequest, writer.StatusCode)
+	require.Contains(t, writer.String(), "too many items")
+}
+
+func TestHandleRequestBodyTooLarge(t *testing.T) {
+	defer func(size int64) { cueserver.MaxRequestBodySize = size }(cueserver.MaxRequestBodySize)
+	cueserver.MaxRequestBodySize = 8
+	cueServer := cueserver.NewCompileServer(func(ctx context.Context, s string) (cue.Value, error) {
+		return cuecontext.New().CompileString(s), nil
+	})
+	raw, err := http.NewRequest("", "", bytes.NewReader([]byte(`x: y: z: 5`)))
+	require.NoError(t, err)
+	writer := &FakeResponseWriter{}
+	cueServer.Handle(restful.NewRequest(raw), restful.NewResponse(writer))
+	require.Equal(t, http.StatusRequestEntityTooLarge, writer.StatusCode)
+	require.Contains(t, writer.String(), "request body exceeds 8 bytes")
+
+	raw, err = http.NewRequest("", "", bytes.NewReader([]byte(`{"items": []}`)))
+	require.NoError(t, err)
+	writer = &FakeResponseWriter{}
+	cueServer.HandleBatch(restful.NewRequest(raw), restful.NewResponse(writer))
+	require.Equal(t, http.StatusRequestEntityTooLarge, writer.StatusCode)
+}
+
+func TestHandleRequestWithRequestIdentity(t *testing.T) {
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm", Namespace: "default"}, Data: map[string]string{"key": "value"}}
+	var impersonated string
+	identity := cueserver.WithRequestIdentity{
+		Authorizer: authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+			if a.GetVerb() == cueserver.VerbCall && a.GetResource() == "providers" && a.GetName() == "kube" && a.GetSubresource() == "get" {
+				return authorizer.DecisionAllow, "", nil
+			}
+			return authorizer.DecisionDeny, "not allowed", nil
+		}),
+		NewClient: func(u user.Info) (client.Client, error) {
+			impersonated = u.GetName()
+			return fake.NewClientBuilder().WithObjects(cm).Build(), nil
+		},
+	}
+	cuexServer := cueserver.NewCompileServer(cuex.NewCompilerWithDefaultInternalPackages().CompileString, identity)
+	for name, tt := range map[string]struct {
+		Body       string
+		User       user.Info
+		StatusCode int
+		Output     string
+	}{
+		"unauthenticated": {
+			Body:       `x: 1`,
+			StatusCode: http.StatusUnauthorized,
+		},
+		"allowed": {
+			Body: `
+				import "vela/kube"
+				cm: kube.#Get & {$params: resource: {apiVersion: "v1", kind: "ConfigMap", metadata: {name: "cm", namespace: "default"}}}
+				data: cm.$returns.data`,
+			User:       &user.DefaultInfo{Name: "alice"},
+			StatusCode: http.StatusOK,
+			Output:     `{"key":"value"}`,
+		},
+		"forbidden": {
+			Body: `
+				import "vela/base64"
+				data: base64.#Encode & {$params: "example"}`,
+			User:       &user.DefaultInfo{Name: "alice"},
+			StatusCode: http.StatusForbidden,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			raw, err := http.NewRequest("", "?path=data", bytes.NewReader([]byte(tt.Body)))
+			require.NoError(t, err)
+			if tt.User != nil {
+				raw = raw.WithContext(request.WithUser(raw.Context(), tt.User))
+			}
+			writer := &FakeResponseWriter{}
+			cuexServer.Handle(restful.NewRequest(raw), restful.NewResponse(writer))
+			require.Equal(t, tt.StatusCode, writer.StatusCode, writer.String())
+			if tt.StatusCode == http.StatusOK {
+				require.JSONEq(t, tt.Output, writer.String())
+				require.Equal(t, "alice", impersonated)
+			}
+		})
+	}
+}
+
+func TestNewImpersonatedClient(t *testing.T) {
+	singleton.KubeConfig.Set(&rest.Config{Host: "https://127.0.0.1:6443"})
+	singleton.RESTMapper.Set(meta.NewDefaultRESTMapper(nil))
+	alice, err := cueserver.NewImpersonatedClient(&user.DefaultInfo{Name: "alice", Groups: []string{"dev"}})
+	require.NoError(t, err)
+	cached, err := cueserver.NewImpersonatedClient(&user.DefaultInfo{Name: "alice", Groups: []string{"dev"}})
+	require.NoError(t, err)
+	require.Same(t, alice, cached)
+	admin, err := cueserver.NewImpersonatedClient(&user.DefaultInfo{Name: "alice", Groups: []string{"admin"}})
+	require.NoError(t, err)
+	require.NotSame(t, alice, admin)
+}
+
+func TestHandleRequestWithRequestIdentityReadSecret(t *testing.T) {
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "default"}, Data: map[string][]byte{"password": []byte("s3cr3t")}}
+	singleton.KubeClient.Set(fake.NewClientBuilder().WithObjects(secret).Build())
+	identity := cueserver.WithRequestIdentity{
+		NewClient: func(u user.Info) (client.Client, error) {
+			return fake.NewClientBuilder().WithObjects(secret).WithInterceptorFuncs(interceptor.Funcs{
+				Get: func(ctx context.Context, cli client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					if _, ok := obj.(*corev1.Secret); ok {
+						return apierrors.NewForbidden(corev1.Resource("secrets"), key.Name, fmt.Errorf("user %s cannot get secrets", u.GetName()))
+					}
+					return cli.Get(ctx, key, obj, opts...)
+				},
+			}).Build(), nil
+		},
+	}
+	body := `
+		import "vela/secret"
+		creds: secret.#Get & {$params: {namespace: "default", name: "creds", key: "password"}}
+		data: creds.$returns.value`
+	for name, opts := range map[string][]cueserver.CompileServerOption{
+		"request identity": {identity},
+		"server identity":  nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			cuexServer := cueserver.NewCompileServer(cuex.NewCompilerWithDefaultInternalPackages().CompileString, opts...)
+			raw, err := http.NewRequest("", "?path=data", bytes.NewReader([]byte(body)))
+			require.NoError(t, err)
+			raw = raw.WithContext(request.WithUser(raw.Context(), &user.DefaultInfo{Name: "alice"}))
+			writer := &FakeResponseWriter{}
+			cuexServer.Handle(restful.NewRequest(raw), restful.NewResponse(writer))
+			if opts == nil {
+				require.Equal(t, http.StatusOK, writer.StatusCode, writer.String())
+				return
+			}
+			require.Equal(t, http.StatusForbidden, writer.StatusCode, writer.String())
+			require.Contains(t, writer.String(), "alice cannot get secrets")
+			require.NotContains(t, writer.String(), "s3cr3t")
+		})
+	}
+}
+
+func TestHandleRequestErrors(t *testing.T) {
+	cueServer := cueserver.NewCompileServer(func(ctx context.Context, s string) (cue.Value, error) {
+		return cuecontext.New().CompileString(s), nil
+	})
+	cuexServer := cueserver.NewCompileServer(cuex.NewCompilerWithDefaultInternalPackages().CompileString)
+	timeoutServer := cueserver.NewCompileServer(func(ctx context.Context, s string) (cue.Value, error) {
+		return cue.Value{}, cuex.ResolveTimeoutErr{}
+	})
+	for name, tt := range map[string]struct {
+		Server     *cueserver.CompileServer
+		Body       string
+		StatusCode int
+		Expected   cueserver.ErrorResponse
+		Line       int
+	}{
+		"parse": {
+			Server:     cueServer,
+			Body:       "a: 1\nb: {",
+			StatusCode: http.StatusBadRequest,
+			Expected:   cueserver.ErrorResponse{Type: cueserver.ErrorTypeParse},
+			Line:       2,
+		},
+		"unification": {
+			Server:     cueServer,
+			Body:       "a: 1\na: 2",
+			StatusCode: http.StatusBadRequest,
+			Expected:   cueserver.ErrorResponse{Type: cueserver.ErrorTypeUnification, Path: "a"},
+			Line:       2,
+		},
+		"provider-not-found": {
+			Server:     cuexServer,
+			Body:       `x: {#do: "get", #provider: "unknown"}`,
+			StatusCode: http.StatusBadRequest,
+			Expected:   cueserver.ErrorResponse{Type: cueserver.ErrorTypeProviderNotFound, Provider: "unknown"},
+		},
+		"function-call": {
+			Server: cuexServer,
+			Body: `
+				import "vela/base64"
+				x: base64.#Decode & {$params: "!!!"}`,
+			StatusCode: http.StatusInternalServerError,
+			Expected:   cueserver.ErrorResponse{Type: cueserver.ErrorTypeFunctionCall, Path: "x", Provider: "base64", Function: "decode"},
+		},
+		"timeout": {
+			Server:     timeoutServer,
+			Body:       `x: 1`,
+			StatusCode: http.StatusGatewayTimeout,
+			Expected:   cueserver.ErrorResponse{Type: cueserver.ErrorTypeTimeout},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			raw, err := http.NewRequest("", "", bytes.NewReader([]byte(tt.Body)))
+			require.NoError(t, err)
+			writer := &FakeResponseWriter{}
+			tt.Server.Handle(restful.NewRequest(raw), restful.NewResponse(writer))
+			require.Equal(t, tt.StatusCode, writer.StatusCode, writer.String())
+			resp := cueserver.ErrorResponse{}
+			require.NoError(t, json.Unmarshal(writer.Bytes(), &resp))
+			require.NotEmpty(t, resp.Message)
+			require.Equal(t, tt.Expected.Type, resp.Type)
+			require.Equal(t, tt.Expected.Path, resp.Path)
+			require.Equal(t, tt.Expected.Provider, resp.Provider)
+			require.Equal(t, tt.Expected.Function, resp.Function)
+			if tt.Line > 0 {
+				require.NotEmpty(t, resp.Positions)
+				require.Equal(t, tt.Line, resp.Positions[0].Line)
+			}
+		})
+	}
+}
+
+func TestHandleAssistRequest(t *testing.T) {
+	assistServer := cueserver.NewAssistServer(cuex.NewCompilerWithDefaultInternalPackages().Assist)
+	body := `{"source": "import \"vela/kube\"\n\nx: kube.#G", "line": 3, "column": 11}`
+	raw, err := http.NewRequest("", "", bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+	writer := &FakeResponseWriter{}
+	assistServer.Handle(restful.NewRequest(raw), restful.NewResponse(writer))
+	res := &cuex.AssistResult{}
+	require.NoError(t, json.Unmarshal(writer.Bytes(), res))
+	require.Equal(t, "#Get", res.Fields[0].Label)
+	require.NotEmpty(t, res.Imports)
+
+	raw, err = http.NewRequest("", "", bytes.NewReader([]byte(`{`)))
+	require.NoError(t, err)
+	writer = &FakeResponseWriter{}
+	assistServer.Handle(restful.NewRequest(raw), restful.NewResponse(writer))
+	require.Equal(t, http.StatusBadRequest, writer.StatusCode)
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cuexruntime "github.com/kubevela/pkg/cue/cuex/runtime"
+	"github.com/kubevela/pkg/util/template/definition"
+)
+
+// templateParameterKey the field that the parameters are filled into
+const templateParameterKey = "parameter"
+
+// TemplateLoadFn load the template source by name and definition type
+type TemplateLoadFn func(ctx context.Context, name string, typ string) (string, error)
+
+// WithTemplateLoader set the loader for the named templates
+type WithTemplateLoader TemplateLoadFn
+
+// ApplyTo .
+func (in WithTemplateLoader) ApplyTo(s *CompileServer) {
+	s.loadTemplate = TemplateLoadFn(in)
+}
+
+// NewDefinitionTemplateLoadFn create TemplateLoadFn which loads the templates
+// from the definitions through definition.TemplateLoader
+func NewDefinitionTemplateLoadFn(cli client.Client) TemplateLoadFn {
+	return func(ctx context.Context, name string, typ string) (string, error) {
+		tmpl, err := definition.NewTemplateLoader(ctx, cli).LoadTemplate(ctx, name, definition.WithType(typ))
+		if err != nil {
+			return "", err
+		}
+		return tmpl.Compile(), nil
+	}
+}
+
+// DefaultTemplateLoadFn load the templates from the definitions with the
+// kubernetes client of the context, which impersonates the requesting user
+// when the request identity is enabled
+func DefaultTemplateLoadFn(ctx context.Context, name string, typ string) (string, error) {
+	return NewDefinitionTemplateLoadFn(cuexruntime.GetKubeClient(ctx))(ctx, name, typ)
+}
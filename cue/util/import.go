@@ -31,7 +31,7 @@ func BuildImport(path string, templates map[string]string) (*build.Instance, err
 		ImportPath: path,
 	}
 	for filename, template := range templates {
-		file, err := parser.ParseFile(filename, template)
+		file, err := parser.ParseFile(filename, template, parser.ParseComments)
 		if err != nil {
 			return nil, err
 		}
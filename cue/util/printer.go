@@ -17,9 +17,14 @@ limitations under the License.
 package util
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"strings"
 
 	"cuelang.org/go/cue"
+	"cuelang.org/go/encoding/openapi"
+	"github.com/pelletier/go-toml/v2"
 	"sigs.k8s.io/yaml"
 )
 
@@ -33,12 +38,21 @@ const (
 	PrintFormatYaml PrintFormat = "yaml"
 	// PrintFormatCue cue
 	PrintFormatCue PrintFormat = "cue"
+	// PrintFormatToml toml
+	PrintFormatToml PrintFormat = "toml"
+	// PrintFormatOpenAPI the OpenAPI schema of the definitions
+	PrintFormatOpenAPI PrintFormat = "openapi"
+	// PrintFormatK8sManifests the kubernetes objects in multi-document yaml
+	PrintFormatK8sManifests PrintFormat = "k8s-manifests"
+	// PrintFormatK8sList the kubernetes objects in v1.List
+	PrintFormatK8sList PrintFormat = "k8s-list"
 )
 
 // PrintConfig config for printing value
 type PrintConfig struct {
-	format PrintFormat
-	path   *cue.Path
+	format   PrintFormat
+	path     *cue.Path
+	redactor func(string) string
 }
 
 // PrintOption options for printing value
@@ -66,6 +80,14 @@ func (in WithPath) ApplyTo(cfg *PrintConfig) {
 	}
 }
 
+// WithRedactor set the function for redacting string values before printing
+type WithRedactor func(string) string
+
+// ApplyTo .
+func (in WithRedactor) ApplyTo(cfg *PrintConfig) {
+	cfg.redactor = in
+}
+
 // NewPrintConfig create print config
 func NewPrintConfig(options ...PrintOption) *PrintConfig {
 	cfg := &PrintConfig{format: PrintFormatCue}
@@ -81,6 +103,9 @@ func Print(value cue.Value, options ...PrintOption) ([]byte, error) {
 	if cfg.path != nil {
 		value = value.LookupPath(*cfg.path)
 	}
+	if cfg.redactor != nil {
+		value = Redact(value, cfg.redactor)
+	}
 	switch cfg.format {
 	case PrintFormatJson:
 		return value.MarshalJSON()
@@ -90,8 +115,150 @@ func Print(value cue.Value, options ...PrintOption) ([]byte, error) {
 			return nil, err
 		}
 		return yaml.JSONToYAML(bs)
+	case PrintFormatToml:
+		return printToml(value)
+	case PrintFormatOpenAPI:
+		return printOpenAPI(value)
+	case PrintFormatK8sManifests:
+		return printK8sManifests(value)
+	case PrintFormatK8sList:
+		return printK8sList(value)
 	default:
 		s, err := ToString(value)
 		return []byte(s), err
 	}
 }
+
+func printToml(value cue.Value) ([]byte, error) {
+	bs, err := value.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(bs))
+	decoder.UseNumber()
+	var obj map[string]interface{}
+	if err = decoder.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("toml format requires a struct value: %w", err)
+	}
+	return toml.Marshal(normalizeNumbers(obj))
+}
+
+// normalizeNumbers convert the json numbers into int64 or float64, so that
+// the integers are not printed as floats
+func normalizeNumbers(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = normalizeNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = normalizeNumbers(item)
+		}
+	}
+	return v
+}
+
+// printOpenAPI export the schemas of the definitions, the regular fields are
+// exported as definitions with the same name
+func printOpenAPI(value cue.Value) ([]byte, error) {
+	schemas := value.Context().CompileString("{}")
+	if value.IncompleteKind() != cue.StructKind {
+		schemas = schemas.FillPath(cue.MakePath(cue.Def("Value")), value)
+	} else {
+		it, err := value.Fields(cue.Definitions(true), cue.Optional(true))
+		if err != nil {
+			return nil, err
+		}
+		for it.Next() {
+			sel := it.Selector()
+			if !sel.IsDefinition() {
+				sel = cue.Def(sel.Unquoted())
+			}
+			schemas = schemas.FillPath(cue.MakePath(sel), it.Value())
+		}
+	}
+	if err := schemas.Err(); err != nil {
+		return nil, err
+	}
+	return openapi.Gen(schemas, &openapi.Config{ExpandReferences: true})
+}
+
+// collectK8sObjects find the kubernetes objects, which have apiVersion and
+// kind, in the value recursively
+func collectK8sObjects(value cue.Value) ([]json.RawMessage, error) {
+	var objects []json.RawMessage
+	switch value.IncompleteKind() {
+	case cue.StructKind:
+		apiVersion, _ := value.LookupPath(cue.ParsePath("apiVersion")).String()
+		kind, _ := value.LookupPath(cue.ParsePath("kind")).String()
+		if apiVersion != "" && kind != "" {
+			bs, err := value.MarshalJSON()
+			if err != nil {
+				return nil, err
+			}
+			return []json.RawMessage{bs}, nil
+		}
+		it, err := value.Fields()
+		if err != nil {
+			return nil, err
+		}
+		for it.Next() {
+			objs, err := collectK8sObjects(it.Value())
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, objs...)
+		}
+	case cue.ListKind:
+		it, err := value.List()
+		if err != nil {
+			return nil, err
+		}
+		for it.Next() {
+			objs, err := collectK8sObjects(it.Value())
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, objs...)
+		}
+	}
+	return objects, nil
+}
+
+func printK8sManifests(value cue.Value) ([]byte, error) {
+	objects, err := collectK8sObjects(value)
+	if err != nil {
+		return nil, err
+	}
+	var docs [][]byte
+	for _, obj := range objects {
+		bs, err := yaml.JSONToYAML(obj)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, bs)
+	}
+	return bytes.Join(docs, []byte("---\n")), nil
+}
+
+func printK8sList(value cue.Value) ([]byte, error) {
+	objects, err := collectK8sObjects(value)
+	if err != nil {
+		return nil, err
+	}
+	if objects == nil {
+		objects = []json.RawMessage{}
+	}
+	return json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      objects,
+	})
+}
@@ -80,3 +80,102 @@ func TestPrint(t *testing.T) {
 		})
 	}
 }
+
+func TestPrintFormats(t *testing.T) {
+	ctx := cuecontext.New()
+	val := ctx.CompileString(`
+		#Param: {
+			image:    string
+			replicas: *1 | int
+		}
+		parameter: {
+			name:  string
+			port?: int
+		}
+		config: {
+			name: "app"
+			server: port: 8080
+		}
+		outputs: {
+			cm: {
+				apiVersion: "v1"
+				kind:       "ConfigMap"
+				metadata: name: "cm"
+			}
+			list: [{
+				apiVersion: "v1"
+				kind:       "Secret"
+				metadata: name: "secret"
+			}]
+			other: x: 1
+		}
+	`)
+	testcases := map[string]struct {
+		Path   string
+		Format util.PrintFormat
+		Err    bool
+		Out    string
+	}{
+		"toml": {
+			Path:   "config",
+			Format: util.PrintFormatToml,
+			Out: `
+				name = 'app'
+
+				[server]
+				port = 8080
+			`,
+		},
+		"toml-not-struct": {
+			Path:   "config.name",
+			Format: util.PrintFormatToml,
+			Err:    true,
+		},
+		"openapi": {
+			Path:   "parameter",
+			Format: util.PrintFormatOpenAPI,
+			Out:    `{"openapi":"3.0.0","info":{"title":"Generated by cue.","version":"no version"},"paths":{},"components":{"schemas":{"name":{"type":"string"},"port":{"type":"integer"}}}}`,
+		},
+		"k8s-manifests": {
+			Path:   "outputs",
+			Format: util.PrintFormatK8sManifests,
+			Out: `
+				apiVersion: v1
+				kind: ConfigMap
+				metadata:
+				  name: cm
+				---
+				apiVersion: v1
+				kind: Secret
+				metadata:
+				  name: secret
+			`,
+		},
+		"k8s-list": {
+			Path:   "outputs",
+			Format: util.PrintFormatK8sList,
+			Out:    `{"apiVersion":"v1","items":[{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm"}},{"apiVersion":"v1","kind":"Secret","metadata":{"name":"secret"}}],"kind":"List"}`,
+		},
+		"k8s-list-empty": {
+			Path:   "config",
+			Format: util.PrintFormatK8sList,
+			Out:    `{"apiVersion":"v1","items":[],"kind":"List"}`,
+		},
+	}
+	for name, tt := range testcases {
+		t.Run(name, func(t *testing.T) {
+			bs, err := util.Print(val, util.WithFormat(tt.Format), util.WithPath(tt.Path))
+			if tt.Err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, stringtools.TrimLeadingIndent(tt.Out), stringtools.TrimLeadingIndent(string(bs)))
+		})
+	}
+
+	bs, err := util.Print(val, util.WithFormat(util.PrintFormatOpenAPI))
+	require.NoError(t, err)
+	require.Contains(t, string(bs), `"Param":{"type":"object","required":["image","replicas"]`)
+	require.Contains(t, string(bs), `"parameter":{"type":"object","required":["name"]`)
+}
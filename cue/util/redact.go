@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/literal"
+	"cuelang.org/go/cue/token"
+)
+
+// Redact rebuild the cue.Value with all string literals rewritten by the
+// given replace function, references are resolved during the rebuild
+func Redact(v cue.Value, replace func(string) string) cue.Value {
+	node := v.Syntax(cue.Final(), cue.Docs(true), cue.All())
+	ast.Walk(node, func(n ast.Node) bool {
+		lit, ok := n.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		s, err := literal.Unquote(lit.Value)
+		if err != nil {
+			return true
+		}
+		if r := replace(s); r != s {
+			form := literal.String
+			if strings.HasPrefix(strings.TrimLeft(lit.Value, "#"), "'") {
+				form = literal.Bytes
+			}
+			lit.Value = form.Quote(r)
+		}
+		return true
+	}, nil)
+	switch x := node.(type) {
+	case *ast.File:
+		return v.Context().BuildFile(x)
+	case ast.Expr:
+		return v.Context().BuildExpr(x)
+	default:
+		return v
+	}
+}
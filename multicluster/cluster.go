@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ListClusters list the names of managed clusters whose credential Secrets
+// match the given selector. The local cluster is not included.
+func ListClusters(ctx context.Context, cli client.Client, selector labels.Selector) ([]string, error) {
+	req, err := labels.NewRequirement(LabelClusterCredentialType, selection.Exists, nil)
+	if err != nil {
+		return nil, err
+	}
+	if selector == nil {
+		selector = labels.Everything()
+	}
+	// only the metadata is listed so that the credentials are not read
+	secrets := &metav1.PartialObjectMetadataList{}
+	secrets.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("SecretList"))
+	if err = cli.List(WithCluster(ctx, Local), secrets,
+		client.InNamespace(ClusterSecretNamespace),
+		client.MatchingLabelsSelector{Selector: selector.Add(*req)}); err != nil {
+		return nil, err
+	}
+	clusters := make([]string, 0, len(secrets.Items))
+	for _, secret := range secrets.Items {
+		clusters = append(clusters, secret.Name)
+	}
+	sort.Strings(clusters)
+	return clusters, nil
+}
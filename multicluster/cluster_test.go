@@ -0,0 +1,61 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multicluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestListClusters(t *testing.T) {
+	newSecret := func(name string, lbs map[string]string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ClusterSecretNamespace, Labels: lbs},
+			Data:       map[string][]byte{"token": []byte("secret")},
+		}
+	}
+	cli := fake.NewClientBuilder().WithObjects(
+		newSecret("beta", map[string]string{LabelClusterCredentialType: "X509", "env": "prod"}),
+		newSecret("alpha", map[string]string{LabelClusterCredentialType: "ServiceAccountToken", "env": "prod"}),
+		newSecret("gamma", map[string]string{LabelClusterCredentialType: "X509", "env": "dev"}),
+		newSecret("other", map[string]string{"env": "prod"}),
+	).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, cli client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if _, ok := list.(*metav1.PartialObjectMetadataList); !ok {
+				t.Fatalf("unexpected list type %T", list)
+			}
+			return cli.List(ctx, list, opts...)
+		},
+	}).Build()
+	ctx := context.Background()
+
+	clusters, err := ListClusters(ctx, cli, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"alpha", "beta", "gamma"}, clusters)
+
+	clusters, err = ListClusters(ctx, cli, labels.SelectorFromSet(map[string]string{"env": "prod"}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"alpha", "beta"}, clusters)
+}
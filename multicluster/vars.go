@@ -19,4 +19,10 @@ package multicluster
 const (
 	// Local is the name of the local cluster in KubeVela
 	Local string = "local"
+	// LabelClusterCredentialType is the label marking Secrets that hold the
+	// credential of a managed cluster
+	LabelClusterCredentialType = "cluster.core.oam.dev/cluster-credential-type"
 )
+
+// ClusterSecretNamespace is the namespace where cluster credential Secrets are stored
+var ClusterSecretNamespace = "vela-system"
@@ -17,11 +17,13 @@ limitations under the License.
 package cert
 
 import (
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"fmt"
 	"math/big"
 	"os"
 	"time"
@@ -70,6 +72,39 @@ func GenerateSelfSignedCertificate(keySize int, ca *x509.Certificate) ([]byte, [
 	return certData, keyData, nil
 }
 
+// ParseCertificatePEM parse the first certificate in the given pem data
+func ParseCertificatePEM(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("failed to decode certificate pem")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// ParsePrivateKeyPEM parse the private key in the given pem data, PKCS1, PKCS8
+// and EC private keys are supported
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode private key pem")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
 // GenerateCertificateRequest generate certificate request for given commonName
 // and organization. keySize is the size of the private key.
 // CertificateRequest and PrivateKey pem data are returned
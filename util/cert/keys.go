@@ -0,0 +1,82 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// KeyAlgorithm the algorithm of the private key
+type KeyAlgorithm string
+
+const (
+	// KeyAlgorithmRSA RSA key, the size is decided separately
+	KeyAlgorithmRSA KeyAlgorithm = "RSA"
+	// KeyAlgorithmECDSAP256 ECDSA key on the P-256 curve
+	KeyAlgorithmECDSAP256 KeyAlgorithm = "ECDSA-P256"
+	// KeyAlgorithmECDSAP384 ECDSA key on the P-384 curve
+	KeyAlgorithmECDSAP384 KeyAlgorithm = "ECDSA-P384"
+	// KeyAlgorithmEd25519 Ed25519 key
+	KeyAlgorithmEd25519 KeyAlgorithm = "Ed25519"
+)
+
+// GenerateKey generate the private key with the algorithm, rsaKeySize is only
+// used for the RSA key
+func GenerateKey(algorithm KeyAlgorithm, rsaKeySize int) (crypto.Signer, error) {
+	switch algorithm {
+	case KeyAlgorithmRSA, "":
+		return rsa.GenerateKey(rand.Reader, rsaKeySize)
+	case KeyAlgorithmECDSAP256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyAlgorithmECDSAP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case KeyAlgorithmEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm %s", algorithm)
+	}
+}
+
+// EncodePrivateKeyPEM encode the private key in pem format. RSA keys are
+// encoded as PKCS1, ECDSA keys as SEC1 and other keys as PKCS8.
+func EncodePrivateKeyPEM(key crypto.Signer) ([]byte, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}), nil
+	case *ecdsa.PrivateKey:
+		bs, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: bs}), nil
+	default:
+		bs, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: bs}), nil
+	}
+}
@@ -0,0 +1,318 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cert
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+const (
+	// CACertName the file name or the Secret key of the CA certificate
+	CACertName = "ca.crt"
+	// CAKeyName the file name or the Secret key of the CA private key
+	CAKeyName = "ca.key"
+	// CertName the file name of the leaf certificate
+	CertName = "tls.crt"
+	// KeyName the file name of the leaf private key
+	KeyName = "tls.key"
+
+	defaultCAValidity    = 365 * 24 * time.Hour
+	defaultCertValidity  = 7 * 24 * time.Hour
+	defaultCheckInterval = 10 * time.Minute
+)
+
+// CAStore the storage for the CA certificate and private key in pem format
+type CAStore interface {
+	// Load returns empty data without error if the CA does not exist
+	Load(ctx context.Context) (certPEM []byte, keyPEM []byte, err error)
+	// Save returns the AlreadyExists or Conflict error if the stored CA is
+	// changed by others since the last Load
+	Save(ctx context.Context, certPEM []byte, keyPEM []byte) error
+}
+
+// FileCAStore stores the CA in the directory as ca.crt and ca.key
+type FileCAStore string
+
+// Load .
+func (in FileCAStore) Load(_ context.Context) ([]byte, []byte, error) {
+	certPEM, err := os.ReadFile(filepath.Join(string(in), CACertName))
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(string(in), CAKeyName))
+	if err != nil {
+		return nil, nil, err
+	}
+	return certPEM, keyPEM, nil
+}
+
+// Save .
+func (in FileCAStore) Save(_ context.Context, certPEM []byte, keyPEM []byte) error {
+	return writeFiles(string(in), map[string][]byte{CACertName: certPEM, CAKeyName: keyPEM})
+}
+
+func writeFiles(dir string, files map[string][]byte) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	for name, data := range files {
+		// write to a temporary file first so that readers never see a partial file
+		tmp := filepath.Join(dir, "."+name+".tmp")
+		if err := os.WriteFile(tmp, data, 0600); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Manager issues the leaf certificate from the CA and rotates both of them
+// before they expire. The certificates are renewed when less than one third of
+// their lifetime remains.
+type Manager struct {
+	CommonName   string
+	Organization []string
+	DNSNames     []string
+	IPs          []net.IP
+	KeyAlgorithm KeyAlgorithm
+	// KeySize the size of the RSA private key
+	KeySize int
+
+	CAValidity    time.Duration
+	CertValidity  time.Duration
+	CheckInterval time.Duration
+
+	// CAStore stores the CA, the CA is only kept in memory if not set
+	CAStore CAStore
+	// Dir writes the leaf certificate and key into the directory as tls.crt
+	// and tls.key if set
+	Dir   string
+	Clock clock.PassiveClock
+
+	mu         sync.RWMutex
+	ca         *x509.Certificate
+	caCertPEM  []byte
+	caKeyPEM   []byte
+	previousCA *x509.Certificate
+	prevCAPEM  []byte
+	cert       *tls.Certificate
+	leaf       *x509.Certificate
+}
+
+// NewManager create the certificate manager for the leaf certificate with the
+// given subject alternative names
+func NewManager(commonName string, dnsNames []string, ips []net.IP) *Manager {
+	return &Manager{
+		CommonName:    commonName,
+		Organization:  []string{defaultOrganization},
+		DNSNames:      dnsNames,
+		IPs:           ips,
+		KeyAlgorithm:  KeyAlgorithmRSA,
+		KeySize:       defaultKeyLength,
+		CAValidity:    defaultCAValidity,
+		CertValidity:  defaultCertValidity,
+		CheckInterval: defaultCheckInterval,
+		Clock:         clock.RealClock{},
+	}
+}
+
+func needsRenewal(cert *x509.Certificate, now time.Time) bool {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	return now.After(cert.NotAfter.Add(-lifetime / 3))
+}
+
+// Rotate loads or generates the CA and issues the leaf certificate if they
+// do not exist or need renewal, returns if any of them is rotated. The CA in
+// the CAStore is always preferred so that the managers sharing the store use
+// the same CA, and the CA saved by others during the renewal is used instead.
+func (in *Manager) Rotate(ctx context.Context) (bool, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	now := in.Clock.Now()
+	rotated, err := in.loadCA(ctx)
+	if err != nil {
+		return false, err
+	}
+	if in.ca == nil || needsRenewal(in.ca, now) {
+		certPEM, keyPEM, err := in.newTemplateBuilder(in.CommonName + "-ca").
+			WithValidity(in.CAValidity).
+			AsCA().
+			SelfSigned()
+		if err != nil {
+			return false, fmt.Errorf("failed to generate ca: %w", err)
+		}
+		saveErr := error(nil)
+		if in.CAStore != nil {
+			saveErr = in.CAStore.Save(ctx, certPEM, keyPEM)
+		}
+		switch {
+		case saveErr == nil:
+			if err = in.useCA(certPEM, keyPEM); err != nil {
+				return false, err
+			}
+			rotated = true
+		case kerrors.IsAlreadyExists(saveErr) || kerrors.IsConflict(saveErr):
+			// the CA is saved by another manager, use it instead
+			loaded, err := in.loadCA(ctx)
+			if err != nil {
+				return false, err
+			}
+			if in.ca == nil || needsRenewal(in.ca, now) {
+				return false, fmt.Errorf("failed to save ca: %w", saveErr)
+			}
+			rotated = rotated || loaded
+		default:
+			return false, fmt.Errorf("failed to save ca: %w", saveErr)
+		}
+	}
+	if rotated || in.leaf == nil || needsRenewal(in.leaf, now) || in.leaf.CheckSignatureFrom(in.ca) != nil {
+		if err := in.issue(); err != nil {
+			return false, err
+		}
+		rotated = true
+	}
+	return rotated, nil
+}
+
+// loadCA use the CA in the CAStore if it differs from the current one,
+// returns if the CA is changed
+func (in *Manager) loadCA(ctx context.Context) (bool, error) {
+	if in.CAStore == nil {
+		return false, nil
+	}
+	certPEM, keyPEM, err := in.CAStore.Load(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to load ca: %w", err)
+	}
+	if len(certPEM) == 0 || bytes.Equal(certPEM, in.caCertPEM) {
+		return false, nil
+	}
+	return true, in.useCA(certPEM, keyPEM)
+}
+
+// useCA replace the current CA and keep it as the previous one
+func (in *Manager) useCA(certPEM []byte, keyPEM []byte) error {
+	ca, err := ParseCertificatePEM(certPEM)
+	if err != nil {
+		return fmt.Errorf("failed to parse ca: %w", err)
+	}
+	if in.ca != nil {
+		in.previousCA, in.prevCAPEM = in.ca, in.caCertPEM
+	}
+	in.ca, in.caCertPEM, in.caKeyPEM = ca, certPEM, keyPEM
+	return nil
+}
+
+func (in *Manager) newTemplateBuilder(commonName string) *TemplateBuilder {
+	return NewTemplateBuilder(commonName).
+		WithOrganization(in.Organization...).
+		WithKeyAlgorithm(in.KeyAlgorithm).
+		WithRSAKeySize(in.KeySize).
+		WithClock(in.Clock)
+}
+
+func (in *Manager) issue() error {
+	certPEM, keyPEM, err := in.newTemplateBuilder(in.CommonName).
+		WithDNSNames(in.DNSNames...).
+		WithIPs(in.IPs...).
+		WithValidity(in.CertValidity).
+		SignedBy(in.caCertPEM, in.caKeyPEM)
+	if err != nil {
+		return fmt.Errorf("failed to issue certificate: %w", err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return err
+	}
+	if in.leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return err
+	}
+	in.cert = &cert
+	if in.Dir != "" {
+		return writeFiles(in.Dir, map[string][]byte{CertName: certPEM, KeyName: keyPEM, CACertName: in.caBundle()})
+	}
+	return nil
+}
+
+// Start rotates the certificates and keeps checking them until the context is
+// done
+func (in *Manager) Start(ctx context.Context) error {
+	if _, err := in.Rotate(ctx); err != nil {
+		return err
+	}
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		rotated, err := in.Rotate(ctx)
+		if err != nil {
+			klog.Errorf("failed to rotate certificate: %v", err)
+		} else if rotated {
+			klog.Infof("certificate for %s rotated", in.CommonName)
+		}
+	}, in.CheckInterval)
+	return nil
+}
+
+// GetCertificate returns the current leaf certificate, which could be used
+// as the tls.Config.GetCertificate
+func (in *Manager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	if in.cert == nil {
+		return nil, fmt.Errorf("certificate for %s is not issued", in.CommonName)
+	}
+	return in.cert, nil
+}
+
+// CABundle returns the pem data of the CA for verifying the leaf certificate.
+// The previous CA is included until it expires so that the clients are not
+// broken during the CA rotation.
+func (in *Manager) CABundle() []byte {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	return in.caBundle()
+}
+
+func (in *Manager) caBundle() []byte {
+	bundle := append([]byte{}, in.caCertPEM...)
+	if in.previousCA != nil && in.Clock.Now().Before(in.previousCA.NotAfter) {
+		bundle = append(bundle, in.prevCAPEM...)
+	}
+	return bundle
+}
+
+// TLSConfig returns the tls config serving the managed certificate
+func (in *Manager) TLSConfig() *tls.Config {
+	return &tls.Config{GetCertificate: in.GetCertificate, MinVersion: tls.VersionTLS12}
+}
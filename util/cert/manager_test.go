@@ -0,0 +1,252 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cert_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubevela/pkg/util/cert"
+)
+
+func leafOf(t *testing.T, m *cert.Manager) *x509.Certificate {
+	c, err := m.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(c.Certificate[0])
+	require.NoError(t, err)
+	return leaf
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	clk := clocktesting.NewFakePassiveClock(time.Now())
+	m := cert.NewManager("test", []string{"localhost"}, []net.IP{net.ParseIP("127.0.0.1")})
+	m.KeySize = 1024
+	m.CAValidity, m.CertValidity = 30*time.Hour, 3*time.Hour
+	m.CAStore, m.Dir, m.Clock = cert.FileCAStore(dir), dir, clk
+
+	_, err := m.GetCertificate(nil)
+	require.Error(t, err)
+	rotated, err := m.Rotate(ctx)
+	require.NoError(t, err)
+	require.True(t, rotated)
+	leaf := leafOf(t, m)
+	require.Equal(t, []string{"localhost"}, leaf.DNSNames)
+	require.True(t, leaf.IPAddresses[0].Equal(net.ParseIP("127.0.0.1")))
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(m.CABundle()))
+	_, err = leaf.Verify(x509.VerifyOptions{Roots: pool, DNSName: "localhost"})
+	require.NoError(t, err)
+	for _, name := range []string{cert.CACertName, cert.CAKeyName, cert.CertName, cert.KeyName} {
+		require.FileExists(t, filepath.Join(dir, name))
+	}
+
+	// not expiring
+	clk.SetTime(clk.Now().Add(time.Hour))
+	rotated, err = m.Rotate(ctx)
+	require.NoError(t, err)
+	require.False(t, rotated)
+
+	// leaf expiring
+	clk.SetTime(clk.Now().Add(time.Hour + time.Minute))
+	rotated, err = m.Rotate(ctx)
+	require.NoError(t, err)
+	require.True(t, rotated)
+	require.NotEqual(t, leaf.SerialNumber, leafOf(t, m).SerialNumber)
+
+	// the CA is loaded from the store by another manager
+	other := cert.NewManager("test", []string{"localhost"}, nil)
+	other.KeySize, other.CAStore = 1024, cert.FileCAStore(dir)
+	_, err = other.Rotate(ctx)
+	require.NoError(t, err)
+	require.Equal(t, m.CABundle(), other.CABundle())
+
+	// ca expiring, the previous ca is kept in the bundle
+	clk.SetTime(clk.Now().Add(20 * time.Hour))
+	rotated, err = m.Rotate(ctx)
+	require.NoError(t, err)
+	require.True(t, rotated)
+	require.NotEqual(t, other.CABundle(), m.CABundle())
+	require.Contains(t, string(m.CABundle()), string(other.CABundle()))
+	require.NoError(t, leafOf(t, m).CheckSignatureFrom(mustParse(t, m.CABundle())))
+}
+
+func mustParse(t *testing.T, data []byte) *x509.Certificate {
+	c, err := cert.ParseCertificatePEM(data)
+	require.NoError(t, err)
+	return c
+}
+
+func TestManagerStart(t *testing.T) {
+	m := cert.NewManager("test", []string{"localhost"}, nil)
+	m.KeySize, m.CheckInterval = 1024, 10*time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	require.NoError(t, m.Start(ctx))
+	require.NotNil(t, m.TLSConfig().GetCertificate)
+	_, err := m.GetCertificate(nil)
+	require.NoError(t, err)
+
+	// the ca store is broken
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0600))
+	m = cert.NewManager("test", nil, nil)
+	m.CAStore = cert.FileCAStore(file)
+	require.Error(t, m.Start(context.Background()))
+}
+
+func TestSecretCAStore(t *testing.T) {
+	ctx := context.Background()
+	cli := fake.NewClientBuilder().Build()
+	store := &cert.SecretCAStore{Client: cli, Namespace: "vela-system", Name: "ca"}
+	certPEM, keyPEM, err := store.Load(ctx)
+	require.NoError(t, err)
+	require.Empty(t, certPEM)
+
+	m := cert.NewManager("test", []string{"localhost"}, nil)
+	m.KeySize, m.CAStore = 1024, store
+	_, err = m.Rotate(ctx)
+	require.NoError(t, err)
+	secret := &corev1.Secret{}
+	require.NoError(t, cli.Get(ctx, client.ObjectKey{Namespace: "vela-system", Name: "ca"}, secret))
+	require.Equal(t, m.CABundle(), secret.Data[cert.CACertName])
+
+	certPEM, keyPEM, err = store.Load(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, keyPEM)
+	require.NoError(t, store.Save(ctx, []byte("cert"), []byte("key")))
+	certPEM, _, err = store.Load(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []byte("cert"), certPEM)
+}
+
+// racingCAStore runs the hook once after loading the CA, which simulates
+// another replica saving the CA between the Load and the Save
+type racingCAStore struct {
+	cert.CAStore
+	hook func()
+}
+
+func (in *racingCAStore) Load(ctx context.Context) ([]byte, []byte, error) {
+	certPEM, keyPEM, err := in.CAStore.Load(ctx)
+	if hook := in.hook; hook != nil {
+		in.hook = nil
+		hook()
+	}
+	return certPEM, keyPEM, err
+}
+
+func TestSecretCAStoreReplicas(t *testing.T) {
+	ctx := context.Background()
+	cli := fake.NewClientBuilder().Build()
+	clk := clocktesting.NewFakePassiveClock(time.Now())
+	newReplica := func() (*cert.Manager, *racingCAStore) {
+		m := cert.NewManager("test", []string{"localhost"}, nil)
+		m.KeySize, m.Clock = 1024, clk
+		m.CAValidity, m.CertValidity = 30*time.Hour, 3*time.Hour
+		store := &racingCAStore{CAStore: &cert.SecretCAStore{Client: cli, Namespace: "vela-system", Name: "ca"}}
+		m.CAStore = store
+		return m, store
+	}
+	m1, _ := newReplica()
+	m2, s2 := newReplica()
+
+	// both replicas create the Secret at the same time
+	s2.hook = func() {
+		_, err := m1.Rotate(ctx)
+		require.NoError(t, err)
+	}
+	_, err := m2.Rotate(ctx)
+	require.NoError(t, err)
+	require.Equal(t, m1.CABundle(), m2.CABundle())
+	require.NoError(t, leafOf(t, m2).CheckSignatureFrom(mustParse(t, m1.CABundle())))
+
+	// the CA renewed by one replica is used by the other
+	clk.SetTime(clk.Now().Add(21 * time.Hour))
+	rotated, err := m1.Rotate(ctx)
+	require.NoError(t, err)
+	require.True(t, rotated)
+	rotated, err = m2.Rotate(ctx)
+	require.NoError(t, err)
+	require.True(t, rotated)
+	require.Equal(t, m1.CABundle(), m2.CABundle())
+
+	// both replicas renew the CA at the same time
+	clk.SetTime(clk.Now().Add(21 * time.Hour))
+	s2.hook = func() {
+		_, err := m1.Rotate(ctx)
+		require.NoError(t, err)
+	}
+	_, err = m2.Rotate(ctx)
+	require.NoError(t, err)
+	require.Equal(t, m1.CABundle(), m2.CABundle())
+	secret := &corev1.Secret{}
+	require.NoError(t, cli.Get(ctx, client.ObjectKey{Namespace: "vela-system", Name: "ca"}, secret))
+	require.Equal(t, secret.Data[cert.CACertName], m2.CABundle()[:len(secret.Data[cert.CACertName])])
+	require.NoError(t, leafOf(t, m2).CheckSignatureFrom(mustParse(t, secret.Data[cert.CACertName])))
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	m := cert.NewManager("test", []string{"localhost"}, nil)
+	m.KeySize, m.Dir = 1024, dir
+	_, err := m.Rotate(context.Background())
+	require.NoError(t, err)
+	certFile, keyFile := filepath.Join(dir, cert.CertName), filepath.Join(dir, cert.KeyName)
+
+	r, err := cert.NewReloader(certFile, keyFile)
+	require.NoError(t, err)
+	loaded, err := r.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	reloaded, err := r.Reload()
+	require.NoError(t, err)
+	require.False(t, reloaded)
+
+	// files rewritten by another issue
+	m2 := cert.NewManager("test", []string{"localhost"}, nil)
+	m2.KeySize, m2.Dir = 1024, dir
+	time.Sleep(10 * time.Millisecond)
+	_, err = m2.Rotate(context.Background())
+	require.NoError(t, err)
+	r.Interval = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = r.Start(ctx) }()
+	require.Eventually(t, func() bool {
+		current, err := r.GetCertificate(nil)
+		return err == nil && string(current.Certificate[0]) != string(loaded.Certificate[0])
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = cert.NewReloader(filepath.Join(dir, "not-exist"), keyFile)
+	require.Error(t, err)
+	require.NoError(t, os.WriteFile(certFile, []byte("bad"), 0600))
+	_, err = r.Reload()
+	require.Error(t, err)
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cert
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+const defaultReloadInterval = 10 * time.Second
+
+// Reloader serves the certificate from the files and reloads it when the files
+// change, such as the certificates mounted from Secret or rotated by Manager
+type Reloader struct {
+	CertFile string
+	KeyFile  string
+	Interval time.Duration
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	modTimes [2]time.Time
+}
+
+// NewReloader create the Reloader and load the certificate from the files
+func NewReloader(certFile string, keyFile string) (*Reloader, error) {
+	r := &Reloader{CertFile: certFile, KeyFile: keyFile, Interval: defaultReloadInterval}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads the certificate if the files are modified since the last load,
+// returns if the certificate is reloaded
+func (in *Reloader) Reload() (bool, error) {
+	var modTimes [2]time.Time
+	for i, file := range []string{in.CertFile, in.KeyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return false, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	in.mu.RLock()
+	unchanged := in.cert != nil && modTimes == in.modTimes
+	in.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+	cert, err := tls.LoadX509KeyPair(in.CertFile, in.KeyFile)
+	if err != nil {
+		return false, err
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.cert, in.modTimes = &cert, modTimes
+	return true, nil
+}
+
+// Start keeps reloading the certificate until the context is done
+func (in *Reloader) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		reloaded, err := in.Reload()
+		if err != nil {
+			klog.Errorf("failed to reload certificate from %s: %v", in.CertFile, err)
+		} else if reloaded {
+			klog.Infof("certificate reloaded from %s", in.CertFile)
+		}
+	}, in.Interval)
+	return nil
+}
+
+// GetCertificate returns the loaded certificate, which could be used as the
+// tls.Config.GetCertificate
+func (in *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	if in.cert == nil {
+		return nil, fmt.Errorf("certificate is not loaded from %s", in.CertFile)
+	}
+	return in.cert, nil
+}
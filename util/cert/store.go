@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cert
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SecretCAStore stores the CA in the Secret as ca.crt and ca.key, so that the
+// replicas of the server share the same CA. The Secret is only created or
+// updated if it is not changed since the last Load, so that the replicas
+// renewing the CA at the same time do not overwrite each other.
+type SecretCAStore struct {
+	Client    client.Client
+	Namespace string
+	Name      string
+
+	mu              sync.Mutex
+	resourceVersion string
+}
+
+// Load .
+func (in *SecretCAStore) Load(ctx context.Context) ([]byte, []byte, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	secret := &corev1.Secret{}
+	if err := in.Client.Get(ctx, client.ObjectKey{Namespace: in.Namespace, Name: in.Name}, secret); err != nil {
+		if kerrors.IsNotFound(err) {
+			in.resourceVersion = ""
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	in.resourceVersion = secret.ResourceVersion
+	return secret.Data[CACertName], secret.Data[CAKeyName], nil
+}
+
+// Save .
+func (in *SecretCAStore) Save(ctx context.Context, certPEM []byte, keyPEM []byte) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.resourceVersion == "" {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: in.Namespace, Name: in.Name},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{CACertName: certPEM, CAKeyName: keyPEM},
+		}
+		if err := in.Client.Create(ctx, secret); err != nil {
+			return err
+		}
+		in.resourceVersion = secret.ResourceVersion
+		return nil
+	}
+	secret := &corev1.Secret{}
+	if err := in.Client.Get(ctx, client.ObjectKey{Namespace: in.Namespace, Name: in.Name}, secret); err != nil {
+		if kerrors.IsNotFound(err) {
+			return kerrors.NewConflict(corev1.Resource("secrets"), in.Name, err)
+		}
+		return err
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[CACertName], secret.Data[CAKeyName] = certPEM, keyPEM
+	secret.ResourceVersion = in.resourceVersion
+	if err := in.Client.Update(ctx, secret); err != nil {
+		return err
+	}
+	in.resourceVersion = secret.ResourceVersion
+	return nil
+}
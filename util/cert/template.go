@@ -0,0 +1,324 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cert
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+const defaultValidity = 365 * 24 * time.Hour
+
+// TemplateBuilder builds the certificate template and issues the certificate
+// with the private key of the chosen algorithm
+type TemplateBuilder struct {
+	template     x509.Certificate
+	validity     time.Duration
+	keyUsageSet  bool
+	keyAlgorithm KeyAlgorithm
+	rsaKeySize   int
+	clock        clock.PassiveClock
+}
+
+// NewTemplateBuilder create the builder for the leaf certificate used for
+// both server and client auth, with RSA key and one year validity by default
+func NewTemplateBuilder(commonName string) *TemplateBuilder {
+	return &TemplateBuilder{
+		template: x509.Certificate{
+			Subject:               pkix.Name{CommonName: commonName},
+			ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+			BasicConstraintsValid: true,
+		},
+		validity:     defaultValidity,
+		keyAlgorithm: KeyAlgorithmRSA,
+		rsaKeySize:   defaultKeyLength,
+		clock:        clock.RealClock{},
+	}
+}
+
+// WithOrganization set the organization of the subject
+func (in *TemplateBuilder) WithOrganization(organization ...string) *TemplateBuilder {
+	in.template.Subject.Organization = organization
+	return in
+}
+
+// WithDNSNames add the dns names to the subject alternative names
+func (in *TemplateBuilder) WithDNSNames(dnsNames ...string) *TemplateBuilder {
+	in.template.DNSNames = append(in.template.DNSNames, dnsNames...)
+	return in
+}
+
+// WithIPs add the ips to the subject alternative names
+func (in *TemplateBuilder) WithIPs(ips ...net.IP) *TemplateBuilder {
+	in.template.IPAddresses = append(in.template.IPAddresses, ips...)
+	return in
+}
+
+// WithValidity set the validity counted from now
+func (in *TemplateBuilder) WithValidity(validity time.Duration) *TemplateBuilder {
+	in.validity = validity
+	return in
+}
+
+// WithClock set the clock for counting the validity
+func (in *TemplateBuilder) WithClock(c clock.PassiveClock) *TemplateBuilder {
+	in.clock = c
+	return in
+}
+
+// WithKeyUsage set the key usage, by default it is decided by the key
+// algorithm and whether the certificate is CA
+func (in *TemplateBuilder) WithKeyUsage(usage x509.KeyUsage) *TemplateBuilder {
+	in.template.KeyUsage, in.keyUsageSet = usage, true
+	return in
+}
+
+// WithExtKeyUsage set the extended key usages
+func (in *TemplateBuilder) WithExtKeyUsage(usages ...x509.ExtKeyUsage) *TemplateBuilder {
+	in.template.ExtKeyUsage = usages
+	return in
+}
+
+// WithKeyAlgorithm set the algorithm of the generated private key
+func (in *TemplateBuilder) WithKeyAlgorithm(algorithm KeyAlgorithm) *TemplateBuilder {
+	in.keyAlgorithm = algorithm
+	return in
+}
+
+// WithRSAKeySize set the size of the generated RSA private key
+func (in *TemplateBuilder) WithRSAKeySize(size int) *TemplateBuilder {
+	in.rsaKeySize = size
+	return in
+}
+
+// AsCA make the certificate a CA which could sign other certificates
+func (in *TemplateBuilder) AsCA() *TemplateBuilder {
+	in.template.IsCA = true
+	return in
+}
+
+// Template returns the certificate template with a random serial number and
+// the validity counted from now
+func (in *TemplateBuilder) Template() (*x509.Certificate, error) {
+	template := in.template
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serial
+	template.NotBefore = in.clock.Now()
+	template.NotAfter = template.NotBefore.Add(in.validity)
+	if !in.keyUsageSet {
+		template.KeyUsage = defaultKeyUsage(in.keyAlgorithm == KeyAlgorithmRSA || in.keyAlgorithm == "", template.IsCA)
+	}
+	return &template, nil
+}
+
+func defaultKeyUsage(rsaKey bool, isCA bool) x509.KeyUsage {
+	usage := x509.KeyUsageDigitalSignature
+	if isCA {
+		usage |= x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	} else if rsaKey {
+		usage |= x509.KeyUsageKeyEncipherment
+	}
+	return usage
+}
+
+// SelfSigned issue the self-signed certificate.
+// Certificate and PrivateKey pem data are returned
+func (in *TemplateBuilder) SelfSigned() ([]byte, []byte, error) {
+	return in.issue(nil, nil)
+}
+
+// SignedBy issue the certificate signed by the ca certificate and the ca
+// private key in pem format. The validity is capped by the ca.
+// Certificate and PrivateKey pem data are returned
+func (in *TemplateBuilder) SignedBy(caCertPEM []byte, caKeyPEM []byte) ([]byte, []byte, error) {
+	ca, err := ParseCertificatePEM(caCertPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	caKey, err := ParsePrivateKeyPEM(caKeyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	return in.issue(ca, caKey)
+}
+
+func (in *TemplateBuilder) issue(ca *x509.Certificate, caKey crypto.Signer) ([]byte, []byte, error) {
+	template, err := in.Template()
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := GenerateKey(in.keyAlgorithm, in.rsaKeySize)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ca == nil {
+		ca, caKey = template, key
+	} else if template.NotAfter.After(ca.NotAfter) {
+		template.NotAfter = ca.NotAfter
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, ca, key.Public(), caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyData, err := EncodePrivateKeyPEM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), keyData, nil
+}
+
+// CertificateRequest generate the certificate request with the subject and
+// the subject alternative names of the builder.
+// CertificateRequest and PrivateKey pem data are returned
+func (in *TemplateBuilder) CertificateRequest() ([]byte, []byte, error) {
+	key, err := GenerateKey(in.keyAlgorithm, in.rsaKeySize)
+	if err != nil {
+		return nil, nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:     in.template.Subject,
+		DNSNames:    in.template.DNSNames,
+		IPAddresses: in.template.IPAddresses,
+	}, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyData, err := EncodePrivateKeyPEM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr}), keyData, nil
+}
+
+// ParseCertificateRequestPEM parse the certificate request in the given pem
+// data and check its signature
+func ParseCertificateRequestPEM(data []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("failed to decode certificate request pem")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if err = csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid certificate request signature: %w", err)
+	}
+	return csr, nil
+}
+
+// ReservedCommonNamePrefix the prefix of the users reserved by kubernetes
+const ReservedCommonNamePrefix = "system:"
+
+// CertificateRequestValidator validate the certificate request before signing,
+// such as the common name and the subject alternative names
+type CertificateRequestValidator func(csr *x509.CertificateRequest) error
+
+// AllowCommonNames create the CertificateRequestValidator which rejects the
+// requests whose common name is not in names
+func AllowCommonNames(names ...string) CertificateRequestValidator {
+	return func(csr *x509.CertificateRequest) error {
+		if !slices.Contains(names, csr.Subject.CommonName) {
+			return fmt.Errorf("common name %q of the certificate request is not allowed", csr.Subject.CommonName)
+		}
+		return nil
+	}
+}
+
+// DefaultCertificateRequestValidator rejects the requests whose common name
+// has the ReservedCommonNamePrefix, as the common name is treated as the user
+// by kubernetes
+func DefaultCertificateRequestValidator(csr *x509.CertificateRequest) error {
+	if strings.HasPrefix(csr.Subject.CommonName, ReservedCommonNamePrefix) {
+		return fmt.Errorf("common name %q of the certificate request is reserved", csr.Subject.CommonName)
+	}
+	return nil
+}
+
+// SignCertificateRequest issue the certificate for the certificate request,
+// such as the one generated by GenerateCertificateRequest, signed by the ca
+// certificate and the ca private key in pem format. The subject and the
+// subject alternative names are taken from the request, the certificate is
+// used for client auth if no extended key usage is given.
+// As the organizations of the subject are treated as the groups by kubernetes,
+// the request is rejected if it asks for any organization not in
+// allowedOrganizations. The request is also validated by validate, which
+// defaults to DefaultCertificateRequestValidator if nil.
+func SignCertificateRequest(csrPEM []byte, caCertPEM []byte, caKeyPEM []byte, validity time.Duration, allowedOrganizations []string, validate CertificateRequestValidator, usages ...x509.ExtKeyUsage) ([]byte, error) {
+	csr, err := ParseCertificateRequestPEM(csrPEM)
+	if err != nil {
+		return nil, err
+	}
+	for _, org := range csr.Subject.Organization {
+		if !slices.Contains(allowedOrganizations, org) {
+			return nil, fmt.Errorf("organization %q of the certificate request is not allowed", org)
+		}
+	}
+	if validate == nil {
+		validate = DefaultCertificateRequestValidator
+	}
+	if err = validate(csr); err != nil {
+		return nil, err
+	}
+	ca, err := ParseCertificatePEM(caCertPEM)
+	if err != nil {
+		return nil, err
+	}
+	caKey, err := ParsePrivateKeyPEM(caKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if len(usages) == 0 {
+		usages = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	_, rsaKey := csr.PublicKey.(*rsa.PublicKey)
+	builder := NewTemplateBuilder(csr.Subject.CommonName).
+		WithDNSNames(csr.DNSNames...).
+		WithIPs(csr.IPAddresses...).
+		WithValidity(validity).
+		WithExtKeyUsage(usages...).
+		WithKeyUsage(defaultKeyUsage(rsaKey, false))
+	template, err := builder.Template()
+	if err != nil {
+		return nil, err
+	}
+	template.Subject = csr.Subject
+	template.URIs, template.EmailAddresses = csr.URIs, csr.EmailAddresses
+	if template.NotAfter.After(ca.NotAfter) {
+		template.NotAfter = ca.NotAfter
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, ca, csr.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), nil
+}
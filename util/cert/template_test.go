@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cert_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/pkg/util/cert"
+)
+
+func TestTemplateBuilder(t *testing.T) {
+	for _, algorithm := range []cert.KeyAlgorithm{cert.KeyAlgorithmRSA, cert.KeyAlgorithmECDSAP256, cert.KeyAlgorithmECDSAP384, cert.KeyAlgorithmEd25519} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			caCert, caKey, err := cert.NewTemplateBuilder("ca").
+				WithOrganization("kubevela").
+				WithKeyAlgorithm(algorithm).
+				WithRSAKeySize(1024).
+				WithValidity(time.Hour).
+				AsCA().
+				SelfSigned()
+			require.NoError(t, err)
+			ca, err := cert.ParseCertificatePEM(caCert)
+			require.NoError(t, err)
+			require.True(t, ca.IsCA)
+			require.Equal(t, x509.KeyUsageDigitalSignature|x509.KeyUsageCertSign|x509.KeyUsageCRLSign, ca.KeyUsage)
+			require.Equal(t, []string{"kubevela"}, ca.Subject.Organization)
+
+			certPEM, keyPEM, err := cert.NewTemplateBuilder("server").
+				WithKeyAlgorithm(algorithm).
+				WithRSAKeySize(1024).
+				WithDNSNames("localhost").
+				WithIPs(net.ParseIP("127.0.0.1")).
+				WithValidity(2*time.Hour).
+				WithExtKeyUsage(x509.ExtKeyUsageServerAuth).
+				SignedBy(caCert, caKey)
+			require.NoError(t, err)
+			_, err = tls.X509KeyPair(certPEM, keyPEM)
+			require.NoError(t, err)
+			key, err := cert.ParsePrivateKeyPEM(keyPEM)
+			require.NoError(t, err)
+			switch algorithm {
+			case cert.KeyAlgorithmRSA:
+				require.IsType(t, &rsa.PrivateKey{}, key)
+			case cert.KeyAlgorithmEd25519:
+				require.IsType(t, ed25519.PrivateKey{}, key)
+			default:
+				require.IsType(t, &ecdsa.PrivateKey{}, key)
+			}
+			leaf, err := cert.ParseCertificatePEM(certPEM)
+			require.NoError(t, err)
+			require.Equal(t, ca.NotAfter.Unix(), leaf.NotAfter.Unix())
+			require.Equal(t, algorithm == cert.KeyAlgorithmRSA, leaf.KeyUsage&x509.KeyUsageKeyEncipherment != 0)
+			pool := x509.NewCertPool()
+			pool.AddCert(ca)
+			_, err = leaf.Verify(x509.VerifyOptions{Roots: pool, DNSName: "localhost", KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+			require.NoError(t, err)
+			_, err = leaf.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+			require.Error(t, err)
+		})
+	}
+
+	template, err := cert.NewTemplateBuilder("x").WithKeyUsage(x509.KeyUsageDigitalSignature).Template()
+	require.NoError(t, err)
+	require.Equal(t, x509.KeyUsageDigitalSignature, template.KeyUsage)
+	_, _, err = cert.NewTemplateBuilder("x").WithKeyAlgorithm("DSA").SelfSigned()
+	require.Error(t, err)
+	_, _, err = cert.NewTemplateBuilder("x").SignedBy([]byte("bad"), nil)
+	require.Error(t, err)
+}
+
+func TestSignCertificateRequest(t *testing.T) {
+	caCert, caKey, err := cert.NewTemplateBuilder("ca").WithKeyAlgorithm(cert.KeyAlgorithmECDSAP256).AsCA().SelfSigned()
+	require.NoError(t, err)
+	ca, err := cert.ParseCertificatePEM(caCert)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	csrPEM, keyPEM, err := cert.GenerateCertificateRequest(1024, "cluster-gateway", []string{"kubevela:client"})
+	require.NoError(t, err)
+	certPEM, err := cert.SignCertificateRequest(csrPEM, caCert, caKey, time.Hour, []string{"kubevela:client"}, cert.AllowCommonNames("cluster-gateway"))
+	require.NoError(t, err)
+	_, err = tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	clientCert, err := cert.ParseCertificatePEM(certPEM)
+	require.NoError(t, err)
+	require.Equal(t, "cluster-gateway", clientCert.Subject.CommonName)
+	require.Equal(t, []string{"kubevela:client"}, clientCert.Subject.Organization)
+	_, err = clientCert.Verify(x509.VerifyOptions{Roots: pool, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	require.NoError(t, err)
+
+	csrPEM, _, err = cert.NewTemplateBuilder("provider").
+		WithKeyAlgorithm(cert.KeyAlgorithmEd25519).
+		WithDNSNames("provider.vela-system.svc").
+		CertificateRequest()
+	require.NoError(t, err)
+	certPEM, err = cert.SignCertificateRequest(csrPEM, caCert, caKey, time.Hour, nil, nil, x509.ExtKeyUsageServerAuth)
+	require.NoError(t, err)
+	serverCert, err := cert.ParseCertificatePEM(certPEM)
+	require.NoError(t, err)
+	_, err = serverCert.Verify(x509.VerifyOptions{Roots: pool, DNSName: "provider.vela-system.svc"})
+	require.NoError(t, err)
+
+	_, err = cert.SignCertificateRequest([]byte("bad"), caCert, caKey, time.Hour, nil, nil)
+	require.Error(t, err)
+	_, err = cert.SignCertificateRequest(csrPEM, []byte("bad"), caKey, time.Hour, nil, nil)
+	require.Error(t, err)
+	_, err = cert.SignCertificateRequest(csrPEM, caCert, []byte("bad"), time.Hour, nil, nil)
+	require.Error(t, err)
+	csrPEM, _, err = cert.GenerateCertificateRequest(1024, "cluster-gateway", []string{"kubevela:client", "system:masters"})
+	require.NoError(t, err)
+	_, err = cert.SignCertificateRequest(csrPEM, caCert, caKey, time.Hour, []string{"kubevela:client"}, nil)
+	require.ErrorContains(t, err, `organization "system:masters" of the certificate request is not allowed`)
+	require.Error(t, err)
+	csrPEM, _, err = cert.GenerateCertificateRequest(1024, "system:kube-controller-manager", nil)
+	require.NoError(t, err)
+	_, err = cert.SignCertificateRequest(csrPEM, caCert, caKey, time.Hour, nil, nil)
+	require.ErrorContains(t, err, `common name "system:kube-controller-manager" of the certificate request is reserved`)
+	_, err = cert.SignCertificateRequest(csrPEM, caCert, caKey, time.Hour, nil, cert.AllowCommonNames("cluster-gateway"))
+	require.ErrorContains(t, err, `common name "system:kube-controller-manager" of the certificate request is not allowed`)
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonutil
+
+import (
+	"regexp"
+	"strings"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+var simplePathRegex = regexp.MustCompile(`^\.?[A-Za-z0-9_\-]+(\.[A-Za-z0-9_\-]+)*$`)
+
+// JSONPath evaluates the JSONPath expression over obj and returns all matched
+// results. Plain dotted paths like `spec.replicas` are resolved by LookupPath,
+// others use the kubectl style syntax like `{.items[*].metadata.name}`.
+func JSONPath(obj any, expr string) ([]any, error) {
+	expr = strings.TrimSpace(expr)
+	expr = strings.TrimPrefix(expr, "$")
+	if simplePathRegex.MatchString(expr) {
+		val, err := LookupPath(obj, strings.TrimPrefix(expr, "."))
+		if err != nil || val == nil {
+			return []any{}, err
+		}
+		return []any{val}, nil
+	}
+	if !strings.HasPrefix(expr, "{") {
+		expr = "{" + expr + "}"
+	}
+	jp := jsonpath.New("jsonpath").AllowMissingKeys(true)
+	if err := jp.Parse(expr); err != nil {
+		return nil, err
+	}
+	results, err := jp.FindResults(obj)
+	if err != nil {
+		return nil, err
+	}
+	out := []any{}
+	for _, rs := range results {
+		for _, r := range rs {
+			out = append(out, r.Interface())
+		}
+	}
+	return out, nil
+}
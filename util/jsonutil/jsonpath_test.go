@@ -0,0 +1,63 @@
+/*
+Copyright 2023 The KubeVela Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonutil_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubevela/pkg/util/jsonutil"
+)
+
+func TestJSONPath(t *testing.T) {
+	obj := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"spec": {"replicas": 3},
+		"items": [
+			{"metadata": {"name": "a"}, "status": "ready"},
+			{"metadata": {"name": "b"}, "status": "pending"}
+		]
+	}`), &obj))
+	testcases := map[string]struct {
+		Expr   string
+		Output []any
+		Error  bool
+	}{
+		"simple":          {Expr: "spec.replicas", Output: []any{float64(3)}},
+		"simple-dot":      {Expr: ".items.1.status", Output: []any{"pending"}},
+		"simple-missing":  {Expr: "spec.missing", Output: []any{}},
+		"dollar":          {Expr: "$.spec.replicas", Output: []any{float64(3)}},
+		"wildcard":        {Expr: "{.items[*].metadata.name}", Output: []any{"a", "b"}},
+		"without-bracket": {Expr: ".items[*].metadata.name", Output: []any{"a", "b"}},
+		"filter":          {Expr: `{.items[?(@.status=="ready")].metadata.name}`, Output: []any{"a"}},
+		"missing":         {Expr: "{.items[*].missing}", Output: []any{}},
+		"bad":             {Expr: "{.items[}", Error: true},
+	}
+	for name, tt := range testcases {
+		t.Run(name, func(t *testing.T) {
+			out, err := jsonutil.JSONPath(obj, tt.Expr)
+			if tt.Error {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.Output, out)
+		})
+	}
+}
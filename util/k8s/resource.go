@@ -60,6 +60,12 @@ func GetGVKFromResource(resource ResourceIdentifier) (schema.GroupVersionKind, e
 
 // GetUnstructuredFromResource returns an unstructured object for the provided resource identifier.
 func GetUnstructuredFromResource(ctx context.Context, resource ResourceIdentifier) (*unstructured.Unstructured, error) {
+	return GetUnstructuredFromResourceWithClient(ctx, singleton.KubeClient.Get(), resource)
+}
+
+// GetUnstructuredFromResourceWithClient returns an unstructured object for the
+// provided resource identifier with the given client.
+func GetUnstructuredFromResourceWithClient(ctx context.Context, cli client.Client, resource ResourceIdentifier) (*unstructured.Unstructured, error) {
 	gvk, err := GetGVKFromResource(resource)
 	if err != nil {
 		return nil, err
@@ -73,7 +79,7 @@ func GetUnstructuredFromResource(ctx context.Context, resource ResourceIdentifie
 	if isNamespaced {
 		un.SetNamespace(resource.Namespace)
 	}
-	if err := singleton.KubeClient.Get().Get(ctx, client.ObjectKey{Name: resource.Name, Namespace: resource.Namespace}, un); err != nil {
+	if err := cli.Get(ctx, client.ObjectKey{Name: resource.Name, Namespace: resource.Namespace}, un); err != nil {
 		return nil, err
 	}
 	return un, nil